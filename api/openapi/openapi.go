@@ -0,0 +1,28 @@
+// Package openapi 内嵌 HTTP 接口的 OpenAPI 3 描述文档
+// 新增或修改接口时请同步更新 openapi.json
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+// Spec 返回原始 OpenAPI 文档
+func Spec() []byte {
+	return spec
+}
+
+// Handler 以 application/json 输出 OpenAPI 文档
+func Handler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	_, _ = w.Write(spec)
+}
@@ -0,0 +1,640 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.28.3
+// source: resolver.proto
+
+package resolverpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ResolveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ip            string                 `protobuf:"bytes,1,opt,name=ip,proto3" json:"ip,omitempty"`
+	Wait          bool                   `protobuf:"varint,2,opt,name=wait,proto3" json:"wait,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResolveRequest) Reset() {
+	*x = ResolveRequest{}
+	mi := &file_resolver_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResolveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolveRequest) ProtoMessage() {}
+
+func (x *ResolveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_resolver_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolveRequest.ProtoReflect.Descriptor instead.
+func (*ResolveRequest) Descriptor() ([]byte, []int) {
+	return file_resolver_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ResolveRequest) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+func (x *ResolveRequest) GetWait() bool {
+	if x != nil {
+		return x.Wait
+	}
+	return false
+}
+
+type ResolveResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Ip       string                 `protobuf:"bytes,1,opt,name=ip,proto3" json:"ip,omitempty"`
+	Key      string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Tag      string                 `protobuf:"bytes,3,opt,name=tag,proto3" json:"tag,omitempty"`
+	Province string                 `protobuf:"bytes,4,opt,name=province,proto3" json:"province,omitempty"`
+	Isp      string                 `protobuf:"bytes,5,opt,name=isp,proto3" json:"isp,omitempty"`
+	// HIT / REFRESH / MISS / REJECTED
+	Cache      string `protobuf:"bytes,6,opt,name=cache,proto3" json:"cache,omitempty"`
+	TtlSeconds int64  `protobuf:"varint,7,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	// 单个 IP 的错误 (仅批量查询中使用)
+	Error         string `protobuf:"bytes,8,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResolveResponse) Reset() {
+	*x = ResolveResponse{}
+	mi := &file_resolver_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResolveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolveResponse) ProtoMessage() {}
+
+func (x *ResolveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_resolver_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolveResponse.ProtoReflect.Descriptor instead.
+func (*ResolveResponse) Descriptor() ([]byte, []int) {
+	return file_resolver_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ResolveResponse) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+func (x *ResolveResponse) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ResolveResponse) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *ResolveResponse) GetProvince() string {
+	if x != nil {
+		return x.Province
+	}
+	return ""
+}
+
+func (x *ResolveResponse) GetIsp() string {
+	if x != nil {
+		return x.Isp
+	}
+	return ""
+}
+
+func (x *ResolveResponse) GetCache() string {
+	if x != nil {
+		return x.Cache
+	}
+	return ""
+}
+
+func (x *ResolveResponse) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *ResolveResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type BatchResolveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ips           []string               `protobuf:"bytes,1,rep,name=ips,proto3" json:"ips,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchResolveRequest) Reset() {
+	*x = BatchResolveRequest{}
+	mi := &file_resolver_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchResolveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchResolveRequest) ProtoMessage() {}
+
+func (x *BatchResolveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_resolver_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchResolveRequest.ProtoReflect.Descriptor instead.
+func (*BatchResolveRequest) Descriptor() ([]byte, []int) {
+	return file_resolver_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *BatchResolveRequest) GetIps() []string {
+	if x != nil {
+		return x.Ips
+	}
+	return nil
+}
+
+type BatchResolveResponse struct {
+	state         protoimpl.MessageState      `protogen:"open.v1"`
+	Results       map[string]*ResolveResponse `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchResolveResponse) Reset() {
+	*x = BatchResolveResponse{}
+	mi := &file_resolver_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchResolveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchResolveResponse) ProtoMessage() {}
+
+func (x *BatchResolveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_resolver_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchResolveResponse.ProtoReflect.Descriptor instead.
+func (*BatchResolveResponse) Descriptor() ([]byte, []int) {
+	return file_resolver_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *BatchResolveResponse) GetResults() map[string]*ResolveResponse {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type InvalidateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ips           []string               `protobuf:"bytes,1,rep,name=ips,proto3" json:"ips,omitempty"`
+	Keys          []string               `protobuf:"bytes,2,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InvalidateRequest) Reset() {
+	*x = InvalidateRequest{}
+	mi := &file_resolver_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InvalidateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InvalidateRequest) ProtoMessage() {}
+
+func (x *InvalidateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_resolver_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InvalidateRequest.ProtoReflect.Descriptor instead.
+func (*InvalidateRequest) Descriptor() ([]byte, []int) {
+	return file_resolver_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *InvalidateRequest) GetIps() []string {
+	if x != nil {
+		return x.Ips
+	}
+	return nil
+}
+
+func (x *InvalidateRequest) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type InvalidateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Removed       int32                  `protobuf:"varint,1,opt,name=removed,proto3" json:"removed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InvalidateResponse) Reset() {
+	*x = InvalidateResponse{}
+	mi := &file_resolver_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InvalidateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InvalidateResponse) ProtoMessage() {}
+
+func (x *InvalidateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_resolver_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InvalidateResponse.ProtoReflect.Descriptor instead.
+func (*InvalidateResponse) Descriptor() ([]byte, []int) {
+	return file_resolver_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *InvalidateResponse) GetRemoved() int32 {
+	if x != nil {
+		return x.Removed
+	}
+	return 0
+}
+
+type StatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsRequest) Reset() {
+	*x = StatsRequest{}
+	mi := &file_resolver_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsRequest) ProtoMessage() {}
+
+func (x *StatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_resolver_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return file_resolver_proto_rawDescGZIP(), []int{6}
+}
+
+type HotKey struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Count         uint64                 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HotKey) Reset() {
+	*x = HotKey{}
+	mi := &file_resolver_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HotKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HotKey) ProtoMessage() {}
+
+func (x *HotKey) ProtoReflect() protoreflect.Message {
+	mi := &file_resolver_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HotKey.ProtoReflect.Descriptor instead.
+func (*HotKey) Descriptor() ([]byte, []int) {
+	return file_resolver_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *HotKey) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *HotKey) GetCount() uint64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type StatsResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	CacheItems     int64                  `protobuf:"varint,1,opt,name=cache_items,json=cacheItems,proto3" json:"cache_items,omitempty"`
+	DroppedUpdates int64                  `protobuf:"varint,2,opt,name=dropped_updates,json=droppedUpdates,proto3" json:"dropped_updates,omitempty"`
+	RetriedUpdates int64                  `protobuf:"varint,3,opt,name=retried_updates,json=retriedUpdates,proto3" json:"retried_updates,omitempty"`
+	QueueLength    int64                  `protobuf:"varint,4,opt,name=queue_length,json=queueLength,proto3" json:"queue_length,omitempty"`
+	HotKeys        []*HotKey              `protobuf:"bytes,5,rep,name=hot_keys,json=hotKeys,proto3" json:"hot_keys,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *StatsResponse) Reset() {
+	*x = StatsResponse{}
+	mi := &file_resolver_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsResponse) ProtoMessage() {}
+
+func (x *StatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_resolver_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsResponse.ProtoReflect.Descriptor instead.
+func (*StatsResponse) Descriptor() ([]byte, []int) {
+	return file_resolver_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *StatsResponse) GetCacheItems() int64 {
+	if x != nil {
+		return x.CacheItems
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetDroppedUpdates() int64 {
+	if x != nil {
+		return x.DroppedUpdates
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetRetriedUpdates() int64 {
+	if x != nil {
+		return x.RetriedUpdates
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetQueueLength() int64 {
+	if x != nil {
+		return x.QueueLength
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetHotKeys() []*HotKey {
+	if x != nil {
+		return x.HotKeys
+	}
+	return nil
+}
+
+var File_resolver_proto protoreflect.FileDescriptor
+
+const file_resolver_proto_rawDesc = "" +
+	"\n" +
+	"\x0eresolver.proto\x12\ripresolver.v1\"4\n" +
+	"\x0eResolveRequest\x12\x0e\n" +
+	"\x02ip\x18\x01 \x01(\tR\x02ip\x12\x12\n" +
+	"\x04wait\x18\x02 \x01(\bR\x04wait\"\xc0\x01\n" +
+	"\x0fResolveResponse\x12\x0e\n" +
+	"\x02ip\x18\x01 \x01(\tR\x02ip\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12\x10\n" +
+	"\x03tag\x18\x03 \x01(\tR\x03tag\x12\x1a\n" +
+	"\bprovince\x18\x04 \x01(\tR\bprovince\x12\x10\n" +
+	"\x03isp\x18\x05 \x01(\tR\x03isp\x12\x14\n" +
+	"\x05cache\x18\x06 \x01(\tR\x05cache\x12\x1f\n" +
+	"\vttl_seconds\x18\a \x01(\x03R\n" +
+	"ttlSeconds\x12\x14\n" +
+	"\x05error\x18\b \x01(\tR\x05error\"'\n" +
+	"\x13BatchResolveRequest\x12\x10\n" +
+	"\x03ips\x18\x01 \x03(\tR\x03ips\"\xbe\x01\n" +
+	"\x14BatchResolveResponse\x12J\n" +
+	"\aresults\x18\x01 \x03(\v20.ipresolver.v1.BatchResolveResponse.ResultsEntryR\aresults\x1aZ\n" +
+	"\fResultsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x124\n" +
+	"\x05value\x18\x02 \x01(\v2\x1e.ipresolver.v1.ResolveResponseR\x05value:\x028\x01\"9\n" +
+	"\x11InvalidateRequest\x12\x10\n" +
+	"\x03ips\x18\x01 \x03(\tR\x03ips\x12\x12\n" +
+	"\x04keys\x18\x02 \x03(\tR\x04keys\".\n" +
+	"\x12InvalidateResponse\x12\x18\n" +
+	"\aremoved\x18\x01 \x01(\x05R\aremoved\"\x0e\n" +
+	"\fStatsRequest\"0\n" +
+	"\x06HotKey\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x04R\x05count\"\xd7\x01\n" +
+	"\rStatsResponse\x12\x1f\n" +
+	"\vcache_items\x18\x01 \x01(\x03R\n" +
+	"cacheItems\x12'\n" +
+	"\x0fdropped_updates\x18\x02 \x01(\x03R\x0edroppedUpdates\x12'\n" +
+	"\x0fretried_updates\x18\x03 \x01(\x03R\x0eretriedUpdates\x12!\n" +
+	"\fqueue_length\x18\x04 \x01(\x03R\vqueueLength\x120\n" +
+	"\bhot_keys\x18\x05 \x03(\v2\x15.ipresolver.v1.HotKeyR\ahotKeys2\xc4\x02\n" +
+	"\bResolver\x12H\n" +
+	"\aResolve\x12\x1d.ipresolver.v1.ResolveRequest\x1a\x1e.ipresolver.v1.ResolveResponse\x12W\n" +
+	"\fBatchResolve\x12\".ipresolver.v1.BatchResolveRequest\x1a#.ipresolver.v1.BatchResolveResponse\x12Q\n" +
+	"\n" +
+	"Invalidate\x12 .ipresolver.v1.InvalidateRequest\x1a!.ipresolver.v1.InvalidateResponse\x12B\n" +
+	"\x05Stats\x12\x1b.ipresolver.v1.StatsRequest\x1a\x1c.ipresolver.v1.StatsResponseB\x1cZ\x1aip-resolver/api/resolverpbb\x06proto3"
+
+var (
+	file_resolver_proto_rawDescOnce sync.Once
+	file_resolver_proto_rawDescData []byte
+)
+
+func file_resolver_proto_rawDescGZIP() []byte {
+	file_resolver_proto_rawDescOnce.Do(func() {
+		file_resolver_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_resolver_proto_rawDesc), len(file_resolver_proto_rawDesc)))
+	})
+	return file_resolver_proto_rawDescData
+}
+
+var file_resolver_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_resolver_proto_goTypes = []any{
+	(*ResolveRequest)(nil),       // 0: ipresolver.v1.ResolveRequest
+	(*ResolveResponse)(nil),      // 1: ipresolver.v1.ResolveResponse
+	(*BatchResolveRequest)(nil),  // 2: ipresolver.v1.BatchResolveRequest
+	(*BatchResolveResponse)(nil), // 3: ipresolver.v1.BatchResolveResponse
+	(*InvalidateRequest)(nil),    // 4: ipresolver.v1.InvalidateRequest
+	(*InvalidateResponse)(nil),   // 5: ipresolver.v1.InvalidateResponse
+	(*StatsRequest)(nil),         // 6: ipresolver.v1.StatsRequest
+	(*HotKey)(nil),               // 7: ipresolver.v1.HotKey
+	(*StatsResponse)(nil),        // 8: ipresolver.v1.StatsResponse
+	nil,                          // 9: ipresolver.v1.BatchResolveResponse.ResultsEntry
+}
+var file_resolver_proto_depIdxs = []int32{
+	9, // 0: ipresolver.v1.BatchResolveResponse.results:type_name -> ipresolver.v1.BatchResolveResponse.ResultsEntry
+	7, // 1: ipresolver.v1.StatsResponse.hot_keys:type_name -> ipresolver.v1.HotKey
+	1, // 2: ipresolver.v1.BatchResolveResponse.ResultsEntry.value:type_name -> ipresolver.v1.ResolveResponse
+	0, // 3: ipresolver.v1.Resolver.Resolve:input_type -> ipresolver.v1.ResolveRequest
+	2, // 4: ipresolver.v1.Resolver.BatchResolve:input_type -> ipresolver.v1.BatchResolveRequest
+	4, // 5: ipresolver.v1.Resolver.Invalidate:input_type -> ipresolver.v1.InvalidateRequest
+	6, // 6: ipresolver.v1.Resolver.Stats:input_type -> ipresolver.v1.StatsRequest
+	1, // 7: ipresolver.v1.Resolver.Resolve:output_type -> ipresolver.v1.ResolveResponse
+	3, // 8: ipresolver.v1.Resolver.BatchResolve:output_type -> ipresolver.v1.BatchResolveResponse
+	5, // 9: ipresolver.v1.Resolver.Invalidate:output_type -> ipresolver.v1.InvalidateResponse
+	8, // 10: ipresolver.v1.Resolver.Stats:output_type -> ipresolver.v1.StatsResponse
+	7, // [7:11] is the sub-list for method output_type
+	3, // [3:7] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_resolver_proto_init() }
+func file_resolver_proto_init() {
+	if File_resolver_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_resolver_proto_rawDesc), len(file_resolver_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_resolver_proto_goTypes,
+		DependencyIndexes: file_resolver_proto_depIdxs,
+		MessageInfos:      file_resolver_proto_msgTypes,
+	}.Build()
+	File_resolver_proto = out.File
+	file_resolver_proto_goTypes = nil
+	file_resolver_proto_depIdxs = nil
+}
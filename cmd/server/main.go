@@ -2,14 +2,35 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"flag"
+	"fmt"
+	"ip-resolver/api/resolverpb"
+	"ip-resolver/api/openapi"
+	"ip-resolver/internal/accesslog"
+	"ip-resolver/internal/admin"
+	"ip-resolver/internal/alert"
+	"ip-resolver/internal/backup"
+	"ip-resolver/internal/compress"
 	"ip-resolver/internal/config"
+	"ip-resolver/internal/dnsserver"
+	"ip-resolver/internal/errreport"
+	"ip-resolver/internal/graceful"
+	"ip-resolver/internal/grpcserver"
+	"ip-resolver/internal/ipacl"
+	"ip-resolver/internal/logging"
+	"ip-resolver/internal/tlsutil"
 	"ip-resolver/internal/monitor"
 	"ip-resolver/internal/provider"
+	"ip-resolver/internal/promexport"
+	"ip-resolver/internal/remoteconfig"
+	"ip-resolver/internal/requestid"
+	"ip-resolver/internal/statsd"
+	"ip-resolver/internal/version"
 	"ip-resolver/internal/worker"
 	"io"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
@@ -18,55 +39,110 @@ import (
 	"sync"
 	"syscall"
 	"time"
+
+	"google.golang.org/grpc"
 )
 
+var initLog = logging.For("Init")
+
 func main() {
 	// 1. 解析配置
 	configPath := flag.String("c", "config.yaml", "path to config file")
+	showVersion := flag.Bool("v", false, "print version and exit")
+	dumpConfig := flag.Bool("dump-config", false, "print the effective config (secrets masked) and exit")
+	// 每个配置项均可通过同名参数覆盖，如 -worker_concurrency 16
+	config.BindFlags(flag.CommandLine)
 	flag.Parse()
 
-	cfg, err := config.LoadConfig(*configPath)
+	if *showVersion {
+		v := version.Get()
+		fmt.Printf("ip-resolver %s %s %s\n", version.Short(), v.GoVersion, v.Platform)
+		return
+	}
+
+	config.ApplyFlags(flag.CommandLine)
+	cfg, src, err := config.LoadConfig(context.Background(), *configPath)
 	if err != nil {
-		log.Fatalf("配置加载失败: %v", err)
+		logging.Fatal("配置加载失败", "err", err)
+	}
+	if *dumpConfig {
+		out, err := cfg.DumpYAML()
+		if err != nil {
+			logging.Fatal("输出配置失败", "err", err)
+		}
+		if src.File != "" {
+			fmt.Printf("# 配置文件: %s\n", src.File)
+		} else {
+			fmt.Println("# 未找到配置文件, 仅使用默认值、环境变量与命令行参数")
+		}
+		os.Stdout.Write(out)
+		return
 	}
 
 	// 1.1 日志配置
 	var logFile *os.File
+	var logOut io.Writer = os.Stderr
+	var logFileErr error
 	if cfg.LogFile != "" {
 		f, err := os.OpenFile(cfg.LogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 		if err != nil {
-			log.Printf("无法打开日志文件 %s: %v, 将仅输出到控制台", cfg.LogFile, err)
+			logFileErr = err
 		} else {
 			logFile = f
 			// 同时输出到控制台和文件
-			mw := io.MultiWriter(os.Stdout, f)
-			log.SetOutput(mw)
+			logOut = io.MultiWriter(os.Stdout, f)
 		}
 	}
+	logging.Setup(logOut, cfg.LogFormat, cfg.LogLevel)
+	if logFileErr != nil {
+		slog.Warn("无法打开日志文件, 将仅输出到控制台", "path", cfg.LogFile, "err", logFileErr)
+	}
 
-	log.Printf(
-		"启动 ip-resolver | API: %s | 监控: %s | 日志等级: %s",
-		cfg.ListenAddr,
-		cfg.MonitorAddr,
-		cfg.LogLevel,
+	slog.Info("启动 ip-resolver",
+		"version", version.Short(),
+		"api", cfg.ListenAddr,
+		"monitor", cfg.MonitorAddr,
+		"level", cfg.LogLevel,
+		"format", cfg.LogFormat,
 	)
+	if len(src.Files) > 1 {
+		initLog.Info("已合并配置文件", "files", src.Files)
+	}
+	if src.Remote != "" {
+		initLog.Info("已合并远程配置", "source", src.Remote)
+	}
+	if src.File == "" {
+		initLog.Warn("未找到配置文件, 仅使用默认值与环境变量 ("+config.EnvPrefix+"_*)", "path", *configPath)
+	}
 
 	// 2. 初始化组件
 	mon := monitor.New()
-
-	prov, err := provider.NewProviderByName(
-		cfg.Provider.Name,
-		cfg.Provider.SecretID,
-		cfg.Provider.SecretKey,
-		mon,
-	)
-	if err != nil {
-		log.Fatalf("Provider 初始化失败: %v", err)
+	mon.SetErrorHistorySize(cfg.ErrorHistorySize)
+	mon.SetSlowCallCapture(time.Duration(cfg.SlowCall.ThresholdMs)*time.Millisecond, cfg.SlowCall.MaxEntries, cfg.SlowCall.CaptureBody)
+	mon.SetSLO(monitor.SLOConfig{
+		AvailabilityTarget: cfg.SLO.AvailabilityTarget / 100,
+		LatencyTarget:      cfg.SLO.LatencyTarget / 100,
+		LatencyThreshold:   time.Duration(cfg.SLO.LatencyThresholdMs) * time.Millisecond,
+	})
+
+	// 创建 providers 中的全部提供商，查询使用第一个 primary
+	var prov provider.IPProvider
+	provs := make([]provider.IPProvider, len(cfg.Providers))
+	for i, pc := range cfg.Providers {
+		p, err := provider.NewProviderByName(pc.Name, pc.SecretID, pc.SecretKey, cfg.ProviderTimeout(pc), mon)
+		if err != nil {
+			logging.Fatal("Provider 初始化失败", "name", pc.Name, "err", err)
+		}
+		provs[i] = p
+		if prov == nil && pc.Role == config.RolePrimary {
+			prov = p
+		}
+		slog.Info("使用 IP 提供商", "name", pc.Name, "provider", p.Name(), "role", pc.Role, "weight", pc.Weight)
 	}
-	log.Printf("使用 IP 提供商: %s", prov.Name())
 
+	var quotaFetcher func(context.Context) int64
 	if cfg.Quota.InstanceID != "" {
-        log.Printf("[初始化] 启用配额检查, 实例ID: %s", cfg.Quota.InstanceID)
+        initLog.Info("启用配额检查", "instance_id", cfg.Quota.InstanceID)
 		
 		// 对应 config.yaml 中的 quota 配置
 		quotaChecker := provider.NewTencentQuotaChecker(
@@ -75,14 +151,67 @@ func main() {
 			cfg.Quota.InstanceID,
 		)
 
-		mon.SetQuotaFetcher(quotaChecker.GetRemainingRequests)
+		mon.SetQuotaWarningThreshold(cfg.Quota.WarningThreshold)
+		// 剩余配额由 Manager 后台定期刷新 (同时用于配额耗尽暂停与 /status)，配额接口变慢不影响状态查询
+		quotaFetcher = quotaChecker.GetRemainingRequestsContext
 	} else {
-		log.Println("[初始化] 配额检查未启用")
+		initLog.Info("配额检查未启用")
 	}
 
 	mgr := worker.NewManager(prov, cfg)
+	if quotaFetcher != nil {
+		mgr.SetQuotaFetcher(quotaFetcher)
+	}
+	// 按配置段登记，热加载时据此更换凭证
+	reloadProviders := make(map[string]provider.IPProvider, len(provs)+1)
+	for i, p := range provs {
+		mgr.RegisterProvider(cfg.Providers[i].Name, p)
+		reloadProviders[fmt.Sprintf("providers[%d]", i)] = p
+	}
+
+	if cfg.IPv6PrefixLen > 0 {
+		if cfg.IPv6Provider.Name != "" {
+			prov6, err := provider.NewProviderByName(
+				cfg.IPv6Provider.Name,
+				cfg.IPv6Provider.SecretID,
+				cfg.IPv6Provider.SecretKey,
+				cfg.ProviderTimeout(cfg.IPv6Provider),
+				mon,
+			)
+			if err != nil {
+				logging.Fatal("IPv6 Provider 初始化失败", "err", err)
+			}
+			mgr.SetIPv6Provider(prov6)
+			mgr.RegisterProvider(cfg.IPv6Provider.Name, prov6)
+			reloadProviders["ipv6_provider"] = prov6
+		}
+		initLog.Info("启用 IPv6", "prefix_len", cfg.IPv6PrefixLen)
+	}
 	
 	mon.SetCacheFetcher(mgr.GetCacheCount)
+	mon.SetDriftFetcher(mgr.GetCacheDrift)
+	mon.SetQueueFetcher(mgr.QueueStats)
+	mon.SetWorkerFetcher(mgr.WorkerStats)
+	mon.SetHitRatioFetcher(mgr.HitRatios)
+	mon.SetPersistenceFetcher(mgr.PersistenceStats)
+	mgr.SetMonitor(mon)
+
+	var reporter *errreport.Reporter
+	if cfg.ErrorReport.Enabled() {
+		reporter, err = errreport.New(errreport.Options{
+			SentryDSN:       cfg.ErrorReport.SentryDSN,
+			BugsnagAPIKey:   cfg.ErrorReport.BugsnagAPIKey,
+			BugsnagEndpoint: cfg.ErrorReport.BugsnagEndpoint,
+			Environment:     cfg.ErrorReport.Environment,
+		})
+		if err != nil {
+			logging.Fatal("错误上报初始化失败", "err", err)
+		}
+		reporter.Start()
+		mgr.SetErrorReporter(reporter, cfg.ErrorReport.ProviderFailures)
+		initLog.Info("启用错误上报", "sentry", cfg.ErrorReport.SentryDSN != "", "bugsnag", cfg.ErrorReport.BugsnagAPIKey != "",
+			"provider_failures", cfg.ErrorReport.ProviderFailures)
+	}
 
 	// 3. 信号处理
 	rootCtx, stop := signal.NotifyContext(
@@ -92,71 +221,410 @@ func main() {
 	)
 	defer stop()
 
+	// SIGUSR2 触发零停机重启 (监听 FD 移交给新进程)
+	reg := graceful.New()
+	upgradeCh := make(chan os.Signal, 1)
+	signal.Notify(upgradeCh, syscall.SIGUSR2)
+	// SIGUSR1 在 info 与 debug 之间切换日志等级，排查线上问题无需重启
+	levelCh := make(chan os.Signal, 1)
+	signal.Notify(levelCh, syscall.SIGUSR1)
+	// SIGHUP 重新加载配置文件，可在运行时修改的配置项立即生效，其余变更记录为需重启
+	rl := &reloader{path: *configPath, mgr: mgr, providers: reloadProviders, requests: make(chan chan reloadReply), done: make(chan struct{})}
+	rl.cfg.Store(cfg)
+	rl.src.Store(&src)
+	reloadCh := make(chan os.Signal, 1)
+	signal.Notify(reloadCh, syscall.SIGHUP)
+	var watchCh <-chan struct{}
+	if cfg.ConfigWatch {
+		files := src.Files
+		if len(files) == 0 {
+			files = []string{*configPath}
+		}
+		if watchCh, err = watchConfig(files, rootCtx.Done()); err != nil {
+			initLog.Warn("无法监视配置文件, 仅支持 SIGHUP 热加载", "files", files, "err", err)
+		} else {
+			initLog.Info("监视配置文件变化", "files", files)
+		}
+	}
+	// 远程配置中心变化时同样走热加载流程，重新读取配置文件与远程配置
+	var remoteCh <-chan struct{}
+	if cfg.Remote.Enabled() && cfg.Remote.Watch {
+		rc, err := remoteconfig.New(cfg.Remote.Options())
+		if err != nil {
+			logging.Fatal("远程配置监听初始化失败", "err", err)
+		}
+		remoteCh = rc.Watch(rootCtx)
+		initLog.Info("监听远程配置变化", "provider", cfg.Remote.Provider, "key", cfg.Remote.Key)
+	}
+
 	// 4. 启动后台任务
 	mgr.Start()
 
-	// 5. API Server (TCP / Unix Socket)
+	var bak *backup.Backup
+	if cfg.Backup.Enabled {
+		if cfg.CacheStorePath == "" {
+			initLog.Warn("未配置 cache_store_path, 缓存备份未启用")
+		} else {
+			initLog.Info("启用缓存备份", "bucket", cfg.Backup.Bucket, "interval_minutes", cfg.Backup.IntervalMinutes)
+			bak = backup.New(&backup.Config{
+				S3: backup.S3Config{
+					Endpoint:  cfg.Backup.Endpoint,
+					Region:    cfg.Backup.Region,
+					Bucket:    cfg.Backup.Bucket,
+					AccessKey: cfg.Backup.AccessKey,
+					SecretKey: cfg.Backup.SecretKey,
+				},
+				Prefix:    cfg.Backup.Prefix,
+				Interval:  time.Duration(cfg.Backup.IntervalMinutes) * time.Minute,
+				Retention: cfg.Backup.Retention,
+			}, mgr.SnapshotCache)
+			bak.Start()
+		}
+	}
+
+	var alerter *alert.Notifier
+	if len(cfg.Alert.Webhooks) > 0 {
+		alerter = newAlerter(cfg, mon, mgr)
+		alerter.Start()
+		initLog.Info("启用告警 Webhook", "webhooks", len(cfg.Alert.Webhooks))
+	}
+
+	var statsdExp *statsd.Exporter
+	if cfg.StatsD.Addr != "" {
+		statsdExp, err = statsd.New(cfg.StatsD.Addr, cfg.StatsD.Prefix, cfg.StatsD.Tags,
+			time.Duration(cfg.StatsD.IntervalSeconds)*time.Second, mon.Metrics)
+		if err != nil {
+			logging.Fatal("StatsD 初始化失败", "err", err)
+		}
+		statsdExp.Start()
+		initLog.Info("启用 StatsD 指标推送", "addr", cfg.StatsD.Addr, "interval", cfg.StatsD.IntervalSeconds)
+	}
+
+	var promExp *promexport.Exporter
+	if cfg.PromPush.Mode != "" {
+		instance := cfg.PromPush.Instance
+		if instance == "" {
+			instance, _ = os.Hostname()
+		}
+		promExp, err = promexport.New(promexport.Options{
+			Mode:        cfg.PromPush.Mode,
+			URL:         cfg.PromPush.URL,
+			Job:         cfg.PromPush.Job,
+			Instance:    instance,
+			Labels:      cfg.PromPush.Labels,
+			BearerToken: cfg.PromPush.BearerToken,
+			Username:    cfg.PromPush.Username,
+			Password:    cfg.PromPush.Password,
+			Interval:    time.Duration(cfg.PromPush.IntervalSeconds) * time.Second,
+		}, mon.Metrics)
+		if err != nil {
+			logging.Fatal("Prometheus 推送初始化失败", "err", err)
+		}
+		promExp.Start()
+		initLog.Info("启用 Prometheus 指标推送", "mode", cfg.PromPush.Mode, "url", cfg.PromPush.URL, "interval", cfg.PromPush.IntervalSeconds)
+	}
+
+	// 5. 管理接口路由 (admin.addr 为空时仅用于保护 API 端口上的管理操作)
+	adm := admin.New(cfg.Admin.Token)
+	adm.HandleFunc("/admin/cache", mgr.HandleAdminCache)
+	adm.HandleFunc("/admin/debug", mgr.HandleAdminDebug)
+	adm.HandleFunc("/admin/preload", mgr.HandlePreload)
+	adm.HandleFunc("/admin/deadletter", mgr.HandleAdminDeadLetter)
+	adm.HandleFunc("/admin/requeue", mgr.HandleAdminRequeue)
+	adm.HandleFunc("/admin/loglevel", logging.HandleLevel)
+	adm.HandleFunc("/admin/slowcalls", mon.HandleSlowCalls)
+	adm.HandleFunc("/admin/config", rl.handleConfig)
+	adm.HandleFunc("/admin/reload", rl.handleReload)
+	mgr.SetAdminAuth(adm.Authorized)
+
+	// 5.1 API Server (TCP / Unix Socket)
 	apiMux := http.NewServeMux()
-	apiMux.HandleFunc("/", mgr.HandleUpdate)
+	if cfg.LegacyLookupRoute {
+		apiMux.HandleFunc("/", mgr.HandleUpdate)
+	}
+	if prefix := lookupPrefix(cfg.LookupPathPrefix); prefix != "" {
+		apiMux.HandleFunc(prefix, mgr.LookupHandler(prefix))
+	}
+	apiMux.HandleFunc("/batch", mgr.HandleBatch)
+	apiMux.HandleFunc("/self", mgr.HandleSelf)
+	apiMux.HandleFunc("/auth", mgr.HandleAuthRequest)
+	apiMux.HandleFunc("/range/", mgr.HandleRange)
+	apiMux.HandleFunc("/tag/", mgr.HandleTag)
+	apiMux.HandleFunc("/detail/", mgr.HandleDetail)
+	apiMux.HandleFunc("/preload", adm.Protect(mgr.HandlePreload))
+	apiMux.HandleFunc("/openapi.json", openapi.Handler)
+
+	var apiHandler http.Handler = apiMux
+	if cfg.Compression {
+		apiHandler = compress.Middleware(apiHandler)
+	}
+
+	// 5.2 访问日志 (独立文件)
+	var accessFile *accesslog.RotatingFile
+	if cfg.AccessLog.Enabled {
+		var out io.Writer = os.Stdout
+		if cfg.AccessLog.File != "" {
+			f, err := accesslog.OpenRotatingFile(
+				cfg.AccessLog.File,
+				int64(cfg.AccessLog.MaxSizeMB)<<20,
+				cfg.AccessLog.MaxBackups,
+			)
+			if err != nil {
+				logging.Fatal("无法打开访问日志", "path", cfg.AccessLog.File, "err", err)
+			}
+			accessFile = f
+			out = f
+		}
+		apiHandler = accesslog.New(out, cfg.AccessLog.Format).Middleware(apiHandler)
+	}
+
+	// Handler panic 上报 (在请求 ID 之内，事件可关联请求)
+	apiHandler = reporter.Middleware(apiHandler)
+	// 5.3 请求 ID (访问日志与 worker 均可读取)
+	apiHandler = requestid.Middleware(apiHandler)
+	// 端到端耗时分布 (/status 的 latency.handler)
+	apiHandler = mon.Middleware(apiHandler)
+
+	// 5.4 来源 IP 访问控制 (最外层)
+	apiHandler = withACL("API", cfg.APIACL, apiHandler)
 
 	apiSrv := &http.Server{
-		Handler:           apiMux,
+		Handler:           apiHandler,
 		ReadHeaderTimeout: 5 * time.Second,
 		ReadTimeout:       10 * time.Second,
 		WriteTimeout:      10 * time.Second,
 		IdleTimeout:       60 * time.Second,
 		MaxHeaderBytes:    1 << 20, // 1MB
+		Protocols:         apiProtocols(cfg),
 	}
 
-	apiListener, apiCleanup, err := createListener(cfg.ListenAddr)
+	apiListener, apiCleanup, err := createListener(reg, cfg.ListenAddr)
 	if err != nil {
-		log.Fatalf("无法创建 API 监听器: %v", err)
+		logging.Fatal("无法创建 API 监听器", "err", err)
 	}
 	defer apiCleanup()
 
+	if tlsutil.Enabled(cfg.APITLS) {
+		tlsCfg, err := tlsutil.ServerConfig(cfg.APITLS)
+		if err != nil {
+			logging.Fatal("API TLS 配置失败", "err", err)
+		}
+		if cfg.HTTP2 {
+			tlsutil.EnableHTTP2(tlsCfg)
+		}
+		apiListener = tls.NewListener(apiListener, tlsCfg)
+		initLog.Info("API 启用 TLS", "client_auth", tlsCfg.ClientCAs != nil)
+	}
+
 	// 6. 监控 Server (仅 TCP)
 	monMux := http.NewServeMux()
 	monMux.HandleFunc("/status", mon.HandleStatus)
+	monMux.HandleFunc("/errors", mon.HandleErrors)
 	monMux.HandleFunc("/statistics", mgr.HandleStatistics)
+	monMux.HandleFunc("/stats/tags", mgr.HandleTagStats)
+	monMux.HandleFunc("/stats/daily", mgr.HandleDailyStats)
+	monMux.HandleFunc("/version", version.Handler)
+	monMux.HandleFunc("/changes", mgr.HandleChanges)
+	monMux.HandleFunc("/events", mgr.HandleEvents)
+	probes := newProbes(cfg.Health, mon, mgr)
+	monMux.HandleFunc("/livez", probes.HandleLive)
+	monMux.HandleFunc("/healthz", probes.HandleLive)
+	monMux.HandleFunc("/startupz", probes.HandleStartup)
+	monMux.HandleFunc("/readyz", probes.HandleReady)
+	if cfg.MonitorPprof {
+		monitor.RegisterPprof(monMux, adm.Protect)
+		initLog.Info("监控端口启用 pprof", "path", "/debug/pprof/")
+	}
+
+
+	monListener, _, err := reg.Listen("tcp", cfg.MonitorAddr)
+	if err != nil {
+		logging.Fatal("无法创建监控监听器", "err", err)
+	}
+
+	if tlsutil.Enabled(cfg.MonitorTLS) {
+		tlsCfg, err := tlsutil.ServerConfig(cfg.MonitorTLS)
+		if err != nil {
+			logging.Fatal("监控 TLS 配置失败", "err", err)
+		}
+		monListener = tls.NewListener(monListener, tlsCfg)
+		initLog.Info("监控启用 TLS", "client_auth", tlsCfg.ClientCAs != nil)
+	}
 
+	var monHandler http.Handler = monMux
+	if cfg.Compression {
+		monHandler = compress.Middleware(monHandler)
+	}
+	monHandler = withACL("监控", cfg.MonitorACL, reporter.Middleware(monHandler))
 
 	monSrv := &http.Server{
-		Addr:              cfg.MonitorAddr,
-		Handler:           monMux,
+		Handler:           monHandler,
 		ReadHeaderTimeout: 5 * time.Second,
 		ReadTimeout:       5 * time.Second,
 		WriteTimeout:      5 * time.Second,
 		IdleTimeout:       30 * time.Second,
 		MaxHeaderBytes:    1 << 20,
 	}
+	// Shutdown 不会中断进行中的请求，SSE 长连接需主动结束，否则拖到关闭超时
+	monSrv.RegisterOnShutdown(mgr.CloseStreams)
+
+	// 6.1 管理 Server (可选, 独立鉴权)
+	var admSrv *http.Server
+	var admListener net.Listener
+	if cfg.Admin.Addr != "" {
+		if cfg.Admin.Token == "" {
+			logging.Fatal("已配置 admin.addr 但 admin.token 为空")
+		}
+
+		var admCleanup func()
+		admListener, admCleanup, err = createListener(reg, cfg.Admin.Addr)
+		if err != nil {
+			logging.Fatal("无法创建管理监听器", "err", err)
+		}
+		defer admCleanup()
+
+		if tlsutil.Enabled(cfg.Admin.TLS) {
+			tlsCfg, err := tlsutil.ServerConfig(cfg.Admin.TLS)
+			if err != nil {
+				logging.Fatal("管理 TLS 配置失败", "err", err)
+			}
+			admListener = tls.NewListener(admListener, tlsCfg)
+		}
+
+		admSrv = &http.Server{
+			Handler:           reporter.Middleware(adm.Handler()),
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       10 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			MaxHeaderBytes:    1 << 20,
+		}
+	}
+
+	// 6.2 gRPC Server (可选, TCP / Unix Socket)
+	var grpcSrv *grpc.Server
+	var grpcListener net.Listener
+	if cfg.GRPCAddr != "" {
+		var grpcCleanup func()
+		grpcListener, grpcCleanup, err = createListener(reg, cfg.GRPCAddr)
+		if err != nil {
+			logging.Fatal("无法创建 gRPC 监听器", "err", err)
+		}
+		defer grpcCleanup()
+
+		// Invalidate 与 HTTP 端的缓存管理一样需要管理 Token
+		grpcSrv = grpc.NewServer(grpc.UnaryInterceptor(grpcserver.AuthInterceptor(adm.AuthorizedHeader)))
+		resolverpb.RegisterResolverServer(grpcSrv, grpcserver.New(mgr))
+	}
+
+	// 6.3 DNS Server (可选, UDP)
+	var dnsSrv *dnsserver.Server
+	var dnsConn net.PacketConn
+	if cfg.DNSAddr != "" {
+		dnsConn, err = reg.ListenPacket("udp", cfg.DNSAddr)
+		if err != nil {
+			logging.Fatal("无法创建 DNS 监听器", "err", err)
+		}
+		dnsSrv = dnsserver.New(cfg.DNSAddr, cfg.DNSZone, mgr)
+	}
 
 	// 7. 启动 Server
-	errCh := make(chan error, 2)
+	errCh := make(chan error, 5)
 
 	go func() {
-		log.Printf("API server 监听于 %s", cfg.ListenAddr)
+		slog.Info("API server 已启动", "addr", cfg.ListenAddr)
 		if err := apiSrv.Serve(apiListener); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			errCh <- err
 		}
 	}()
 
 	go func() {
-		log.Printf("监控 server 监听于 %s", cfg.MonitorAddr)
-		if err := monSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Info("监控 server 已启动", "addr", cfg.MonitorAddr)
+		if err := monSrv.Serve(monListener); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			errCh <- err
 		}
 	}()
 
+	if admSrv != nil {
+		go func() {
+			slog.Info("管理 server 已启动", "addr", cfg.Admin.Addr)
+			if err := admSrv.Serve(admListener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errCh <- err
+			}
+		}()
+	}
+
+	if grpcSrv != nil {
+		go func() {
+			slog.Info("gRPC server 已启动", "addr", cfg.GRPCAddr)
+			if err := grpcSrv.Serve(grpcListener); err != nil {
+				errCh <- err
+			}
+		}()
+	}
+
+	if dnsSrv != nil {
+		go func() {
+			slog.Info("DNS server 已启动", "addr", cfg.DNSAddr, "zone", cfg.DNSZone)
+			if err := dnsSrv.Serve(dnsConn); err != nil {
+				errCh <- err
+			}
+		}()
+	}
+
+	// 由旧进程拉起时通知其退出
+	if err := reg.Ready(); err != nil {
+		slog.Error("通知旧进程失败", "err", err)
+	}
+	// 旧进程在退出前才保存其队列，退出后再接管一次
+	go func() {
+		if reg.WaitParent(rootCtx) {
+			slog.Info("旧进程已退出, 接管其待解析任务")
+			mgr.RestoreQueue()
+		}
+	}()
+	probes.SetStarted()
+
 	// 8. 等待退出信号
-	select {
-	case <-rootCtx.Done():
-		log.Println("收到退出信号")
-	case err := <-errCh:
-		log.Printf("Server 错误: %v", err)
-		stop()
+wait:
+	for {
+		select {
+		case <-rootCtx.Done():
+			slog.Info("收到退出信号")
+			break wait
+		case err := <-errCh:
+			slog.Error("Server 错误", "err", err)
+			stop()
+			break wait
+		case <-upgradeCh:
+			slog.Info("收到 SIGUSR2, 启动新进程...")
+			if err := reg.Upgrade(gracefulUpgradeTimeout); err != nil {
+				slog.Error("零停机重启失败, 继续使用当前进程", "err", err)
+				continue
+			}
+			slog.Info("新进程已就绪, 当前进程开始退出")
+			break wait
+		case <-levelCh:
+			logging.ToggleLevel()
+		case <-reloadCh:
+			slog.Info("收到 SIGHUP, 重新加载配置")
+			rl.reload(rootCtx)
+		case <-watchCh:
+			slog.Info("配置文件已变化, 重新加载配置")
+			rl.reload(rootCtx)
+		case <-remoteCh:
+			slog.Info("远程配置已变化, 重新加载配置")
+			rl.reload(rootCtx)
+		case reply := <-rl.requests:
+			slog.Info("收到 /admin/reload 请求, 重新加载配置")
+			res, err := rl.reload(rootCtx)
+			reply <- reloadReply{res, err}
+		}
 	}
 
-	log.Println("正在关闭...")
+	close(rl.done)
+	slog.Info("正在关闭...")
+	probes.SetDraining()
 
 	// 先关闭 HTTP Server
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -168,62 +636,252 @@ func main() {
 	go func() {
 		defer wg.Done()
 		if err := apiSrv.Shutdown(shutdownCtx); err != nil {
-			log.Printf("API关闭失败: %v", err)
+			slog.Error("API关闭失败", "err", err)
 		}
 	}()
 
 	go func() {
 		defer wg.Done()
 		if err := monSrv.Shutdown(shutdownCtx); err != nil {
-			log.Printf("监控关闭失败: %v", err)
+			slog.Error("监控关闭失败", "err", err)
 		}
 	}()
 
+	if admSrv != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := admSrv.Shutdown(shutdownCtx); err != nil {
+				slog.Error("管理接口关闭失败", "err", err)
+			}
+		}()
+	}
+
+	if grpcSrv != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stopped := make(chan struct{})
+			go func() {
+				grpcSrv.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-shutdownCtx.Done():
+				grpcSrv.Stop()
+			}
+		}()
+	}
+
 	wg.Wait()
 
+	if dnsSrv != nil {
+		dnsSrv.Close()
+	}
+
+	if bak != nil {
+		bak.Stop()
+	}
+	if alerter != nil {
+		alerter.Stop()
+	}
+	if statsdExp != nil {
+		statsdExp.Stop()
+	}
+	if promExp != nil {
+		promExp.Stop()
+	}
 	// 确认无流量后关闭 Manager
 	mgr.Stop()
+	// Manager 关闭期间的 panic 仍需上报
+	if reporter != nil {
+		reporter.Stop()
+	}
 	
 	// 关闭日志文件
+	if accessFile != nil {
+		_ = accessFile.Close()
+	}
 	if logFile != nil {
 		_ = logFile.Close()
 	}
-	log.Println("退出完成")
+	slog.Info("退出完成")
 }
 
-// createListener 创建 TCP 或 Unix Socket 监听器
-func createListener(addr string) (net.Listener, func(), error) {
+// ======== 零停机重启参数 =========
+const gracefulUpgradeTimeout = 30 * time.Second
+
+// createListener 创建 TCP 或 Unix Socket 监听器，重启时优先继承旧进程的 FD
+func createListener(reg *graceful.Registry, addr string) (net.Listener, func(), error) {
 	// Unix Socket
 	if strings.HasPrefix(addr, "unix://") {
 		socketPath := strings.TrimPrefix(addr, "unix://")
 
-		if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
-			return nil, nil, err
+		if !reg.Inherits("unix", socketPath) {
+			if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+				return nil, nil, err
+			}
 		}
 
-		l, err := net.Listen("unix", socketPath)
+		l, inherited, err := reg.Listen("unix", socketPath)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		if err := os.Chmod(socketPath, 0660); err != nil {
-			l.Close()
-			return nil, nil, err
+		if !inherited {
+			if err := os.Chmod(socketPath, 0660); err != nil {
+				l.Close()
+				return nil, nil, err
+			}
 		}
 
 		cleanup := func() {
 			_ = l.Close()
-			_ = os.Remove(socketPath)
+			// 已移交给新进程时保留 socket 文件
+			if !reg.HandedOff() {
+				_ = os.Remove(socketPath)
+			}
 		}
 
 		return l, cleanup, nil
 	}
 
 	// TCP
-	l, err := net.Listen("tcp", addr)
+	l, _, err := reg.Listen("tcp", addr)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	return l, func() { _ = l.Close() }, nil
 }
+
+// withACL 配置了访问控制规则时包装 handler
+func withACL(name string, cfg config.ACLConfig, next http.Handler) http.Handler {
+	acl, err := ipacl.New(name, cfg.Allow, cfg.Deny)
+	if err != nil {
+		logging.Fatal("访问控制配置错误", "listener", name, "err", err)
+	}
+	if !acl.Enabled() {
+		return next
+	}
+	initLog.Info("启用来源 IP 访问控制", "listener", name, "allow", len(cfg.Allow), "deny", len(cfg.Deny))
+	return acl.Middleware(next)
+}
+
+// lookupPrefix 规范化查询路由前缀为 "/xxx/" 形式，空或 "/" 返回空
+func lookupPrefix(prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return ""
+	}
+	return "/" + prefix + "/"
+}
+
+// apiProtocols 根据配置决定 API Server 支持的协议
+func apiProtocols(cfg *config.Config) *http.Protocols {
+	p := new(http.Protocols)
+	p.SetHTTP1(true)
+	p.SetHTTP2(cfg.HTTP2)
+	p.SetUnencryptedHTTP2(cfg.H2C)
+	return p
+}
+
+// newAlerter 按配置注册告警检查项: 上游连续失败、配额耗尽 / 不足、持久化丢弃、队列积压
+func newAlerter(c *config.Config, mon *monitor.Monitor, mgr *worker.Manager) *alert.Notifier {
+	cfg := c.Alert
+	hooks := make([]alert.Webhook, 0, len(cfg.Webhooks))
+	for _, h := range cfg.Webhooks {
+		hooks = append(hooks, alert.Webhook{URL: h.URL, Type: h.Type})
+	}
+	n := alert.New(hooks,
+		time.Duration(cfg.CheckIntervalSeconds)*time.Second,
+		time.Duration(cfg.CooldownSeconds)*time.Second,
+	)
+
+	if cfg.ConsecutiveErrors > 0 {
+		threshold := int64(cfg.ConsecutiveErrors)
+		n.Watch("consecutive_errors", func() (bool, string) {
+			streak, lastErr := mon.ConsecutiveErrors()
+			if streak < threshold {
+				return false, "上游调用已恢复"
+			}
+			return true, fmt.Sprintf("上游连续失败 %d 次 | 最近错误: %s", streak, lastErr)
+		})
+	}
+	if cfg.QuotaExhausted {
+		n.Watch("quota_exhausted", func() (bool, string) {
+			if mgr.QuotaPaused() {
+				return true, "剩余配额为 0，上游请求已暂停"
+			}
+			return false, "配额已恢复，继续上游请求"
+		})
+	}
+	if cfg.QuotaLow && c.Quota.WarningThreshold > 0 {
+		// 剩余配额来自配额检查 (quota.refresh_interval_seconds)，不额外调用查询接口
+		threshold := c.Quota.WarningThreshold
+		n.Watch("quota_low", func() (bool, string) {
+			remaining := mgr.RemainingQuota()
+			if remaining < 0 || remaining >= threshold {
+				return false, fmt.Sprintf("剩余配额已回到预警阈值 %d 以上", threshold)
+			}
+			return true, fmt.Sprintf("剩余配额 %d 低于预警阈值 %d，请及时续费资源包", remaining, threshold)
+		})
+	}
+	if cfg.PersistenceDrops {
+		// 按最近 1 分钟的丢弃速率判断，偶发丢弃可通过 persistence_drops_per_minute 忽略
+		threshold := cfg.PersistenceDropsPerMinute
+		n.Watch("persistence_drops", func() (bool, string) {
+			rate := mgr.PersistenceDropRate()
+			if rate <= threshold {
+				return false, "缓存持久化丢弃速率已恢复正常"
+			}
+			return true, fmt.Sprintf("缓存持久化丢弃速率 %.1f 条/分钟，超过阈值 %g (累计丢弃 %d)", rate, threshold, mgr.GetDroppedUpdates())
+		})
+	}
+	if cfg.QueueSaturationPercent > 0 {
+		// 在队列写满、客户端收到 503 之前提前告警
+		threshold := float64(cfg.QueueSaturationPercent) / 100
+		n.Watch("queue_saturation", func() (bool, string) {
+			qs := mgr.QueueStats()
+			if qs.QueueUtilization < threshold {
+				return false, "解析队列积压已缓解"
+			}
+			return true, fmt.Sprintf("解析队列积压 %d/%d (%.0f%%)，Worker 使用率 %.0f%%，处理中 %d",
+				qs.QueueDepth, qs.QueueCapacity, qs.QueueUtilization*100, qs.WorkerUtilization*100, qs.Inflight)
+		})
+	}
+	return n
+}
+
+// newProbes 注册 /readyz 的就绪检查: 上游连续失败次数与首次查询队列使用率
+func newProbes(cfg config.HealthConfig, mon *monitor.Monitor, mgr *worker.Manager) *monitor.Probes {
+	p := monitor.NewProbes()
+	if cfg.MaxConsecutiveErrors > 0 {
+		threshold := int64(cfg.MaxConsecutiveErrors)
+		p.AddReadyCheck("provider", func() error {
+			if streak, lastErr := mon.ConsecutiveErrors(); streak >= threshold {
+				return fmt.Errorf("上游连续失败 %d 次: %s", streak, lastErr)
+			}
+			return nil
+		})
+	}
+	if cfg.MaxPersistenceDropsPerMinute > 0 {
+		p.AddReadyCheck("persistence", func() error {
+			if rate := mgr.PersistenceDropRate(); rate > cfg.MaxPersistenceDropsPerMinute {
+				return fmt.Errorf("缓存持久化丢弃速率 %.1f 条/分钟", rate)
+			}
+			return nil
+		})
+	}
+	if cfg.QueueSaturationPercent > 0 {
+		p.AddReadyCheck("queue", func() error {
+			qs := mgr.QueueStats()
+			if qs.QueueCapacity > 0 && qs.QueueDepth*100 >= qs.QueueCapacity*cfg.QueueSaturationPercent {
+				return fmt.Errorf("队列积压 %d/%d", qs.QueueDepth, qs.QueueCapacity)
+			}
+			return nil
+		})
+	}
+	return p
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"ip-resolver/internal/config"
+	"ip-resolver/internal/logging"
+	"ip-resolver/internal/provider"
+	"ip-resolver/internal/worker"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+var reloadLog = logging.For("Reload")
+
+// ======== 硬编码参数 =========
+const configWatchDebounce = 500 * time.Millisecond // 合并编辑器保存时的连续事件
+
+// reloader 配置热加载: 重新读取配置文件，可在运行时修改的配置项立即生效，其余变更记录为需重启
+type reloader struct {
+	path      string
+	cfg       atomic.Pointer[config.Config]  // 当前生效的配置
+	src       atomic.Pointer[config.Sources] // 当前配置的来源，仅在加载成功时更新
+	mgr       *worker.Manager
+	providers map[string]provider.IPProvider // 按配置段 (providers[i] / ipv6_provider) 登记
+
+	requests chan chan reloadReply // POST /admin/reload 转交主循环执行，与 SIGHUP 等来源串行
+	done     chan struct{}         // 主循环退出时关闭，之后不再受理
+}
+
+// reloadResult 一次热加载的结果
+type reloadResult struct {
+	Applied         []string `json:"applied"`          // 已立即生效的配置项
+	RestartRequired []string `json:"restart_required"` // 有变更但需重启后生效的配置项
+}
+
+type reloadReply struct {
+	res reloadResult
+	err error
+}
+
+// reload 仅由主循环调用，ctx 取消 (进程退出) 时中止远程配置读取
+// 可热加载: log_level、worker_concurrency (未启用自动伸缩时)、provider_rate_limit、cache_ttl_seconds (仅影响新写入的条目)、
+// providers 各项与 ipv6_provider 的 secret_id 与 secret_key (提供商名称与数量不变时)
+func (r *reloader) reload(ctx context.Context) (reloadResult, error) {
+	next, src, err := config.LoadConfig(ctx, r.path)
+	if err != nil {
+		reloadLog.Error("重新加载配置失败, 继续使用当前配置", "path", r.path, "err", err)
+		return reloadResult{}, err
+	}
+
+	cur := *r.cfg.Load()
+	var applied []string
+
+	if next.LogLevel != cur.LogLevel {
+		logging.SetLevel(next.LogLevel)
+		cur.LogLevel = next.LogLevel
+		applied = append(applied, "log_level")
+	}
+	if next.WorkerConcurrency != cur.WorkerConcurrency && r.mgr.SetConcurrency(next.WorkerConcurrency) {
+		cur.WorkerConcurrency = next.WorkerConcurrency
+		applied = append(applied, "worker_concurrency")
+	}
+	if next.ProviderRateLimit != cur.ProviderRateLimit {
+		r.mgr.SetProviderRateLimit(next.ProviderRateLimit)
+		cur.ProviderRateLimit = next.ProviderRateLimit
+		applied = append(applied, "provider_rate_limit")
+	}
+	if next.CacheTTLSeconds != cur.CacheTTLSeconds {
+		r.mgr.SetCacheTTL(time.Duration(next.CacheTTLSeconds) * time.Second)
+		cur.CacheTTLSeconds = next.CacheTTLSeconds
+		applied = append(applied, "cache_ttl_seconds")
+	}
+
+	type providerPair struct {
+		key       string
+		cur, next *config.ProviderConfig
+	}
+	pairs := []providerPair{{"ipv6_provider", &cur.IPv6Provider, &next.IPv6Provider}}
+	// 提供商数量变化需重启，由下方的差异比较列出
+	if len(cur.Providers) == len(next.Providers) {
+		cur.Providers = slices.Clone(cur.Providers)
+		for i := range cur.Providers {
+			pairs = append(pairs, providerPair{fmt.Sprintf("providers[%d]", i), &cur.Providers[i], &next.Providers[i]})
+		}
+	}
+	for _, pc := range pairs {
+		if pc.cur.Name != pc.next.Name || (pc.cur.SecretID == pc.next.SecretID && pc.cur.SecretKey == pc.next.SecretKey) {
+			continue
+		}
+		setter, ok := r.providers[pc.key].(provider.CredentialSetter)
+		if !ok {
+			continue
+		}
+		setter.SetCredentials(pc.next.SecretID, pc.next.SecretKey)
+		pc.cur.SecretID, pc.cur.SecretKey = pc.next.SecretID, pc.next.SecretKey
+		applied = append(applied, pc.key+".secret_id", pc.key+".secret_key")
+	}
+
+	// 已生效的项已同步到 cur，剩余差异均需重启
+	restart := changedKeys(reflect.ValueOf(cur), reflect.ValueOf(*next), "")
+	r.cfg.Store(&cur)
+	r.src.Store(&src)
+
+	res := reloadResult{Applied: applied, RestartRequired: restart}
+	if len(applied) == 0 && len(restart) == 0 {
+		reloadLog.Info("配置已重新加载, 无变化", "path", r.path)
+		return res, nil
+	}
+	reloadLog.Info("配置已重新加载", "path", r.path, "applied", applied, "restart_required", restart)
+	if len(restart) > 0 {
+		reloadLog.Warn("部分配置变更需重启后生效", "keys", restart)
+	}
+	return res, nil
+}
+
+// handleReload POST /admin/reload 触发热加载 (同 SIGHUP)，返回已生效与需重启的配置项
+// 实际加载由主循环执行，避免与信号、文件监视或远程配置触发的热加载并发
+func (r *reloader) handleReload(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	reply := make(chan reloadReply, 1)
+	select {
+	case r.requests <- reply:
+	case <-r.done:
+		http.Error(w, "服务正在关闭", http.StatusServiceUnavailable)
+		return
+	case <-req.Context().Done():
+		return
+	}
+
+	var rep reloadReply
+	select {
+	case rep = <-reply:
+	case <-req.Context().Done():
+		return
+	}
+	if rep.err != nil {
+		http.Error(w, "重新加载配置失败, 继续使用当前配置: "+rep.err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if rep.res.Applied == nil {
+		rep.res.Applied = []string{}
+	}
+	if rep.res.RestartRequired == nil {
+		rep.res.RestartRequired = []string{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rep.res)
+}
+
+// handleConfig /admin/config 返回当前生效的配置 (密钥已隐藏)，用于核对默认值与命令行参数 / 环境变量 / 配置文件的覆盖结果
+// 默认 JSON，?format=yaml 返回与 --dump-config 相同的 YAML
+func (r *reloader) handleConfig(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	cfg := r.cfg.Load()
+	if req.URL.Query().Get("format") == "yaml" {
+		out, err := cfg.DumpYAML()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+		w.Write(out)
+		return
+	}
+
+	m, err := cfg.RedactedMap()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		ConfigFile string         `json:"config_file"` // 为空表示未找到配置文件
+		Config     map[string]any `json:"config"`
+	}{r.src.Load().File, m})
+}
+
+// changedKeys 返回 a 与 b 中取值不同的配置项，嵌套配置以 . 连接 (如 provider.name)
+func changedKeys(a, b reflect.Value, prefix string) []string {
+	var out []string
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("mapstructure"), ",")
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		name = prefix + name
+
+		if f.Type.Kind() == reflect.Struct {
+			out = append(out, changedKeys(a.Field(i), b.Field(i), name+".")...)
+			continue
+		}
+		if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// watchConfig 监视配置文件 (含 include 与 profile 覆盖文件) 变化，连续事件合并后通知一次
+// 监视所在目录而非文件本身: 编辑器通常以替换文件的方式保存，Kubernetes ConfigMap 通过切换符号链接更新
+func watchConfig(paths []string, done <-chan struct{}) (<-chan struct{}, error) {
+	files := make([]string, 0, len(paths))
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, abs)
+	}
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirsOf(files) {
+		if err := w.Add(dir); err != nil {
+			w.Close()
+			return nil, err
+		}
+	}
+
+	ch := make(chan struct{}, 1)
+	go func() {
+		defer w.Close()
+
+		targets := make(map[string]string, len(files))
+		for _, f := range files {
+			targets[f], _ = filepath.EvalSymlinks(f)
+		}
+		debounce := time.NewTimer(configWatchDebounce)
+		debounce.Stop()
+
+		for {
+			select {
+			case ev, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				changed := slices.Contains(files, filepath.Clean(ev.Name)) && !ev.Has(fsnotify.Chmod)
+				// 符号链接指向变化 (ConfigMap 更新) 时事件落在其他文件上
+				for _, f := range files {
+					if t, err := filepath.EvalSymlinks(f); err == nil && t != targets[f] {
+						targets[f] = t
+						changed = true
+					}
+				}
+				if changed {
+					debounce.Reset(configWatchDebounce)
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				reloadLog.Warn("监视配置文件出错", "err", err)
+			case <-debounce.C:
+				missing := false
+				for _, f := range files {
+					if _, err := os.Stat(f); err != nil {
+						missing = true
+					}
+				}
+				if missing {
+					// 替换过程中文件短暂不存在，等待后续事件
+					continue
+				}
+				select {
+				case ch <- struct{}{}:
+				default:
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// dirsOf 返回文件所在目录 (去重)
+func dirsOf(files []string) []string {
+	var dirs []string
+	for _, f := range files {
+		if d := filepath.Dir(f); !slices.Contains(dirs, d) {
+			dirs = append(dirs, d)
+		}
+	}
+	return dirs
+}
@@ -0,0 +1,129 @@
+package accesslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"ip-resolver/internal/requestid"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Logger HTTP 访问日志，输出独立于应用日志
+type Logger struct {
+	mu     sync.Mutex
+	out    io.Writer
+	format string // text / json
+}
+
+func New(out io.Writer, format string) *Logger {
+	return &Logger{out: out, format: format}
+}
+
+// record 单条访问日志
+type record struct {
+	Time      string  `json:"time"`
+	Client    string  `json:"client"`
+	Method    string  `json:"method"`
+	Path      string  `json:"path"`
+	Status    int     `json:"status"`
+	Bytes     int     `json:"bytes"`
+	LatencyMs float64 `json:"latency_ms"`
+	Cache     string  `json:"cache,omitempty"` // 来自 X-Cache 响应头
+	RequestID string  `json:"request_id,omitempty"`
+}
+
+// Middleware 记录每个请求的访问日志
+func (l *Logger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		l.write(record{
+			Time:      start.Format(time.RFC3339),
+			Client:    clientAddr(r.RemoteAddr),
+			Method:    r.Method,
+			Path:      r.URL.RequestURI(),
+			Status:    rec.status,
+			Bytes:     rec.bytes,
+			LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			Cache:     rec.Header().Get("X-Cache"),
+			RequestID: rec.Header().Get(requestid.Header),
+		})
+	})
+}
+
+func (l *Logger) write(rec record) {
+	var line []byte
+	if l.format == "json" {
+		b, err := json.Marshal(rec)
+		if err != nil {
+			return
+		}
+		line = append(b, '\n')
+	} else {
+		cache := rec.Cache
+		if cache == "" {
+			cache = "-"
+		}
+		reqID := rec.RequestID
+		if reqID == "" {
+			reqID = "-"
+		}
+		line = []byte(fmt.Sprintf("%s %s \"%s %s\" %d %d %.3fms %s %s\n",
+			rec.Time, rec.Client, rec.Method, rec.Path, rec.Status, rec.Bytes, rec.LatencyMs, cache, reqID))
+	}
+
+	l.mu.Lock()
+	_, _ = l.out.Write(line)
+	l.mu.Unlock()
+}
+
+// clientAddr 提取客户端地址，Unix Socket 连接记为 unix
+func clientAddr(addr string) string {
+	if addr == "" || addr == "@" {
+		return "unix"
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// statusRecorder 记录响应状态码与字节数
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(p)
+	r.bytes += n
+	return n, err
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap 供 http.ResponseController 访问底层 ResponseWriter
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
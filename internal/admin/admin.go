@@ -0,0 +1,74 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// Server 管理接口: 独立监听、独立鉴权 (Bearer Token)
+// 缓存管理、配置重载、调试等危险操作只注册在这里，不暴露在业务端口上
+type Server struct {
+	token string
+	mux   *http.ServeMux
+}
+
+func New(token string) *Server {
+	return &Server{
+		token: token,
+		mux:   http.NewServeMux(),
+	}
+}
+
+func (s *Server) HandleFunc(pattern string, handler http.HandlerFunc) {
+	s.mux.HandleFunc(pattern, handler)
+}
+
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Handler 返回带鉴权的 Handler
+func (s *Server) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="ip-resolver-admin"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		s.mux.ServeHTTP(w, r)
+	})
+}
+
+// Authorized 校验请求是否携带正确的管理 Token，供其他端口上的受保护操作复用
+func (s *Server) Authorized(r *http.Request) bool {
+	return s.authorized(r)
+}
+
+// Protect 包装其他端口上的受保护操作，要求携带管理 Token (未配置 Token 时一律拒绝)
+func (s *Server) Protect(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="ip-resolver-admin"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	return s.AuthorizedHeader(r.Header.Get("Authorization"))
+}
+
+// AuthorizedHeader 校验 "Bearer <token>" 形式的凭证，供 gRPC 等非 HTTP 入口复用 (未配置 Token 时一律拒绝)
+func (s *Server) AuthorizedHeader(value string) bool {
+	if s.token == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(value, "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
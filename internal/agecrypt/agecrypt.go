@@ -0,0 +1,343 @@
+// Package agecrypt 解密 age (https://age-encryption.org/v1) 格式的密文，
+// 仅支持 X25519 身份 (age-keygen 生成的 AGE-SECRET-KEY-1...)，用于配置中的加密值
+package agecrypt
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// ======== 硬编码参数 =========
+const (
+	intro        = "age-encryption.org/v1"
+	x25519Label  = "age-encryption.org/v1/X25519"
+	identityHRP  = "age-secret-key-"
+	armorBegin   = "-----BEGIN AGE ENCRYPTED FILE-----"
+	armorEnd     = "-----END AGE ENCRYPTED FILE-----"
+	fileKeySize  = 16
+	streamNonce  = 16
+	chunkSize    = 64 * 1024
+	stanzaColumn = 48 // 每行 64 个 base64 字符对应的字节数
+)
+
+// ErrNoIdentity 没有与密文匹配的身份
+var ErrNoIdentity = errors.New("没有可解密该密文的 age 身份")
+
+// Identity X25519 私钥
+type Identity struct {
+	key *ecdh.PrivateKey
+}
+
+// ParseIdentities 解析身份文件内容，每行一个 AGE-SECRET-KEY-1...，忽略空行与 # 注释
+func ParseIdentities(text string) ([]Identity, error) {
+	var ids []Identity
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, err := ParseIdentity(line)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 行: %w", i+1, err)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("未包含 age 身份")
+	}
+	return ids, nil
+}
+
+// ParseIdentity 解析单个 AGE-SECRET-KEY-1... 私钥
+func ParseIdentity(s string) (Identity, error) {
+	hrp, data, err := bech32Decode(s)
+	if err != nil {
+		return Identity{}, fmt.Errorf("age 私钥格式错误: %w", err)
+	}
+	if hrp != identityHRP {
+		return Identity{}, fmt.Errorf("不是 age X25519 私钥 (前缀 %q)", hrp)
+	}
+	key, err := ecdh.X25519().NewPrivateKey(data)
+	if err != nil {
+		return Identity{}, fmt.Errorf("age 私钥无效: %w", err)
+	}
+	return Identity{key: key}, nil
+}
+
+// Unarmor 接受 ASCII armor (age -a) 或 base64 编码的二进制密文 (age | base64)，返回二进制密文
+func Unarmor(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if body, ok := strings.CutPrefix(s, armorBegin); ok {
+		body, ok = strings.CutSuffix(body, armorEnd)
+		if !ok {
+			return nil, errors.New("age armor 缺少结束行")
+		}
+		s = body
+	}
+	s = strings.Join(strings.Fields(s), "")
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("密文 base64 解码失败: %w", err)
+	}
+	return data, nil
+}
+
+// stanza 头部中的一个接收方条目
+type stanza struct {
+	typ  string
+	args []string
+	body []byte
+}
+
+// Decrypt 使用任一匹配的身份解密 age 二进制密文
+func Decrypt(data []byte, ids []Identity) ([]byte, error) {
+	stanzas, headerNoMAC, mac, payload, err := parseHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var fileKey []byte
+	for _, s := range stanzas {
+		if s.typ != "X25519" {
+			continue
+		}
+		for _, id := range ids {
+			if fileKey, err = id.unwrap(s); err == nil {
+				break
+			}
+		}
+		if fileKey != nil {
+			break
+		}
+	}
+	if fileKey == nil {
+		return nil, ErrNoIdentity
+	}
+
+	hmacKey, err := hkdf.Key(sha256.New, fileKey, nil, "header", 32)
+	if err != nil {
+		return nil, err
+	}
+	h := hmac.New(sha256.New, hmacKey)
+	h.Write(headerNoMAC)
+	if !hmac.Equal(h.Sum(nil), mac) {
+		return nil, errors.New("age 头部校验失败")
+	}
+	return decryptPayload(fileKey, payload)
+}
+
+// unwrap 尝试用身份解开 X25519 条目中的文件密钥
+func (id Identity) unwrap(s stanza) ([]byte, error) {
+	if len(s.args) != 1 {
+		return nil, errors.New("X25519 条目参数错误")
+	}
+	share, err := base64.RawStdEncoding.Strict().DecodeString(s.args[0])
+	if err != nil || len(share) != 32 {
+		return nil, errors.New("X25519 临时公钥无效")
+	}
+	pub, err := ecdh.X25519().NewPublicKey(share)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := id.key.ECDH(pub) // 全零共享密钥会返回错误
+	if err != nil {
+		return nil, err
+	}
+	salt := append(bytes.Clone(share), id.key.PublicKey().Bytes()...)
+	wrapKey, err := hkdf.Key(sha256.New, shared, salt, x25519Label, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.New(wrapKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.body) != fileKeySize+aead.Overhead() {
+		return nil, errors.New("X25519 条目长度错误")
+	}
+	return aead.Open(nil, make([]byte, chacha20poly1305.NonceSize), s.body, nil)
+}
+
+// parseHeader 解析文本头部，返回条目、参与 MAC 计算的头部、MAC 与负载
+func parseHeader(data []byte) ([]stanza, []byte, []byte, []byte, error) {
+	r := bufio.NewReader(bytes.NewReader(data))
+	readLine := func() (string, error) {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", errors.New("age 头部不完整")
+		}
+		return strings.TrimSuffix(line, "\n"), nil
+	}
+
+	line, err := readLine()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if line != intro {
+		return nil, nil, nil, nil, errors.New("不是 age v1 密文")
+	}
+	consumed := len(line) + 1
+
+	var stanzas []stanza
+	for {
+		line, err := readLine()
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		if macB64, ok := strings.CutPrefix(line, "--- "); ok {
+			mac, err := base64.RawStdEncoding.Strict().DecodeString(macB64)
+			if err != nil {
+				return nil, nil, nil, nil, errors.New("age 头部 MAC 格式错误")
+			}
+			// MAC 覆盖到 "---" 为止，不含其后的空格
+			headerNoMAC := data[:consumed+len("---")]
+			return stanzas, headerNoMAC, mac, data[consumed+len(line)+1:], nil
+		}
+		consumed += len(line) + 1
+
+		fields, ok := strings.CutPrefix(line, "-> ")
+		if !ok {
+			return nil, nil, nil, nil, errors.New("age 头部格式错误")
+		}
+		args := strings.Split(fields, " ")
+		s := stanza{typ: args[0], args: args[1:]}
+		// 条目正文按 64 列换行，最后一行不足 64 列 (可为空行)
+		for {
+			line, err := readLine()
+			if err != nil {
+				return nil, nil, nil, nil, err
+			}
+			consumed += len(line) + 1
+			chunk, err := base64.RawStdEncoding.Strict().DecodeString(line)
+			if err != nil || len(chunk) > stanzaColumn {
+				return nil, nil, nil, nil, errors.New("age 条目正文格式错误")
+			}
+			s.body = append(s.body, chunk...)
+			if len(chunk) < stanzaColumn {
+				break
+			}
+		}
+		stanzas = append(stanzas, s)
+	}
+}
+
+// decryptPayload 按 STREAM 结构逐块解密: 每块 64KiB，nonce 为 11 字节计数器 + 末块标记
+func decryptPayload(fileKey, payload []byte) ([]byte, error) {
+	if len(payload) < streamNonce {
+		return nil, errors.New("age 负载过短")
+	}
+	key, err := hkdf.Key(sha256.New, fileKey, payload[:streamNonce], "payload", chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	rest := payload[streamNonce:]
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	for counter := uint64(0); ; counter++ {
+		n := min(len(rest), chunkSize+aead.Overhead())
+		chunk := rest[:n]
+		rest = rest[n:]
+		last := len(rest) == 0
+		// 只有空明文才允许空的末块，否则末块至少 1 字节
+		if n < aead.Overhead() || (last && counter > 0 && n == aead.Overhead()) {
+			return nil, errors.New("age 负载分块错误")
+		}
+		for i := 0; i < 8; i++ {
+			nonce[10-i] = byte(counter >> (8 * i))
+		}
+		nonce[11] = 0
+		if last {
+			nonce[11] = 1
+		}
+		plain, err := aead.Open(nil, nonce, chunk, nil)
+		if err != nil {
+			return nil, errors.New("age 负载解密失败")
+		}
+		out.Write(plain)
+		if last {
+			return out.Bytes(), nil
+		}
+	}
+}
+
+// ======== Bech32 (BIP 173) =========
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+// bech32Decode 解码并校验，返回小写 HRP 与 8 位数据
+func bech32Decode(s string) (string, []byte, error) {
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, errors.New("大小写混用")
+	}
+	s = strings.ToLower(s)
+	pos := strings.LastIndexByte(s, '1')
+	if pos < 1 || pos+7 > len(s) {
+		return "", nil, errors.New("分隔符位置错误")
+	}
+	hrp := s[:pos]
+	var values []byte
+	for _, c := range hrp {
+		values = append(values, byte(c>>5))
+	}
+	values = append(values, 0)
+	for _, c := range hrp {
+		values = append(values, byte(c&31))
+	}
+	data := make([]byte, 0, len(s)-pos-1)
+	for _, c := range s[pos+1:] {
+		i := strings.IndexRune(bech32Charset, c)
+		if i < 0 {
+			return "", nil, fmt.Errorf("非法字符 %q", c)
+		}
+		data = append(data, byte(i))
+	}
+	if bech32Polymod(append(values, data...)) != 1 {
+		return "", nil, errors.New("校验和错误")
+	}
+
+	// 5 位分组转 8 位，不允许多余的非零填充位
+	var out []byte
+	acc, bits := uint32(0), uint(0)
+	for _, v := range data[:len(data)-6] {
+		acc = acc<<5 | uint32(v)
+		bits += 5
+		for bits >= 8 {
+			bits -= 8
+			out = append(out, byte(acc>>bits))
+		}
+	}
+	if bits >= 5 || acc&(1<<bits-1) != 0 {
+		return "", nil, errors.New("填充位错误")
+	}
+	return hrp, out, nil
+}
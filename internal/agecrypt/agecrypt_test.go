@@ -0,0 +1,252 @@
+package agecrypt
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// age testkit 使用的身份 (私钥为 32 个 0x42) 及其接收方
+const (
+	testkitIdentity  = "AGE-SECRET-KEY-1GFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPQ4EGAEX"
+	testkitRecipient = "age1zvkyg2lqzraa2lnjvqej32nkuu0ues2s82hzrye869xeexvn73equnujwj"
+)
+
+func TestParseIdentity(t *testing.T) {
+	id, err := ParseIdentity(testkitIdentity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(id.key.Bytes(), bytes.Repeat([]byte{0x42}, 32)) {
+		t.Fatalf("private key = %x", id.key.Bytes())
+	}
+	if got := bech32Encode("age", id.key.PublicKey().Bytes()); got != testkitRecipient {
+		t.Fatalf("recipient = %s, want %s", got, testkitRecipient)
+	}
+
+	for _, bad := range []string{
+		"",
+		strings.ToLower(testkitIdentity[:20]) + testkitIdentity[20:], // 大小写混用
+		testkitIdentity[:len(testkitIdentity)-1] + "Q",               // 校验和错误
+		testkitRecipient, // 公钥不是身份
+	} {
+		if _, err := ParseIdentity(bad); err == nil {
+			t.Errorf("ParseIdentity(%q) succeeded", bad)
+		}
+	}
+
+	ids, err := ParseIdentities("# created: 2024\n\n" + testkitIdentity + "\n")
+	if err != nil || len(ids) != 1 {
+		t.Fatalf("ParseIdentities = %d, %v", len(ids), err)
+	}
+}
+
+func TestDecrypt(t *testing.T) {
+	id, err := ParseIdentity(testkitIdentity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := bytes.Repeat([]byte{'a'}, chunkSize)
+
+	tests := []struct {
+		name    string
+		file    func() []byte
+		want    []byte
+		wantErr error // nil 时只要求返回错误
+		ok      bool
+	}{
+		{"small", func() []byte { return encrypt(t, id.key.PublicKey(), split([]byte("hello"))) }, []byte("hello"), nil, true},
+		{"empty plaintext", func() []byte { return encrypt(t, id.key.PublicKey(), [][]byte{{}}) }, []byte{}, nil, true},
+		{"exactly one chunk", func() []byte { return encrypt(t, id.key.PublicKey(), split(full)) }, full, nil, true},
+		{"two chunks", func() []byte { return encrypt(t, id.key.PublicKey(), split(append(bytes.Clone(full), 'b'))) }, append(bytes.Clone(full), 'b'), nil, true},
+		{"wrong identity", func() []byte { return encrypt(t, other.PublicKey(), split([]byte("hello"))) }, nil, ErrNoIdentity, false},
+		{"bad header mac", func() []byte {
+			f := encrypt(t, id.key.PublicKey(), split([]byte("hello")))
+			i := bytes.Index(f, []byte("\n--- ")) + len("\n--- ")
+			f[i] ^= 'A' ^ 'B' // 修改 MAC 的第一个 base64 字符
+			if f[i] == '\n' {
+				t.Fatal("unexpected mac layout")
+			}
+			return f
+		}, nil, nil, false},
+		{"tampered stanza", func() []byte {
+			return bytes.Replace(encrypt(t, id.key.PublicKey(), split([]byte("hello"))), []byte("-> X25519 "), []byte("-> X25519 A"), 1)
+		}, nil, nil, false},
+		{"bad payload tag", func() []byte {
+			f := encrypt(t, id.key.PublicKey(), split([]byte("hello")))
+			f[len(f)-1] ^= 1
+			return f
+		}, nil, nil, false},
+		{"truncated last chunk", func() []byte {
+			// 去掉末块后，首个满块未标记为末块
+			f := encrypt(t, id.key.PublicKey(), split(append(bytes.Clone(full), 'b')))
+			return f[:len(f)-(1+chacha20poly1305.Overhead)]
+		}, nil, nil, false},
+		{"truncated inside chunk", func() []byte {
+			f := encrypt(t, id.key.PublicKey(), split([]byte("hello")))
+			return f[:len(f)-3]
+		}, nil, nil, false},
+		{"empty final chunk", func() []byte {
+			// 明文恰为整块时末块必须是该满块，不能再追加空块
+			return encrypt(t, id.key.PublicKey(), [][]byte{full, {}})
+		}, nil, nil, false},
+		{"missing payload nonce", func() []byte {
+			f := encrypt(t, id.key.PublicKey(), split([]byte("hello")))
+			i := bytes.Index(f, []byte("\n--- "))
+			return f[:i+1+bytes.IndexByte(f[i+1:], '\n')+1+8]
+		}, nil, nil, false},
+		{"not age", func() []byte { return []byte("age-encryption.org/v2\n") }, nil, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decrypt(tt.file(), []Identity{id})
+			if !tt.ok {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("plaintext length %d, want %d", len(got), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestUnarmor(t *testing.T) {
+	data := []byte("age-encryption.org/v1\n-> X25519 abc\n")
+	b64 := base64.StdEncoding.EncodeToString(data)
+	tests := []struct {
+		in string
+		ok bool
+	}{
+		{b64, true},
+		{armorBegin + "\n" + b64[:20] + "\n" + b64[20:] + "\n" + armorEnd + "\n", true},
+		{armorBegin + "\n" + b64 + "\n", false},
+		{"not base64!", false},
+	}
+	for _, tt := range tests {
+		got, err := Unarmor(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("Unarmor(%q) err = %v, want ok = %v", tt.in, err, tt.ok)
+			continue
+		}
+		if tt.ok && !bytes.Equal(got, data) {
+			t.Errorf("Unarmor(%q) = %q", tt.in, got)
+		}
+	}
+}
+
+// split 把明文按 64KiB 分块 (空明文为单个空块)
+func split(plain []byte) [][]byte {
+	var chunks [][]byte
+	for len(plain) > chunkSize {
+		chunks = append(chunks, plain[:chunkSize])
+		plain = plain[chunkSize:]
+	}
+	return append(chunks, plain)
+}
+
+// encrypt 按 age v1 规范为单个 X25519 接收方生成密文，chunks 原样作为 STREAM 分块 (最后一块标记为末块)，
+// 独立于被测实现，用于构造合法与畸形的测试向量
+func encrypt(t *testing.T, recipient *ecdh.PublicKey, chunks [][]byte) []byte {
+	t.Helper()
+
+	fileKey := make([]byte, fileKeySize)
+	_, _ = rand.Read(fileKey)
+	eph, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared, err := eph.ECDH(recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share := eph.PublicKey().Bytes()
+	wrapKey, _ := hkdf.Key(sha256.New, shared, append(bytes.Clone(share), recipient.Bytes()...), x25519Label, chacha20poly1305.KeySize)
+	wrap, _ := chacha20poly1305.New(wrapKey)
+	body := wrap.Seal(nil, make([]byte, chacha20poly1305.NonceSize), fileKey, nil)
+
+	var hdr bytes.Buffer
+	hdr.WriteString(intro + "\n")
+	hdr.WriteString("-> X25519 " + base64.RawStdEncoding.EncodeToString(share) + "\n")
+	hdr.WriteString(base64.RawStdEncoding.EncodeToString(body) + "\n") // 32 字节，单行
+	hdr.WriteString("---")
+	hmacKey, _ := hkdf.Key(sha256.New, fileKey, nil, "header", 32)
+	h := hmac.New(sha256.New, hmacKey)
+	h.Write(hdr.Bytes())
+	hdr.WriteString(" " + base64.RawStdEncoding.EncodeToString(h.Sum(nil)) + "\n")
+
+	nonce := make([]byte, streamNonce)
+	_, _ = rand.Read(nonce)
+	hdr.Write(nonce)
+	key, _ := hkdf.Key(sha256.New, fileKey, nonce, "payload", chacha20poly1305.KeySize)
+	aead, _ := chacha20poly1305.New(key)
+	for i, c := range chunks {
+		n := make([]byte, chacha20poly1305.NonceSize)
+		for b := 0; b < 8; b++ {
+			n[10-b] = byte(uint64(i) >> (8 * b))
+		}
+		if i == len(chunks)-1 {
+			n[11] = 1
+		}
+		hdr.Write(aead.Seal(nil, n, c, nil))
+	}
+	return hdr.Bytes()
+}
+
+// bech32Encode 8 位数据转 5 位分组后编码 (用于校验 testkit 接收方)
+func bech32Encode(hrp string, data []byte) string {
+	var values []byte
+	acc, bits := uint32(0), uint(0)
+	for _, b := range data {
+		acc = acc<<8 | uint32(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			values = append(values, byte(acc>>bits)&31)
+		}
+	}
+	if bits > 0 {
+		values = append(values, byte(acc<<(5-bits))&31)
+	}
+
+	var exp []byte
+	for _, c := range hrp {
+		exp = append(exp, byte(c>>5))
+	}
+	exp = append(exp, 0)
+	for _, c := range hrp {
+		exp = append(exp, byte(c&31))
+	}
+	mod := bech32Polymod(append(append(exp, values...), 0, 0, 0, 0, 0, 0)) ^ 1
+
+	var sb strings.Builder
+	sb.WriteString(hrp + "1")
+	for _, v := range values {
+		sb.WriteByte(bech32Charset[v])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(bech32Charset[(mod>>(5*(5-i)))&31])
+	}
+	return sb.String()
+}
@@ -0,0 +1,213 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"ip-resolver/internal/logging"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+var log = logging.For("Alert")
+
+// ======== 硬编码参数 =========
+const sendTimeout = 5 * time.Second
+
+// Webhook 告警接收地址，Type 决定消息格式: json (默认) / dingtalk / wecom / slack
+type Webhook struct {
+	URL  string
+	Type string
+}
+
+// Check 返回告警是否处于触发状态及说明
+type Check func() (firing bool, msg string)
+
+type watch struct {
+	kind  string
+	check Check
+}
+
+// Notifier 定期执行检查项，在告警触发 / 恢复时推送 Webhook
+// 同一类告警在 cooldown 内只推送一次，避免抖动时刷屏
+type Notifier struct {
+	hooks    []Webhook
+	client   *http.Client
+	interval time.Duration
+	cooldown time.Duration
+	host     string
+
+	mu       sync.Mutex
+	watches  []watch
+	firing   map[string]bool
+	notified map[string]bool      // 本轮触发是否已推送 (未推送的触发也不推送恢复)
+	lastSent map[string]time.Time // 最近一次推送触发的时间
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func New(hooks []Webhook, interval, cooldown time.Duration) *Notifier {
+	host, _ := os.Hostname()
+	return &Notifier{
+		hooks:    hooks,
+		client:   &http.Client{Timeout: sendTimeout},
+		interval: interval,
+		cooldown: cooldown,
+		host:     host,
+		firing:   make(map[string]bool),
+		notified: make(map[string]bool),
+		lastSent: make(map[string]time.Time),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Watch 注册检查项，kind 用于区分告警类型 (如 consecutive_errors)
+func (n *Notifier) Watch(kind string, check Check) {
+	n.mu.Lock()
+	n.watches = append(n.watches, watch{kind: kind, check: check})
+	n.mu.Unlock()
+}
+
+func (n *Notifier) Start() {
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		ticker := time.NewTicker(n.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				n.evaluate()
+			case <-n.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (n *Notifier) Stop() {
+	close(n.stop)
+	n.wg.Wait()
+}
+
+func (n *Notifier) evaluate() {
+	n.mu.Lock()
+	watches := n.watches
+	n.mu.Unlock()
+
+	for _, w := range watches {
+		firing, msg := w.check()
+		n.Update(w.kind, firing, msg)
+	}
+}
+
+// Update 更新告警状态，仅在状态变化时推送
+func (n *Notifier) Update(kind string, firing bool, msg string) {
+	n.mu.Lock()
+	if firing == n.firing[kind] {
+		n.mu.Unlock()
+		return
+	}
+	n.firing[kind] = firing
+
+	send := false
+	if firing {
+		if time.Since(n.lastSent[kind]) >= n.cooldown {
+			n.lastSent[kind] = time.Now()
+			n.notified[kind] = true
+			send = true
+		}
+	} else if n.notified[kind] {
+		n.notified[kind] = false
+		send = true
+	}
+	n.mu.Unlock()
+
+	if !firing {
+		log.Info("告警已恢复", "kind", kind, "message", msg)
+	} else {
+		log.Warn("告警触发", "kind", kind, "message", msg, "suppressed", !send)
+	}
+	if send {
+		n.send(Event{Kind: kind, Firing: firing, Message: msg, Host: n.host, Time: time.Now()})
+	}
+}
+
+// Event 单条告警通知
+type Event struct {
+	Kind    string
+	Firing  bool
+	Message string
+	Host    string
+	Time    time.Time
+}
+
+func (e Event) text() string {
+	state := "告警"
+	if !e.Firing {
+		state = "恢复"
+	}
+	return fmt.Sprintf("[ip-resolver] %s: %s\n类型: %s\n主机: %s\n时间: %s",
+		state, e.Message, e.Kind, e.Host, e.Time.Format(time.DateTime))
+}
+
+// payload 按接收方类型构造消息体
+func (e Event) payload(typ string) any {
+	switch typ {
+	case "dingtalk", "wecom":
+		return map[string]any{"msgtype": "text", "text": map[string]string{"content": e.text()}}
+	case "slack":
+		return map[string]string{"text": e.text()}
+	default:
+		status := "firing"
+		if !e.Firing {
+			status = "resolved"
+		}
+		return map[string]any{
+			"service": "ip-resolver",
+			"kind":    e.Kind,
+			"status":  status,
+			"message": e.Message,
+			"host":    e.Host,
+			"time":    e.Time.Format(time.RFC3339),
+		}
+	}
+}
+
+func (n *Notifier) send(e Event) {
+	for _, h := range n.hooks {
+		if err := n.post(h, e); err != nil {
+			log.Error("推送告警失败", "kind", e.Kind, "type", h.Type, "err", err)
+		}
+	}
+}
+
+func (n *Notifier) post(h Webhook, e Event) error {
+	body, err := json.Marshal(e.payload(h.Type))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"ip-resolver/internal/logging"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var log = logging.For("Backup")
+
+// Config 定时备份配置
+type Config struct {
+	S3        S3Config
+	Prefix    string        // 对象 Key 前缀
+	Interval  time.Duration // 备份间隔
+	Retention int           // 远端保留份数 (<=0 表示不清理)
+}
+
+// Backup 定时将缓存快照上传到 S3 兼容存储
+type Backup struct {
+	config   *Config
+	client   *S3Client
+	snapshot func(dst string) error
+
+	ctx    context.Context // Stop 时取消，中止进行中的上传
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New 创建备份任务，snapshot 负责把当前缓存导出到 dst 文件
+func New(config *Config, snapshot func(dst string) error) *Backup {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Backup{
+		config:   config,
+		client:   NewS3Client(&config.S3),
+		snapshot: snapshot,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+func (b *Backup) Start() {
+	b.wg.Add(1)
+
+	go func() {
+		defer b.wg.Done()
+
+		ticker := time.NewTicker(b.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := b.RunOnce(b.ctx); err != nil && b.ctx.Err() == nil {
+					log.Error("备份失败", "err", err)
+				}
+			case <-b.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止定时备份，进行中的上传被中止 (不影响 RunOnce 的其他调用方)
+func (b *Backup) Stop() {
+	b.cancel()
+	b.wg.Wait()
+}
+
+// RunOnce 执行一次快照 + 上传 + 清理
+func (b *Backup) RunOnce(ctx context.Context) error {
+	tmpDir, err := os.MkdirTemp("", "ip-resolver-backup-")
+	if err != nil {
+		return fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	dst := filepath.Join(tmpDir, "cache.db")
+	if err := b.snapshot(dst); err != nil {
+		return fmt.Errorf("导出快照失败: %w", err)
+	}
+
+	key := b.config.Prefix + "cache-" + time.Now().UTC().Format("20060102-150405") + ".db"
+	start := time.Now()
+	if err := b.client.PutFile(ctx, key, dst); err != nil {
+		return fmt.Errorf("上传快照失败: %w", err)
+	}
+	log.Info("上传完成", "key", key, "elapsed", time.Since(start))
+
+	if b.config.Retention > 0 {
+		if err := b.prune(ctx); err != nil {
+			return fmt.Errorf("清理旧备份失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// prune 仅保留最新的 Retention 份备份
+func (b *Backup) prune(ctx context.Context) error {
+	objects, err := b.client.List(ctx, b.config.Prefix+"cache-")
+	if err != nil {
+		return err
+	}
+
+	var backups []S3Object
+	for _, obj := range objects {
+		if strings.HasSuffix(obj.Key, ".db") {
+			backups = append(backups, obj)
+		}
+	}
+	if len(backups) <= b.config.Retention {
+		return nil
+	}
+
+	// Key 中包含时间戳，按字典序即按时间排序
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Key > backups[j].Key })
+
+	for _, obj := range backups[b.config.Retention:] {
+		if err := b.client.Delete(ctx, obj.Key); err != nil {
+			return err
+		}
+		log.Info("已删除过期备份", "key", obj.Key)
+	}
+	return nil
+}
@@ -4,7 +4,10 @@ import (
     "context"
     "database/sql"
     "fmt"
-    "log"
+    "ip-resolver/internal/fanout"
+    "ip-resolver/internal/logging"
+    "math"
+    "math/rand/v2"
     "sync"
     "sync/atomic"
     "time"
@@ -12,6 +15,8 @@ import (
     _ "modernc.org/sqlite"
 )
 
+var log = logging.For("Cache")
+
 // ================= 配置常量 =================
 
 const (
@@ -21,8 +26,11 @@ const (
     defaultShardCapacity = 2000
 
     persistBatchSize = 100
+    persistRetryCap  = 4096 // 持久化重试缓冲区容量 (按 key 去重)
     persistInterval  = 2 * time.Second
     cleanupInterval  = 30 * time.Minute
+
+    reconcileInterval = 10 * time.Minute
 )
 
 // ================= 结构定义 =================
@@ -31,14 +39,18 @@ type persistenceOp struct {
     IsDelete  bool
     Key       string
     Value     string
+    Detail    string
     Exp       int64
     RefreshAt int64
 }
 
 type entry struct {
     value     string
+    detail    string // 附加信息 (由调用方编码，如原始省份/运营商)
     exp       int64
     refreshAt int64
+    rev       uint64 // 写入版本号，用于 CompareAndSet
+    meta      *accessMeta
 }
 
 type shard struct {
@@ -49,19 +61,42 @@ type shard struct {
 type Cache struct {
     shards [shardCount]*shard
 
-    ttl           int64
-    refreshWindow int64
+    ttl           int64   // 原子读写，支持热加载修改
+    refreshWindow int64   // 原子读写
+    refreshJitter int64   // 预刷新起点的最大随机推迟 (不超过 refreshWindow)，原子读写
+    refreshRatio  float64 // 预刷新窗口占 TTL 的比例
+    jitterRatio   float64 // 随机推迟占预刷新窗口的比例
     shardCap      int
 
+    // 淘汰策略
+    policy      EvictionPolicy
+    trackAccess bool
+
     // 统计指标
     count          int64
-    droppedUpdates int64
+    droppedUpdates int64 // 重试缓冲区也满时最终丢弃的更新
+    retriedUpdates int64 // 通道满后进入重试缓冲区的更新
+    lastDrift      int64 // 最近一次校准发现的计数偏差
+    totalDrift     int64 // 累计校准偏差 (绝对值)
+
+    // 全局递增的写入版本号
+    rev uint64
+
+    // 变更订阅
+    changes fanout.Hub[ChangeEvent]
+
+    // 只读快照 (可选)
+    snapshot atomic.Pointer[map[string]entry]
 
     now int64
 
     stop      chan struct{}
     persistCh chan persistenceOp
 
+    // 通道满时的重试缓冲区，同一 key 仅保留最新操作
+    retryMu  sync.Mutex
+    retryBuf map[string]persistenceOp
+
     // === 数据库并发控制 ===
     // 使用读写锁保护 dbPath 和 roDB，替代 sync.Once 以处理更复杂的初始化逻辑
     dbMu   sync.RWMutex
@@ -82,10 +117,13 @@ func New(ttl time.Duration, refreshRatio float64) *Cache {
     c := &Cache{
         ttl:           int64(ttl),
         refreshWindow: int64(float64(ttl) * refreshRatio),
+        refreshRatio:  refreshRatio,
         shardCap:      defaultShardCapacity,
+        policy:        EvictRandom,
         now:           time.Now().UnixNano(),
         stop:          make(chan struct{}),
         persistCh:     make(chan persistenceOp, 2048),
+        retryBuf:      make(map[string]persistenceOp),
     }
 
     for i := 0; i < shardCount; i++ {
@@ -96,6 +134,7 @@ func New(ttl time.Duration, refreshRatio float64) *Cache {
 
     c.startClock()
     c.startCleanup()
+    c.startReconcile()
 
     return c
 }
@@ -115,52 +154,88 @@ func (c *Cache) getShard(key string) *shard {
 
 func (c *Cache) Get(key string) (string, bool, bool, time.Duration) {
     now := atomic.LoadInt64(&c.now)
-    s := c.getShard(key)
 
-    s.mu.RLock()
-    e, ok := s.items[key]
-    s.mu.RUnlock()
+    e, ok := c.lookupSnapshot(key, now)
+    if !ok {
+        s := c.getShard(key)
+        s.mu.RLock()
+        e, ok = s.items[key]
+        s.mu.RUnlock()
+    }
 
     if !ok || now >= e.exp {
         return "", false, false, 0
     }
 
-    needsRefresh := c.refreshWindow > 0 && now >= e.refreshAt
+    if c.trackAccess {
+        e.meta.touch(now)
+    }
+
+    needsRefresh := atomic.LoadInt64(&c.refreshWindow) > 0 && now >= e.refreshAt
     remaining := time.Duration(e.exp - now)
 
     return e.value, true, needsRefresh, remaining
 }
 
+// SetRefreshJitter 在预刷新窗口内随机推迟刷新起点，ratio 为占窗口的比例 (0 ~ 1)
+// 避免同一时间写入的条目在过期前同一时刻集中刷新
+func (c *Cache) SetRefreshJitter(ratio float64) {
+    ratio = min(max(ratio, 0), 1)
+    c.jitterRatio = ratio
+    atomic.StoreInt64(&c.refreshJitter, int64(float64(atomic.LoadInt64(&c.refreshWindow))*ratio))
+}
+
+// SetTTL 修改新写入条目的有效期，预刷新窗口与随机推迟按原比例同步调整 (配置热加载)
+// 已有条目保持写入时的过期时间
+func (c *Cache) SetTTL(ttl time.Duration) {
+    window := int64(float64(ttl) * c.refreshRatio)
+    atomic.StoreInt64(&c.refreshWindow, window)
+    atomic.StoreInt64(&c.refreshJitter, int64(float64(window)*c.jitterRatio))
+    atomic.StoreInt64(&c.ttl, int64(ttl))
+}
+
+// TTL 返回新写入条目的有效期
+func (c *Cache) TTL() time.Duration {
+    return time.Duration(atomic.LoadInt64(&c.ttl))
+}
+
+// refreshAtFor 计算过期时间为 exp 的条目进入预刷新的时间
+func (c *Cache) refreshAtFor(exp int64) int64 {
+    at := exp - atomic.LoadInt64(&c.refreshWindow)
+    if jitter := atomic.LoadInt64(&c.refreshJitter); jitter > 0 {
+        at += rand.Int64N(jitter)
+    }
+    return at
+}
+
 func (c *Cache) Set(key, val string) {
     now := atomic.LoadInt64(&c.now)
-    exp := now + c.ttl
+    exp := now + atomic.LoadInt64(&c.ttl)
 
     e := entry{
         value:     val,
         exp:       exp,
-        refreshAt: exp - c.refreshWindow,
+        refreshAt: c.refreshAtFor(exp),
+        rev:       atomic.AddUint64(&c.rev, 1),
     }
 
     s := c.getShard(key)
     s.mu.Lock()
 
-    if _, exists := s.items[key]; exists {
+    if old, exists := s.items[key]; exists {
+        e.meta = old.meta
         s.items[key] = e
         s.mu.Unlock()
         c.sendToPersist(persistenceOp{
             Key: key, Value: val, Exp: exp, RefreshAt: e.refreshAt,
         })
+        c.publish(OpSet, key, val, SourceSet)
         return
     }
 
-    if len(s.items) >= c.shardCap {
-        for k := range s.items {
-            delete(s.items, k)
-            atomic.AddInt64(&c.count, -1)
-            break
-        }
-    }
+    c.evictOne(s)
 
+    e.meta = &accessMeta{lastAccess: now}
     s.items[key] = e
     atomic.AddInt64(&c.count, 1)
     s.mu.Unlock()
@@ -168,18 +243,134 @@ func (c *Cache) Set(key, val string) {
     c.sendToPersist(persistenceOp{
         Key: key, Value: val, Exp: exp, RefreshAt: e.refreshAt,
     })
+    c.publish(OpSet, key, val, SourceSet)
 }
 
-func (c *Cache) Delete(key string) {
+// GetDetail 返回 key 的附加信息，不存在或已过期时返回空
+func (c *Cache) GetDetail(key string) string {
+    now := atomic.LoadInt64(&c.now)
+    s := c.getShard(key)
+
+    s.mu.RLock()
+    e, ok := s.items[key]
+    s.mu.RUnlock()
+
+    if !ok || now >= e.exp {
+        return ""
+    }
+    return e.detail
+}
+
+// Revision 返回 key 当前的写入版本号，不存在或已过期时返回 0
+func (c *Cache) Revision(key string) uint64 {
+    now := atomic.LoadInt64(&c.now)
+    s := c.getShard(key)
+
+    s.mu.RLock()
+    e, ok := s.items[key]
+    s.mu.RUnlock()
+
+    if !ok || now >= e.exp {
+        return 0
+    }
+    return e.rev
+}
+
+// CompareAndSet 仅当 key 的版本号仍等于 rev 时写入 (rev 为 0 表示期望 key 不存在)
+// 用于防止慢请求的旧结果覆盖期间已被刷新的新结果，detail 为附加信息，source 标记变更来源
+func (c *Cache) CompareAndSet(key, val, detail string, rev uint64, source string) bool {
+    now := atomic.LoadInt64(&c.now)
+    exp := now + atomic.LoadInt64(&c.ttl)
+
+    s := c.getShard(key)
+    s.mu.Lock()
+
+    old, exists := s.items[key]
+    var cur uint64
+    if exists && now < old.exp {
+        cur = old.rev
+    }
+    if cur != rev {
+        s.mu.Unlock()
+        return false
+    }
+
+    e := entry{
+        value:     val,
+        detail:    detail,
+        exp:       exp,
+        refreshAt: c.refreshAtFor(exp),
+        rev:       atomic.AddUint64(&c.rev, 1),
+    }
+
+    if exists {
+        e.meta = old.meta
+    } else {
+        c.evictOne(s)
+        e.meta = &accessMeta{lastAccess: now}
+        atomic.AddInt64(&c.count, 1)
+    }
+    s.items[key] = e
+    s.mu.Unlock()
+
+    c.sendToPersist(persistenceOp{
+        Key: key, Value: val, Detail: detail, Exp: exp, RefreshAt: e.refreshAt,
+    })
+    c.publish(OpSet, key, val, source)
+    return true
+}
+
+// Pin 写入人工指定的映射并立即生效，permanent 为 true 时永不过期
+// 人工条目不进入预刷新窗口，避免在过期前被上游结果覆盖
+func (c *Cache) Pin(key, val, detail string, permanent bool) {
+    now := atomic.LoadInt64(&c.now)
+    exp := now + atomic.LoadInt64(&c.ttl)
+    if permanent {
+        exp = math.MaxInt64
+    }
+
+    e := entry{
+        value:     val,
+        detail:    detail,
+        exp:       exp,
+        refreshAt: exp,
+        rev:       atomic.AddUint64(&c.rev, 1),
+    }
+
+    s := c.getShard(key)
+    s.mu.Lock()
+    if old, exists := s.items[key]; exists {
+        e.meta = old.meta
+    } else {
+        c.evictOne(s)
+        e.meta = &accessMeta{lastAccess: now}
+        atomic.AddInt64(&c.count, 1)
+    }
+    s.items[key] = e
+    s.mu.Unlock()
+
+    c.invalidateSnapshot()
+    c.sendToPersist(persistenceOp{
+        Key: key, Value: val, Detail: detail, Exp: exp, RefreshAt: e.refreshAt,
+    })
+    c.publish(OpSet, key, val, SourceManual)
+}
+
+// Delete 删除 key，返回 key 是否存在
+func (c *Cache) Delete(key string) bool {
     s := c.getShard(key)
     s.mu.Lock()
     defer s.mu.Unlock()
 
-    if _, ok := s.items[key]; ok {
+    _, ok := s.items[key]
+    if ok {
         delete(s.items, key)
         atomic.AddInt64(&c.count, -1)
         c.sendToPersist(persistenceOp{Key: key, IsDelete: true})
+        c.publish(OpDelete, key, "", SourceDelete)
+        c.invalidateSnapshot()
     }
+    return ok
 }
 
 func (c *Cache) sendToPersist(op persistenceOp) {
@@ -188,11 +379,45 @@ func (c *Cache) sendToPersist(op persistenceOp) {
         atomic.AddInt64(&c.droppedUpdates, 1)
         return
     }
-    select {
-    case c.persistCh <- op:
-    default:
+
+    // 重试缓冲区非空时新操作也进入缓冲区，保证同一 key 的写入顺序
+    c.retryMu.Lock()
+    if len(c.retryBuf) == 0 {
+        select {
+        case c.persistCh <- op:
+            c.retryMu.Unlock()
+            return
+        default:
+        }
+    }
+    c.spillLocked(op)
+    c.retryMu.Unlock()
+}
+
+// spillLocked 将操作放入重试缓冲区 (调用方需持有 retryMu)
+func (c *Cache) spillLocked(op persistenceOp) {
+    if _, ok := c.retryBuf[op.Key]; !ok && len(c.retryBuf) >= persistRetryCap {
         atomic.AddInt64(&c.droppedUpdates, 1)
+        return
+    }
+    c.retryBuf[op.Key] = op
+    atomic.AddInt64(&c.retriedUpdates, 1)
+}
+
+// takeRetries 取出重试缓冲区中的全部操作
+func (c *Cache) takeRetries() []persistenceOp {
+    c.retryMu.Lock()
+    defer c.retryMu.Unlock()
+
+    if len(c.retryBuf) == 0 {
+        return nil
     }
+    ops := make([]persistenceOp, 0, len(c.retryBuf))
+    for k, op := range c.retryBuf {
+        ops = append(ops, op)
+        delete(c.retryBuf, k)
+    }
+    return ops
 }
 
 // ================= 持久化逻辑 =================
@@ -205,7 +430,7 @@ func (c *Cache) StartPersistence(path string) {
 
     // 预热只读连接 (可选，但推荐)
     if err := c.ensureReadOnlyDB(); err != nil {
-        log.Printf("StartPersistence: init roDB failed: %v", err)
+        log.Error("StartPersistence: init roDB failed", "err", err)
         // 注意：这里不 return，依然尝试启动写入协程，保证核心功能可用
     }
 
@@ -217,7 +442,7 @@ func (c *Cache) StartPersistence(path string) {
         // 写入协程使用独立的连接
         db, err := sql.Open("sqlite", path)
         if err != nil {
-            log.Printf("StartPersistence: open db failed: %v", err)
+            log.Error("StartPersistence: open db failed", "err", err)
             return
         }
         defer db.Close()
@@ -231,7 +456,7 @@ func (c *Cache) StartPersistence(path string) {
         db.SetMaxIdleConns(1)
 
         if err := c.initDB(db); err != nil {
-            log.Printf("StartPersistence: initDB failed: %v", err)
+            log.Error("StartPersistence: initDB failed", "err", err)
             return
         }
 
@@ -242,12 +467,25 @@ func (c *Cache) StartPersistence(path string) {
         defer ticker.Stop()
         defer cleanupTicker.Stop()
 
+        // 先取完通道中较早的操作，再追加重试缓冲区中较新的操作
+        drain := func() {
+            for {
+                select {
+                case op := <-c.persistCh:
+                    batch = append(batch, op)
+                default:
+                    batch = append(batch, c.takeRetries()...)
+                    return
+                }
+            }
+        }
+
         flush := func() {
             if len(batch) == 0 {
                 return
             }
             if err := c.flushBatch(db, batch); err != nil {
-                log.Printf("Flush batch failed: %v", err)
+                log.Error("Flush batch failed", "err", err)
             }
             batch = batch[:0]
         }
@@ -265,10 +503,12 @@ func (c *Cache) StartPersistence(path string) {
                     flush()
                 }
             case <-ticker.C:
+                drain()
                 flush()
             case <-cleanupTicker.C:
                 cleanExpired()
             case <-c.stop:
+                drain()
                 flush()
                 return
             }
@@ -329,8 +569,40 @@ func (c *Cache) initDB(db *sql.DB) error {
             refresh_at INTEGER
         );
         CREATE INDEX IF NOT EXISTS idx_exp ON ip_cache(exp);
+        CREATE INDEX IF NOT EXISTS idx_value ON ip_cache(value);
     `)
-    return err
+    if err != nil {
+        return err
+    }
+    return c.migrateDB(db)
+}
+
+// migrateDB 为旧版本数据库补充新增列
+func (c *Cache) migrateDB(db *sql.DB) error {
+    rows, err := db.Query("PRAGMA table_info(ip_cache)")
+    if err != nil {
+        return err
+    }
+
+    columns := make(map[string]bool)
+    for rows.Next() {
+        var (
+            cid, notNull, pk int
+            name, typ        string
+            dflt             sql.NullString
+        )
+        if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err == nil {
+            columns[name] = true
+        }
+    }
+    rows.Close()
+
+    if !columns["detail"] {
+        if _, err := db.Exec("ALTER TABLE ip_cache ADD COLUMN detail TEXT NOT NULL DEFAULT ''"); err != nil {
+            return fmt.Errorf("add column detail failed: %w", err)
+        }
+    }
+    return nil
 }
 
 func (c *Cache) flushBatch(db *sql.DB, batch []persistenceOp) error {
@@ -341,7 +613,7 @@ func (c *Cache) flushBatch(db *sql.DB, batch []persistenceOp) error {
 
     // 务必检查 Prepare 错误并回滚
     stmtInsert, err := tx.Prepare(
-        "INSERT OR REPLACE INTO ip_cache(key, value, exp, refresh_at) VALUES(?, ?, ?, ?)",
+        "INSERT OR REPLACE INTO ip_cache(key, value, detail, exp, refresh_at) VALUES(?, ?, ?, ?, ?)",
     )
     if err != nil {
         _ = tx.Rollback()
@@ -362,7 +634,7 @@ func (c *Cache) flushBatch(db *sql.DB, batch []persistenceOp) error {
         if op.IsDelete {
             _, _ = stmtDelete.Exec(op.Key)
         } else {
-            _, _ = stmtInsert.Exec(op.Key, op.Value, op.Exp, op.RefreshAt)
+            _, _ = stmtInsert.Exec(op.Key, op.Value, op.Detail, op.Exp, op.RefreshAt)
         }
     }
 
@@ -393,7 +665,7 @@ func (c *Cache) LoadFromSQLite(path string) error {
 
     now := time.Now().UnixNano()
     rows, err := db.Query(
-        "SELECT key, value, exp, refresh_at FROM ip_cache WHERE exp > ?",
+        "SELECT key, value, detail, exp, refresh_at FROM ip_cache WHERE exp > ?",
         now,
     )
     if err != nil {
@@ -402,15 +674,64 @@ func (c *Cache) LoadFromSQLite(path string) error {
     defer rows.Close()
 
     for rows.Next() {
-        var k, v string
+        var k, v, d string
         var exp, refresh int64
-        if err := rows.Scan(&k, &v, &exp, &refresh); err == nil {
-            c.SetWithTime(k, v, exp, refresh)
+        if err := rows.Scan(&k, &v, &d, &exp, &refresh); err == nil {
+            c.SetWithTime(k, v, d, exp, refresh)
         }
     }
     return nil
 }
 
+// Range 遍历内存中所有未过期条目，fn 返回 false 时停止
+func (c *Cache) Range(fn func(key, val string) bool) {
+    now := atomic.LoadInt64(&c.now)
+    for i := 0; i < shardCount; i++ {
+        s := c.shards[i]
+
+        s.mu.RLock()
+        items := make([][2]string, 0, len(s.items))
+        for k, e := range s.items {
+            if now < e.exp {
+                items = append(items, [2]string{k, e.value})
+            }
+        }
+        s.mu.RUnlock()
+
+        // 回调在锁外执行，避免阻塞写入
+        for _, kv := range items {
+            if !fn(kv[0], kv[1]) {
+                return
+            }
+        }
+    }
+}
+
+// RefreshDue 返回内存中已进入预刷新窗口且尚未过期的 key，最多 limit 个
+func (c *Cache) RefreshDue(limit int) []string {
+    if atomic.LoadInt64(&c.refreshWindow) <= 0 {
+        return nil
+    }
+
+    now := atomic.LoadInt64(&c.now)
+    var keys []string
+    for i := 0; i < shardCount && len(keys) < limit; i++ {
+        s := c.shards[i]
+
+        s.mu.RLock()
+        for k, e := range s.items {
+            if now >= e.refreshAt && now < e.exp {
+                keys = append(keys, k)
+                if len(keys) >= limit {
+                    break
+                }
+            }
+        }
+        s.mu.RUnlock()
+    }
+    return keys
+}
+
 // ================= 只读查询 (统计) =================
 
 func (c *Cache) GetAllItems() (map[string]string, error) {
@@ -451,27 +772,130 @@ func (c *Cache) GetAllItemsContext(ctx context.Context) (map[string]string, erro
     return res, nil
 }
 
+// KeysByTag 分页查询 Tag 对应的缓存 Key (按 Key 排序)，同时返回总数
+func (c *Cache) KeysByTag(ctx context.Context, tag string, offset, limit int) ([]string, int, error) {
+    if err := c.ensureReadOnlyDB(); err != nil {
+        return nil, 0, err
+    }
+
+    c.dbMu.RLock()
+    db := c.roDB
+    c.dbMu.RUnlock()
+
+    if db == nil {
+        return nil, 0, fmt.Errorf("db not initialized")
+    }
+
+    now := atomic.LoadInt64(&c.now)
+
+    var total int
+    if err := db.QueryRowContext(ctx,
+        "SELECT COUNT(*) FROM ip_cache WHERE value = ? AND exp > ?",
+        tag, now,
+    ).Scan(&total); err != nil {
+        return nil, 0, err
+    }
+
+    rows, err := db.QueryContext(ctx,
+        "SELECT key FROM ip_cache WHERE value = ? AND exp > ? ORDER BY key LIMIT ? OFFSET ?",
+        tag, now, limit, offset,
+    )
+    if err != nil {
+        return nil, 0, err
+    }
+    defer rows.Close()
+
+    keys := make([]string, 0, limit)
+    for rows.Next() {
+        var k string
+        if err := rows.Scan(&k); err == nil {
+            keys = append(keys, k)
+        }
+    }
+    return keys, total, rows.Err()
+}
+
+// TagCounts 按 Tag 统计未过期的缓存条目数
+func (c *Cache) TagCounts(ctx context.Context) (map[string]int, error) {
+    if err := c.ensureReadOnlyDB(); err != nil {
+        return nil, err
+    }
+
+    c.dbMu.RLock()
+    db := c.roDB
+    c.dbMu.RUnlock()
+
+    if db == nil {
+        return nil, fmt.Errorf("db not initialized")
+    }
+
+    now := atomic.LoadInt64(&c.now)
+    rows, err := db.QueryContext(ctx,
+        "SELECT value, COUNT(*) FROM ip_cache WHERE exp > ? GROUP BY value",
+        now,
+    )
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    res := make(map[string]int)
+    for rows.Next() {
+        var tag string
+        var n int
+        if err := rows.Scan(&tag, &n); err == nil {
+            res[tag] = n
+        }
+    }
+    return res, rows.Err()
+}
+
+// ================= 备份 =================
+
+// Snapshot 将持久化数据库导出为一致性快照文件 (VACUUM INTO)
+func (c *Cache) Snapshot(dst string) error {
+    c.dbMu.RLock()
+    path := c.dbPath
+    c.dbMu.RUnlock()
+
+    if path == "" {
+        return fmt.Errorf("db path not set")
+    }
+
+    db, err := sql.Open("sqlite", path)
+    if err != nil {
+        return err
+    }
+    defer db.Close()
+
+    _, _ = db.Exec("PRAGMA busy_timeout=5000;")
+    if _, err := db.Exec("VACUUM INTO ?", dst); err != nil {
+        return fmt.Errorf("vacuum into failed: %w", err)
+    }
+    return nil
+}
+
 // ================= 恢复用辅助方法 =================
 
-func (c *Cache) SetWithTime(key, val string, exp, refreshAt int64) {
+func (c *Cache) SetWithTime(key, val, detail string, exp, refreshAt int64) {
     s := c.getShard(key)
     s.mu.Lock()
     defer s.mu.Unlock()
 
-    if _, ok := s.items[key]; ok {
-        s.items[key] = entry{val, exp, refreshAt}
+    rev := atomic.AddUint64(&c.rev, 1)
+
+    e := entry{value: val, detail: detail, exp: exp, refreshAt: refreshAt, rev: rev}
+
+    if old, ok := s.items[key]; ok {
+        e.meta = old.meta
+        s.items[key] = e
         return
     }
 
-    if len(s.items) >= c.shardCap {
-        for k := range s.items {
-            delete(s.items, k)
-            atomic.AddInt64(&c.count, -1)
-            break
-        }
-    }
+    c.evictOne(s)
 
-    s.items[key] = entry{val, exp, refreshAt}
+    e.meta = &accessMeta{lastAccess: atomic.LoadInt64(&c.now)}
+    s.items[key] = e
     atomic.AddInt64(&c.count, 1)
 }
 
@@ -488,6 +912,8 @@ func (c *Cache) Close() {
         c.roDB = nil
     }
     c.dbMu.Unlock()
+
+    c.closeSubscribers()
 }
 
 func (c *Cache) startClock() {
@@ -544,6 +970,56 @@ func (c *Cache) cleanupShard(s *shard, now int64) {
     }
 }
 
+// startReconcile 定期重新统计各分片的真实条目数，修正 count 的漂移
+func (c *Cache) startReconcile() {
+    ticker := time.NewTicker(reconcileInterval)
+    c.wg.Add(1)
+
+    go func() {
+        defer c.wg.Done()
+        defer ticker.Stop()
+
+        for {
+            select {
+            case <-ticker.C:
+                c.Reconcile()
+            case <-c.stop:
+                return
+            }
+        }
+    }()
+}
+
+// Reconcile 统计真实条目数并修正计数器，返回发现的偏差 (真实值 - 计数值)
+// count 只在持有对应分片写锁时增减，同时持有全部分片读锁即得到一致快照，
+// 统计只读取 len，阻塞写入的时间很短
+func (c *Cache) Reconcile() int64 {
+    var actual int64
+    for i := 0; i < shardCount; i++ {
+        c.shards[i].mu.RLock()
+        actual += int64(len(c.shards[i].items))
+    }
+    drift := actual - atomic.LoadInt64(&c.count)
+    if drift != 0 {
+        atomic.AddInt64(&c.count, drift)
+    }
+    for i := 0; i < shardCount; i++ {
+        c.shards[i].mu.RUnlock()
+    }
+
+    if drift != 0 {
+        log.Info("计数校准", "drift", drift, "count", actual)
+    }
+
+    atomic.StoreInt64(&c.lastDrift, drift)
+    if drift < 0 {
+        atomic.AddInt64(&c.totalDrift, -drift)
+    } else {
+        atomic.AddInt64(&c.totalDrift, drift)
+    }
+    return drift
+}
+
 // ================= 统计 Getter =================
 
 func (c *Cache) Count() int64 {
@@ -552,4 +1028,16 @@ func (c *Cache) Count() int64 {
 
 func (c *Cache) DroppedCount() int64 {
     return atomic.LoadInt64(&c.droppedUpdates)
+}
+
+func (c *Cache) RetriedCount() int64 {
+    return atomic.LoadInt64(&c.retriedUpdates)
+}
+
+func (c *Cache) LastDrift() int64 {
+    return atomic.LoadInt64(&c.lastDrift)
+}
+
+func (c *Cache) TotalDrift() int64 {
+    return atomic.LoadInt64(&c.totalDrift)
 }
\ No newline at end of file
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompareAndSet(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(c *Cache) uint64 // 准备缓存状态，返回写入时使用的 rev
+		ok    bool
+	}{
+		{"missing key expects 0", func(c *Cache) uint64 { return 0 }, true},
+		{"missing key with stale rev", func(c *Cache) uint64 { return 42 }, false},
+		{"current rev", func(c *Cache) uint64 {
+			c.Set("k", "old")
+			return c.Revision("k")
+		}, true},
+		{"existing key expects 0", func(c *Cache) uint64 {
+			c.Set("k", "old")
+			return 0
+		}, false},
+		{"overwritten since read", func(c *Cache) uint64 {
+			c.Set("k", "old")
+			rev := c.Revision("k")
+			c.Set("k", "newer")
+			return rev
+		}, false},
+		{"pinned since read", func(c *Cache) uint64 {
+			c.Set("k", "old")
+			rev := c.Revision("k")
+			c.Pin("k", "manual", "", false)
+			return rev
+		}, false},
+		{"deleted since read", func(c *Cache) uint64 {
+			c.Set("k", "old")
+			rev := c.Revision("k")
+			c.Delete("k")
+			return rev
+		}, false},
+		{"expired entry counts as missing", func(c *Cache) uint64 {
+			past := time.Now().Add(-time.Minute).UnixNano()
+			c.SetWithTime("k", "old", "", past, past)
+			return c.Revision("k")
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(time.Hour, 0.1)
+			defer c.Close()
+
+			rev := tt.setup(c)
+			before, _, _, _ := c.Get("k")
+
+			if got := c.CompareAndSet("k", "resolved", "", rev, SourceMiss); got != tt.ok {
+				t.Fatalf("CompareAndSet = %v, want %v", got, tt.ok)
+			}
+			val, _, _, _ := c.Get("k")
+			switch {
+			case tt.ok && (val != "resolved" || c.Revision("k") <= rev):
+				t.Fatalf("after write: value %q rev %d (was %d)", val, c.Revision("k"), rev)
+			case !tt.ok && val != before:
+				t.Fatalf("rejected write changed value %q -> %q", before, val)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package cache
+
+import "time"
+
+// ================= 变更订阅 =================
+
+const (
+    OpSet    = "set"
+    OpDelete = "delete"
+
+    // 变更来源
+    SourceMiss    = "miss"    // 缓存未命中后解析
+    SourceRefresh = "refresh" // 预刷新
+    SourceSet     = "set"     // 直接调用 Set
+    SourceDelete  = "delete"  // 直接调用 Delete
+    SourceManual  = "manual"  // 人工指定 (PUT /{cidr})
+)
+
+// ChangeEvent 缓存变更事件
+type ChangeEvent struct {
+    Op     string    `json:"op"`
+    Key    string    `json:"key"`
+    Tag    string    `json:"tag,omitempty"`
+    Source string    `json:"source"`
+    Time   time.Time `json:"time"`
+}
+
+// Subscribe 订阅缓存变更，返回事件通道与取消函数
+// 订阅者消费过慢时事件会被丢弃，不会阻塞缓存写入
+func (c *Cache) Subscribe(buf int) (<-chan ChangeEvent, func()) {
+    return c.changes.Subscribe(buf)
+}
+
+// DroppedChanges 返回因订阅者过慢而丢弃的事件数
+func (c *Cache) DroppedChanges() int64 {
+    return c.changes.Dropped()
+}
+
+func (c *Cache) publish(op, key, val, source string) {
+    if !c.changes.HasSubscribers() {
+        return
+    }
+    c.changes.Publish(ChangeEvent{Op: op, Key: key, Tag: val, Source: source, Time: time.Now()})
+}
+
+// closeSubscribers 关闭所有订阅通道 (缓存关闭时调用)
+func (c *Cache) closeSubscribers() {
+    c.changes.Close()
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+    "context"
+    "fmt"
+    "strings"
+)
+
+// ================= 每日汇总持久化 =================
+
+// DailyRecord 一天的汇总报告，Data 为调用方编码的 JSON
+type DailyRecord struct {
+    Date string // YYYY-MM-DD
+    Data string
+}
+
+// dailyKeyPrefix 每日汇总在 app_state 中的 key 前缀，后接 YYYY-MM-DD，按 key 排序即按日期排序
+const dailyKeyPrefix = "daily_report:"
+
+// SaveDailyReport 写入 (覆盖) 某一天的汇总，并删除 keepAfter 之前的记录 (keepAfter 为空时不清理)
+func (c *Cache) SaveDailyReport(date, data string, updatedAt int64, keepAfter string) error {
+    db, err := c.openAuxDB(stateSchema)
+    if err != nil {
+        return err
+    }
+    defer db.Close()
+
+    if _, err := db.Exec("INSERT OR REPLACE INTO app_state(key, data, updated_at) VALUES(?, ?, ?)", dailyKeyPrefix+date, data, updatedAt); err != nil {
+        return fmt.Errorf("save daily report failed: %w", err)
+    }
+    if keepAfter != "" {
+        if _, err := db.Exec("DELETE FROM app_state WHERE key >= ? AND key < ?", dailyKeyPrefix, dailyKeyPrefix+keepAfter); err != nil {
+            return fmt.Errorf("prune daily report failed: %w", err)
+        }
+    }
+    return nil
+}
+
+// DailyReports 返回 [from, to] 范围内的汇总 (按日期降序)
+func (c *Cache) DailyReports(ctx context.Context, from, to string) ([]DailyRecord, error) {
+    db, err := c.openAuxDB(stateSchema)
+    if err != nil {
+        return nil, err
+    }
+    defer db.Close()
+
+    rows, err := db.QueryContext(ctx, "SELECT key, data FROM app_state WHERE key >= ? AND key <= ? ORDER BY key DESC", dailyKeyPrefix+from, dailyKeyPrefix+to)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var out []DailyRecord
+    for rows.Next() {
+        var r DailyRecord
+        if err := rows.Scan(&r.Date, &r.Data); err != nil {
+            return nil, err
+        }
+        r.Date = strings.TrimPrefix(r.Date, dailyKeyPrefix)
+        out = append(out, r)
+    }
+    return out, rows.Err()
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+    "fmt"
+    "sync/atomic"
+)
+
+// ================= 淘汰策略 =================
+
+type EvictionPolicy string
+
+const (
+    EvictRandom     EvictionPolicy = "random"      // 随机淘汰 (默认)
+    EvictLRU        EvictionPolicy = "lru"         // 最久未访问
+    EvictLFU        EvictionPolicy = "lfu"         // 访问次数最少
+    EvictTTLNearest EvictionPolicy = "ttl_nearest" // 最先过期
+
+    // 近似淘汰的采样数量 (同 Redis 的 maxmemory-samples)
+    evictionSamples = 5
+)
+
+// accessMeta 访问统计，由同一 key 的各个 entry 副本共享，使用原子操作更新
+type accessMeta struct {
+    lastAccess int64
+    hits       uint32
+}
+
+func (m *accessMeta) touch(now int64) {
+    atomic.StoreInt64(&m.lastAccess, now)
+    atomic.AddUint32(&m.hits, 1)
+}
+
+// ParseEvictionPolicy 解析配置中的淘汰策略名称
+func ParseEvictionPolicy(name string) (EvictionPolicy, error) {
+    switch p := EvictionPolicy(name); p {
+    case "":
+        return EvictRandom, nil
+    case EvictRandom, EvictLRU, EvictLFU, EvictTTLNearest:
+        return p, nil
+    default:
+        return "", fmt.Errorf("未知淘汰策略: %s", name)
+    }
+}
+
+// SetEvictionPolicy 设置分片满时的淘汰策略，需在写入数据前调用
+func (c *Cache) SetEvictionPolicy(p EvictionPolicy) {
+    c.policy = p
+    c.trackAccess = p == EvictLRU || p == EvictLFU
+}
+
+// evictOne 在分片已满时淘汰一个条目 (调用方需持有写锁)
+// 非随机策略通过采样若干条目近似选出淘汰对象，避免全表扫描
+func (c *Cache) evictOne(s *shard) {
+    if len(s.items) < c.shardCap {
+        return
+    }
+
+    var (
+        victim string
+        best   int64
+        n      int
+    )
+    for k, e := range s.items {
+        score := c.evictionScore(e)
+        if n == 0 || score < best {
+            victim, best = k, score
+        }
+        n++
+        if c.policy == EvictRandom || n >= evictionSamples {
+            break
+        }
+    }
+
+    delete(s.items, victim)
+    atomic.AddInt64(&c.count, -1)
+}
+
+// evictionScore 分数越小越优先被淘汰
+func (c *Cache) evictionScore(e entry) int64 {
+    switch c.policy {
+    case EvictLRU:
+        return atomic.LoadInt64(&e.meta.lastAccess)
+    case EvictLFU:
+        return int64(atomic.LoadUint32(&e.meta.hits))
+    case EvictTTLNearest:
+        return e.exp
+    default:
+        return 0
+    }
+}
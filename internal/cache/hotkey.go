@@ -0,0 +1,156 @@
+package cache
+
+import (
+    "sort"
+    "sync"
+    "time"
+)
+
+// ================= 热点 Key 统计 =================
+
+const (
+    // 按 Key 哈希分片，每个分片独立加锁，避免每次查询都竞争同一把锁；总计数器数与分片前相同
+    hotKeyShards = 16
+    sketchDepth  = 4
+    sketchWidth  = 2048 / hotKeyShards
+
+    // 每隔 hotKeyDecayInterval 将所有计数减半，使统计偏向近期流量
+    hotKeyDecayInterval = 10 * time.Minute
+)
+
+// HotKey 热点 Key 及其估算访问次数
+type HotKey struct {
+    Key   string `json:"key"`
+    Count uint64 `json:"count"`
+}
+
+// HotKeyTracker 基于 Count-Min Sketch 估算访问频率，并维护一个 Top-N 候选集
+// 同一 Key 总是落在同一分片，各分片各自维护 Top-N，查询时合并
+type HotKeyTracker struct {
+    shards [hotKeyShards]hotKeyShard
+    size   int
+}
+
+type hotKeyShard struct {
+    mu      sync.Mutex
+    sketch  [sketchDepth][sketchWidth]uint32
+    top     map[string]uint64
+    size    int
+    decayAt time.Time
+}
+
+func NewHotKeyTracker(size int) *HotKeyTracker {
+    if size <= 0 {
+        size = 20
+    }
+    t := &HotKeyTracker{size: size}
+    decayAt := time.Now().Add(hotKeyDecayInterval)
+    for i := range t.shards {
+        t.shards[i].top = make(map[string]uint64, size)
+        t.shards[i].size = size
+        t.shards[i].decayAt = decayAt
+    }
+    return t
+}
+
+// Touch 记录一次 key 访问
+func (t *HotKeyTracker) Touch(key string) {
+    h1, h2 := sketchHash(key)
+    t.shards[h1%hotKeyShards].touch(key, h1/hotKeyShards, h2)
+}
+
+func (t *hotKeyShard) touch(key string, h1, h2 uint64) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    if time.Now().After(t.decayAt) {
+        t.decay()
+    }
+
+    // 取各行最小值作为估算值 (Conservative Update)
+    var est uint32 = ^uint32(0)
+    for i := 0; i < sketchDepth; i++ {
+        idx := (h1 + uint64(i)*h2) % sketchWidth
+        if t.sketch[i][idx] < est {
+            est = t.sketch[i][idx]
+        }
+    }
+    est++
+    for i := 0; i < sketchDepth; i++ {
+        idx := (h1 + uint64(i)*h2) % sketchWidth
+        if t.sketch[i][idx] < est {
+            t.sketch[i][idx] = est
+        }
+    }
+
+    if _, ok := t.top[key]; ok || len(t.top) < t.size {
+        t.top[key] = uint64(est)
+        return
+    }
+
+    // 候选集已满，替换计数最小者
+    minKey, minCount := "", ^uint64(0)
+    for k, v := range t.top {
+        if v < minCount {
+            minKey, minCount = k, v
+        }
+    }
+    if uint64(est) > minCount {
+        delete(t.top, minKey)
+        t.top[key] = uint64(est)
+    }
+}
+
+// Top 返回按访问次数降序排列的热点 Key (最多 size 个)
+func (t *HotKeyTracker) Top(n int) []HotKey {
+    var res []HotKey
+    for i := range t.shards {
+        s := &t.shards[i]
+        s.mu.Lock()
+        for k, v := range s.top {
+            res = append(res, HotKey{Key: k, Count: v})
+        }
+        s.mu.Unlock()
+    }
+
+    sort.Slice(res, func(i, j int) bool {
+        if res[i].Count != res[j].Count {
+            return res[i].Count > res[j].Count
+        }
+        return res[i].Key < res[j].Key
+    })
+    if n <= 0 || n > t.size {
+        n = t.size
+    }
+    if len(res) > n {
+        res = res[:n]
+    }
+    return res
+}
+
+// decay 将分片的所有计数减半 (调用方需持有锁)
+func (t *hotKeyShard) decay() {
+    for i := range t.sketch {
+        for j := range t.sketch[i] {
+            t.sketch[i][j] >>= 1
+        }
+    }
+    for k, v := range t.top {
+        if v >>= 1; v == 0 {
+            delete(t.top, k)
+        } else {
+            t.top[k] = v
+        }
+    }
+    t.decayAt = time.Now().Add(hotKeyDecayInterval)
+}
+
+// sketchHash 基于 FNV-1a 生成双哈希 (Kirsch-Mitzenmacher)
+func sketchHash(key string) (uint64, uint64) {
+    var h uint64 = 14695981039346656037
+    for i := 0; i < len(key); i++ {
+        h ^= uint64(key[i])
+        h *= 1099511628211
+    }
+    return h, (h >> 32) | 1
+}
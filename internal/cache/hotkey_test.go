@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestHotKeyTrackerTop(t *testing.T) {
+	tr := NewHotKeyTracker(3)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 50; n++ {
+				tr.Touch("hot-1")
+				if n%2 == 0 {
+					tr.Touch("hot-2")
+				}
+				if n%5 == 0 {
+					tr.Touch("hot-3")
+				}
+				tr.Touch(fmt.Sprintf("cold-%d-%d", i, n))
+			}
+		}()
+	}
+	wg.Wait()
+
+	top := tr.Top(0)
+	if len(top) != 3 {
+		t.Fatalf("Top(0) returned %d keys, want size 3: %+v", len(top), top)
+	}
+	for i, want := range []string{"hot-1", "hot-2", "hot-3"} {
+		if top[i].Key != want {
+			t.Fatalf("top = %+v, want hot-1, hot-2, hot-3", top)
+		}
+	}
+	if top[0].Count < 200 {
+		t.Fatalf("hot-1 count = %d, want >= 200", top[0].Count)
+	}
+	if got := tr.Top(1); len(got) != 1 || got[0].Key != "hot-1" {
+		t.Fatalf("Top(1) = %+v", got)
+	}
+}
@@ -0,0 +1,127 @@
+package cache
+
+import (
+    "database/sql"
+    "fmt"
+)
+
+// ================= 待解析队列持久化 =================
+
+// QueuedJob 重启时需要保留的待解析任务
+type QueuedJob struct {
+    ID         string // 解析任务 ID
+    IP         string
+    RequestID  string
+    Attempt    int  // 已重试次数
+    Background bool // 后台刷新 / 预热任务
+}
+
+// HasStore 是否配置了 SQLite 持久化
+func (c *Cache) HasStore() bool {
+    c.dbMu.RLock()
+    defer c.dbMu.RUnlock()
+    return c.dbPath != ""
+}
+
+const queueSchema = `
+    CREATE TABLE IF NOT EXISTS pending_queue (
+        ip TEXT PRIMARY KEY,
+        request_id TEXT NOT NULL DEFAULT '',
+        attempt INTEGER NOT NULL DEFAULT 0,
+        background INTEGER NOT NULL DEFAULT 0,
+        job_id TEXT NOT NULL DEFAULT ''
+    );
+`
+
+func (c *Cache) openQueueDB() (*sql.DB, error) {
+    db, err := c.openAuxDB(queueSchema)
+    if err != nil {
+        return nil, err
+    }
+
+    // 兼容旧版本创建的表
+    var n int
+    if err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('pending_queue') WHERE name = 'job_id'").Scan(&n); err == nil && n == 0 {
+        if _, err := db.Exec("ALTER TABLE pending_queue ADD COLUMN job_id TEXT NOT NULL DEFAULT ''"); err != nil {
+            _ = db.Close()
+            return nil, fmt.Errorf("add column job_id failed: %w", err)
+        }
+    }
+    return db, nil
+}
+
+// SaveQueue 保存待解析任务 (覆盖上一次保存的内容)，供下次启动时恢复
+func (c *Cache) SaveQueue(jobs []QueuedJob) error {
+    db, err := c.openQueueDB()
+    if err != nil {
+        return err
+    }
+    defer db.Close()
+
+    tx, err := db.Begin()
+    if err != nil {
+        return err
+    }
+
+    if _, err := tx.Exec("DELETE FROM pending_queue"); err != nil {
+        _ = tx.Rollback()
+        return fmt.Errorf("clear pending queue failed: %w", err)
+    }
+
+    stmt, err := tx.Prepare("INSERT OR REPLACE INTO pending_queue(ip, request_id, attempt, background, job_id) VALUES(?, ?, ?, ?, ?)")
+    if err != nil {
+        _ = tx.Rollback()
+        return fmt.Errorf("prepare insert failed: %w", err)
+    }
+    defer stmt.Close()
+
+    for _, j := range jobs {
+        if _, err := stmt.Exec(j.IP, j.RequestID, j.Attempt, j.Background, j.ID); err != nil {
+            _ = tx.Rollback()
+            return fmt.Errorf("insert pending job failed: %w", err)
+        }
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("commit failed: %w", err)
+    }
+    return nil
+}
+
+// TakeQueue 读取并清空上次保存的待解析任务 (首次查询任务在前)
+func (c *Cache) TakeQueue() ([]QueuedJob, error) {
+    db, err := c.openQueueDB()
+    if err != nil {
+        return nil, err
+    }
+    defer db.Close()
+
+    tx, err := db.Begin()
+    if err != nil {
+        return nil, err
+    }
+
+    rows, err := tx.Query("SELECT ip, request_id, attempt, background, job_id FROM pending_queue ORDER BY background, rowid")
+    if err != nil {
+        _ = tx.Rollback()
+        return nil, err
+    }
+
+    var jobs []QueuedJob
+    for rows.Next() {
+        var j QueuedJob
+        if err := rows.Scan(&j.IP, &j.RequestID, &j.Attempt, &j.Background, &j.ID); err == nil {
+            jobs = append(jobs, j)
+        }
+    }
+    rows.Close()
+
+    if _, err := tx.Exec("DELETE FROM pending_queue"); err != nil {
+        _ = tx.Rollback()
+        return nil, fmt.Errorf("clear pending queue failed: %w", err)
+    }
+    if err := tx.Commit(); err != nil {
+        return nil, fmt.Errorf("commit failed: %w", err)
+    }
+    return jobs, nil
+}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 并发写入与删除期间校准不应报告偏差，人为制造的偏差被修正
+func TestReconcile(t *testing.T) {
+	c := New(time.Hour, 0.1)
+	defer c.Close()
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				key := fmt.Sprintf("%d.%d.0.0", w, i%4096)
+				if i%3 == 0 {
+					c.Delete(key)
+				} else {
+					c.Set(key, "tag")
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 5000; i++ {
+		if drift := c.Reconcile(); drift != 0 {
+			close(stop)
+			wg.Wait()
+			t.Fatalf("pass %d: drift = %d under concurrent writes", i, drift)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	atomic.AddInt64(&c.count, 5)
+	if drift := c.Reconcile(); drift != -5 {
+		t.Fatalf("drift = %d, want -5", drift)
+	}
+	if drift := c.Reconcile(); drift != 0 || c.TotalDrift() != 5 {
+		t.Fatalf("after fix: drift = %d, total = %d", drift, c.TotalDrift())
+	}
+}
@@ -0,0 +1,71 @@
+package cache
+
+import (
+    "sync/atomic"
+    "time"
+)
+
+// ================= 只读快照 =================
+
+// EnableSnapshot 定期将所有分片复制为一份不可变 map，Get 优先无锁读取快照
+// 快照中的值最多滞后 interval，适用于读远多于写、分片锁竞争明显的场景
+func (c *Cache) EnableSnapshot(interval time.Duration) {
+    if interval <= 0 {
+        return
+    }
+
+    c.rebuildSnapshot()
+
+    ticker := time.NewTicker(interval)
+    c.wg.Add(1)
+
+    go func() {
+        defer c.wg.Done()
+        defer ticker.Stop()
+
+        for {
+            select {
+            case <-ticker.C:
+                c.rebuildSnapshot()
+            case <-c.stop:
+                return
+            }
+        }
+    }()
+}
+
+func (c *Cache) rebuildSnapshot() {
+    snap := make(map[string]entry, atomic.LoadInt64(&c.count))
+    for i := 0; i < shardCount; i++ {
+        s := c.shards[i]
+        s.mu.RLock()
+        for k, e := range s.items {
+            snap[k] = e
+        }
+        s.mu.RUnlock()
+    }
+    c.snapshot.Store(&snap)
+}
+
+// lookupSnapshot 从快照读取未进入刷新窗口的有效条目
+// 已过期或需要刷新的条目交由分片判断，避免基于旧数据重复触发刷新
+func (c *Cache) lookupSnapshot(key string, now int64) (entry, bool) {
+    snap := c.snapshot.Load()
+    if snap == nil {
+        return entry{}, false
+    }
+
+    e, ok := (*snap)[key]
+    if !ok || now >= e.exp {
+        return entry{}, false
+    }
+    if atomic.LoadInt64(&c.refreshWindow) > 0 && now >= e.refreshAt {
+        return entry{}, false
+    }
+    return e, true
+}
+
+// invalidateSnapshot 丢弃当前快照，直到下次重建 (删除操作需立即生效)
+func (c *Cache) invalidateSnapshot() {
+    c.snapshot.Store(nil)
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+)
+
+// ================= 运行状态持久化 =================
+
+// openAuxDB 打开缓存数据库并确保 schema 中的辅助表存在 (运行状态、待解析队列)，
+// 调用方负责关闭；辅助表与 ip_cache 共用同一个文件，读写频率低，不复用常驻连接
+func (c *Cache) openAuxDB(schema string) (*sql.DB, error) {
+    c.dbMu.RLock()
+    path := c.dbPath
+    c.dbMu.RUnlock()
+
+    if path == "" {
+        return nil, fmt.Errorf("db path not set")
+    }
+
+    db, err := sql.Open("sqlite", path)
+    if err != nil {
+        return nil, err
+    }
+
+    _, _ = db.Exec("PRAGMA busy_timeout=5000;")
+    if _, err := db.Exec(schema); err != nil {
+        _ = db.Close()
+        return nil, err
+    }
+    return db, nil
+}
+
+const stateSchema = `
+    CREATE TABLE IF NOT EXISTS app_state (
+        key TEXT PRIMARY KEY,
+        data TEXT NOT NULL,
+        updated_at INTEGER NOT NULL
+    );
+`
+
+// SaveState 保存 (覆盖) 一项需要跨重启保留的运行状态，data 为调用方编码的 JSON
+func (c *Cache) SaveState(key, data string, updatedAt int64) error {
+    db, err := c.openAuxDB(stateSchema)
+    if err != nil {
+        return err
+    }
+    defer db.Close()
+
+    if _, err := db.Exec("INSERT OR REPLACE INTO app_state(key, data, updated_at) VALUES(?, ?, ?)", key, data, updatedAt); err != nil {
+        return fmt.Errorf("save state failed: %w", err)
+    }
+    return nil
+}
+
+// LoadState 读取运行状态，不存在时返回 false
+func (c *Cache) LoadState(key string) (string, bool, error) {
+    db, err := c.openAuxDB(stateSchema)
+    if err != nil {
+        return "", false, err
+    }
+    defer db.Close()
+
+    var data string
+    err = db.QueryRow("SELECT data FROM app_state WHERE key = ?", key).Scan(&data)
+    if errors.Is(err, sql.ErrNoRows) {
+        return "", false, nil
+    }
+    if err != nil {
+        return "", false, err
+    }
+    return data, true, nil
+}
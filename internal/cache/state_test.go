@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func newStoreCache(t *testing.T) *Cache {
+	t.Helper()
+	return &Cache{dbPath: filepath.Join(t.TempDir(), "cache.db")}
+}
+
+func TestState(t *testing.T) {
+	c := newStoreCache(t)
+	if _, ok, err := c.LoadState("monitor_counters"); ok || err != nil {
+		t.Fatalf("LoadState on empty db: ok=%v err=%v", ok, err)
+	}
+	for _, v := range []string{`{"n":1}`, `{"n":2}`} {
+		if err := c.SaveState("monitor_counters", v, 1); err != nil {
+			t.Fatal(err)
+		}
+		if got, ok, err := c.LoadState("monitor_counters"); !ok || err != nil || got != v {
+			t.Fatalf("LoadState = %q %v %v, want %q", got, ok, err, v)
+		}
+	}
+
+	if _, _, err := (&Cache{}).LoadState("monitor_counters"); err == nil {
+		t.Fatal("LoadState without db path should fail")
+	}
+}
+
+// 每日汇总与其他运行状态共用 app_state，范围查询与清理只涉及 daily_report: 前缀
+func TestDailyReports(t *testing.T) {
+	c := newStoreCache(t)
+	if err := c.SaveState("monitor_counters", "{}", 1); err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []string{"2026-01-30", "2026-01-31", "2026-02-01", "2026-02-02"} {
+		if err := c.SaveDailyReport(d, `"`+d+`"`, 1, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		from, to string
+		want     []string
+	}{
+		{"all descending", "2026-01-01", "2026-12-31", []string{"2026-02-02", "2026-02-01", "2026-01-31", "2026-01-30"}},
+		{"inclusive bounds", "2026-01-31", "2026-02-01", []string{"2026-02-01", "2026-01-31"}},
+		{"single day", "2026-02-02", "2026-02-02", []string{"2026-02-02"}},
+		{"empty range", "2025-01-01", "2025-12-31", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recs, err := c.DailyReports(context.Background(), tt.from, tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(recs) != len(tt.want) {
+				t.Fatalf("got %d records, want %d", len(recs), len(tt.want))
+			}
+			for i, r := range recs {
+				if r.Date != tt.want[i] || r.Data != `"`+tt.want[i]+`"` {
+					t.Fatalf("record %d = %+v, want %s", i, r, tt.want[i])
+				}
+			}
+		})
+	}
+
+	// 覆盖写入并清理 keepAfter 之前的记录，不影响其他状态
+	if err := c.SaveDailyReport("2026-02-02", `"updated"`, 2, "2026-02-01"); err != nil {
+		t.Fatal(err)
+	}
+	recs, err := c.DailyReports(context.Background(), "2026-01-01", "2026-12-31")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 2 || recs[0].Data != `"updated"` || recs[1].Date != "2026-02-01" {
+		t.Fatalf("after prune: %+v", recs)
+	}
+	if _, ok, _ := c.LoadState("monitor_counters"); !ok {
+		t.Fatal("prune removed unrelated state")
+	}
+}
+
+func TestQueue(t *testing.T) {
+	c := newStoreCache(t)
+	jobs := []QueuedJob{
+		{ID: "b1", IP: "2.2.2.0", Background: true},
+		{ID: "a1", IP: "1.1.1.0", RequestID: "req-1", Attempt: 2},
+	}
+	if err := c.SaveQueue(jobs); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.TakeQueue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 首次查询任务在前
+	if len(got) != 2 || got[0] != jobs[1] || got[1] != jobs[0] {
+		t.Fatalf("TakeQueue = %+v", got)
+	}
+	if got, err := c.TakeQueue(); err != nil || len(got) != 0 {
+		t.Fatalf("second TakeQueue = %+v %v", got, err)
+	}
+}
@@ -1,7 +1,13 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"ip-resolver/internal/remoteconfig"
+	"net/url"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -11,55 +17,529 @@ type Config struct {
 	// Server
 	ListenAddr  string `mapstructure:"listen_addr"`
 	MonitorAddr string `mapstructure:"monitor_addr"`
+	GRPCAddr    string `mapstructure:"grpc_addr"` // 留空不启用 gRPC
+
+	// HTTP/2: TLS 下通过 ALPN 协商，h2c 为明文 HTTP/2 (prior knowledge)，仅建议在受信任代理后开启
+	HTTP2 bool `mapstructure:"http2"`
+	H2C   bool `mapstructure:"h2c"`
+
+	// 响应压缩 (gzip / deflate)
+	Compression bool `mapstructure:"compression"`
+
+	// 管理接口
+	Admin AdminConfig `mapstructure:"admin"`
+
+	// 来源 IP 访问控制 (基于直连地址)
+	APIACL     ACLConfig `mapstructure:"api_acl"`
+	MonitorACL ACLConfig `mapstructure:"monitor_acl"`
+
+	// TLS (可选，API 支持 mTLS)
+	APITLS     TLSConfig `mapstructure:"api_tls"`
+	MonitorTLS TLSConfig `mapstructure:"monitor_tls"`
+
+	// 在监控端口挂载 /debug/pprof/ (需携带管理 Token)
+	MonitorPprof bool `mapstructure:"monitor_pprof"`
+
+	// 监控端口 /errors 保留的最近错误条数，0 为不保留
+	ErrorHistorySize int `mapstructure:"error_history_size"`
+
+	// 监控累计计数 (上游调用数等) 写入 SQLite 的间隔，重启后继续累计，0 为不持久化 (需配置 cache_store_path)
+	CounterPersistIntervalSeconds int `mapstructure:"counter_persist_interval_seconds"`
+
+	// 就绪探针 (/readyz) 判定条件
+	Health HealthConfig `mapstructure:"health"`
+	DNSAddr     string `mapstructure:"dns_addr"`  // 留空不启用 DNS (UDP)
+	DNSZone     string `mapstructure:"dns_zone"`
 	WorkerConcurrency int `mapstructure:"worker_concurrency"`
+	WorkerMinConcurrency int `mapstructure:"worker_min_concurrency"` // 自动伸缩下限
+	WorkerMaxConcurrency int `mapstructure:"worker_max_concurrency"` // 自动伸缩上限，0 为关闭 (固定 worker_concurrency)
+	FetchMaxRetries      int `mapstructure:"fetch_max_retries"`      // 上游查询失败后的重试次数，耗尽后进入死信列表
+	FetchRetryBackoffMs  int `mapstructure:"fetch_retry_backoff_ms"` // 首次重试等待 (毫秒)，之后逐次翻倍
+	FetchTransientMaxRequeues int `mapstructure:"fetch_transient_max_requeues"` // 超时 / 5xx 等短暂故障的最多重新入队次数 (不计入重试次数)
+	ProviderTimeoutMs    int     `mapstructure:"provider_timeout_ms"` // 上游单次请求超时 (毫秒)，可被 provider.timeout_ms 覆盖
+	ProviderRateLimit    float64 `mapstructure:"provider_rate_limit"` // 所有 Worker 合计的上游 QPS 上限，0 为不限制
+	AdaptiveConcurrency  AdaptiveConcurrencyConfig `mapstructure:"adaptive_concurrency"`
+	WorkerBatchSize      int `mapstructure:"worker_batch_size"`      // 提供商支持批量查询时单次合并的 IP 数，1 为关闭
+	QueueSize         int `mapstructure:"queue_size"` // 解析队列容量 (首次查询与后台刷新各一份)，不得小于 worker_concurrency
+	QueueShards       int `mapstructure:"queue_shards"` // 队列分片数，Worker 优先处理所在分片并窃取其他分片，1 为单队列
+	RefreshShedQueueDepth int `mapstructure:"refresh_shed_queue_depth"` // 队列合计深度达到该值时跳过预刷新，0 为关闭
+	RefreshShedInflight   int `mapstructure:"refresh_shed_inflight"`    // 处理中任务数达到该值时跳过预刷新，0 为关闭
+	LookupMaxWaitMs   int `mapstructure:"lookup_max_wait_ms"` // ?wait=1 同步等待上限 (毫秒)
+	LookupCallbackEnabled   bool `mapstructure:"lookup_callback_enabled"`    // 是否允许 ?callback= 完成回调
+	LookupCallbackTimeoutMs int  `mapstructure:"lookup_callback_timeout_ms"` // 回调等待解析上限 (毫秒)
+	LookupCallbackAllowedHosts []string `mapstructure:"lookup_callback_allowed_hosts"` // 允许回调的主机 (支持 *.example.com)
+	LookupCallbackAllowPrivate bool     `mapstructure:"lookup_callback_allow_private"` // 允许回调到回环 / 私有 / 链路本地地址
+	TrustedProxies    []string `mapstructure:"trusted_proxies"` // 受信任代理 CIDR，用于 /self 解析 X-Forwarded-For
+	AuthRequestHeader string   `mapstructure:"auth_request_header"` // /auth 接口读取 IP 的请求头
+	LookupPathPrefix  string   `mapstructure:"lookup_path_prefix"`  // 单 IP 查询的路由前缀，如 /api/v1/resolve/
+	LegacyLookupRoute bool     `mapstructure:"legacy_lookup_route"` // 是否保留 /<ip> 旧路由
 
 	// Cache
 	CacheTTLSeconds   int64 `mapstructure:"cache_ttl_seconds"`
 	CacheRefreshRatio int   `mapstructure:"cache_refresh_ratio"`
+	CacheRefreshJitterPercent int `mapstructure:"cache_refresh_jitter_percent"` // 在预刷新窗口前段随机推迟刷新起点的比例 (0-100)
 	CacheStorePath    string `mapstructure:"cache_store_path"`
+	CacheSnapshotIntervalSeconds int `mapstructure:"cache_snapshot_interval_seconds"` // 0 为关闭只读快照
+	CacheEvictionPolicy string `mapstructure:"cache_eviction_policy"` // random / lru / lfu / ttl_nearest
+	HitRatioWindows   []string `mapstructure:"hit_ratio_windows"` // 滚动命中率窗口，如 1m / 5m / 1h
+
+	// CIDR 预热每秒入队的子网数
+	PreloadRatePerSecond int `mapstructure:"preload_rate_per_second"`
 
-	// Provider 配置
-	Provider ProviderConfig `mapstructure:"provider"`
+	// 定期扫描进入预刷新窗口的缓存条目 (间隔为 0 关闭)
+	RefreshCrawlIntervalSeconds int `mapstructure:"refresh_crawl_interval_seconds"`
+	RefreshCrawlRatePerSecond   int `mapstructure:"refresh_crawl_rate_per_second"`
+
+	// 处理中标记的最长保留时间，超时视为泄漏并清除 (0 关闭)
+	InflightMaxAgeSeconds int `mapstructure:"inflight_max_age_seconds"`
+
+	// 首次查询队列已满时的溢出日志 (留空关闭)，最多保存条目数
+	QueueSpillPath     string `mapstructure:"queue_spill_path"`
+	QueueSpillMaxItems int    `mapstructure:"queue_spill_max_items"`
+
+	// 关闭时继续处理队列的最长时间，超时后剩余任务持久化 (未配置 cache_store_path 时丢弃)
+	ShutdownDrainTimeoutSeconds int `mapstructure:"shutdown_drain_timeout_seconds"`
+
+	// 结果为 fallback 或仍在解析中时的响应定制
+	FallbackResponse FallbackConfig `mapstructure:"fallback_response"`
+
+	// 缓存变更推送地址 (留空不推送)
+	ChangeWebhookURL string `mapstructure:"change_webhook_url"`
+
+	// Provider 配置: providers 列表 (按角色与权重)，或单个 provider 配置段 (等同于只有一个 primary 的列表，可通过环境变量设置)
+	Providers []ProviderConfig `mapstructure:"providers"`
+	Provider  ProviderConfig   `mapstructure:"provider"`
+
+	// IPv6: 聚合前缀长度 (0 为关闭)，以及可选的 IPv6 专用提供商 (留空则共用 primary 提供商)
+	IPv6PrefixLen int            `mapstructure:"ipv6_prefix_len"`
+	IPv6Provider  ProviderConfig `mapstructure:"ipv6_provider"`
 
 	// Quota 配置
 	Quota QuotaConfig `mapstructure:"quota"`
 
+	// Backup 配置
+	Backup BackupConfig `mapstructure:"backup"`
+
+	// 告警 Webhook
+	Alert AlertConfig `mapstructure:"alert"`
+
+	// StatsD / Datadog 指标推送
+	StatsD StatsDConfig `mapstructure:"statsd"`
+
+	// Prometheus Pushgateway / remote-write 指标推送
+	PromPush PromPushConfig `mapstructure:"prometheus_push"`
+
+	// 慢调用记录
+	SlowCall SlowCallConfig `mapstructure:"slow_call"`
+
+	// 业务端口的 SLO (可用性 / 延迟)
+	SLO SLOConfig `mapstructure:"slo"`
+
+	// Sentry / Bugsnag 错误上报
+	ErrorReport ErrorReportConfig `mapstructure:"error_report"`
+
 	// Log
-	LogLevel string `mapstructure:"log_level"`
-	LogFile  string `mapstructure:"log_file"`
+	LogLevel  string `mapstructure:"log_level"`
+	LogFile   string `mapstructure:"log_file"`
+	LogFormat string `mapstructure:"log_format"` // text / json
+
+	// 环境 profile (如 dev / staging / prod)，在主配置文件之上合并同目录的 config.<profile>.yaml
+	Profile string `mapstructure:"profile"`
+
+	// 监视配置文件变化并自动热加载 (SIGHUP 始终可用)
+	ConfigWatch bool `mapstructure:"config_watch"`
+
+	// age 私钥 (age-keygen 生成)，用于解密 enc: 前缀的配置值，通常通过环境变量或 age_identity_file 提供
+	AgeIdentity     string `mapstructure:"age_identity"`
+	AgeIdentityFile string `mapstructure:"age_identity_file"`
+
+	// 远程配置中心 (Consul / etcd / Nacos)，集中管理多个实例
+	Remote RemoteConfig `mapstructure:"remote_config"`
+
+	// 访问日志
+	AccessLog AccessLogConfig `mapstructure:"access_log"`
+}
+
+// RemoteConfig 为远程配置中心配置，provider 为空时不启用。
+// 远程内容为 YAML，覆盖配置文件中的同名项，环境变量与命令行参数仍优先；
+// 本段只能在配置文件 / 环境变量 / 命令行中设置，远程内容中的 remote_config 会被忽略
+type RemoteConfig struct {
+	Provider     string `mapstructure:"provider"`      // consul / etcd / nacos
+	Endpoint     string `mapstructure:"endpoint"`      // 如 http://127.0.0.1:8500 (etcd 为 v3 HTTP 网关地址)
+	Key          string `mapstructure:"key"`           // Consul / etcd 的 key，Nacos 的 dataId
+	Group        string `mapstructure:"group"`         // Nacos group，默认 DEFAULT_GROUP
+	Namespace    string `mapstructure:"namespace"`     // Nacos 命名空间 ID
+	Token        string `mapstructure:"token"`         // Consul ACL Token
+	TokenFile    string `mapstructure:"token_file"`    // 从文件读取 token
+	Username     string `mapstructure:"username"`      // etcd / Nacos 认证
+	Password     string `mapstructure:"password"`
+	PasswordFile string `mapstructure:"password_file"` // 从文件读取 password
+	Watch        bool   `mapstructure:"watch"`         // 远程配置变化时自动热加载
+}
+
+// Enabled 是否启用远程配置
+func (r RemoteConfig) Enabled() bool {
+	return r.Provider != ""
+}
+
+// Options 转换为配置中心客户端参数
+func (r RemoteConfig) Options() remoteconfig.Options {
+	return remoteconfig.Options{
+		Provider:  r.Provider,
+		Endpoint:  r.Endpoint,
+		Key:       r.Key,
+		Group:     r.Group,
+		Namespace: r.Namespace,
+		Token:     r.Token,
+		Username:  r.Username,
+		Password:  r.Password,
+	}
+}
+
+// AccessLogConfig 为 HTTP 访问日志配置
+type AccessLogConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	File       string `mapstructure:"file"`        // 留空输出到标准输出
+	Format     string `mapstructure:"format"`      // text / json
+	MaxSizeMB  int    `mapstructure:"max_size_mb"` // 单个文件大小上限，0 为不滚动
+	MaxBackups int    `mapstructure:"max_backups"` // 保留的历史文件数
 }
 
 // ProviderConfig 为数据提供方配置
 type ProviderConfig struct {
-	Name      string `mapstructure:"name"`
-	SecretID  string `mapstructure:"secret_id"`
-	SecretKey string `mapstructure:"secret_key"`
+	Name          string `mapstructure:"name"`
+	SecretID      string `mapstructure:"secret_id"`
+	SecretKey     string `mapstructure:"secret_key"`
+	SecretIDFile  string `mapstructure:"secret_id_file"`  // 从文件读取 secret_id (如 Docker secrets)
+	SecretKeyFile string `mapstructure:"secret_key_file"` // 从文件读取 secret_key
+	TimeoutMs     int    `mapstructure:"timeout_ms"`      // 单次请求超时 (毫秒)，0 使用 provider_timeout_ms
+	Role          string `mapstructure:"role"`            // primary / fallback / shadow (仅 providers 列表)，默认 primary
+	Weight        int    `mapstructure:"weight"`          // 同角色提供商之间分配请求的权重 (仅 providers 列表)，默认 1
+}
+
+// 提供商角色
+// 目前查询只使用第一个 primary，其余提供商可通过 ?provider= 指定；fallback 调用链、shadow 对比与按 weight 分流在此基础上实现
+const (
+	RolePrimary  = "primary"
+	RoleFallback = "fallback" // primary 失败时接替
+	RoleShadow   = "shadow"   // 旁路调用，结果仅用于对比
+)
+
+// maxEnqueueRatePerSecond 预热、刷新扫描每秒入队数的上限
+const maxEnqueueRatePerSecond = 10000
+
+// MaxRequeueDelaySeconds 短暂故障重新入队前的最长等待时间，等待期间处理中标记不会刷新
+const MaxRequeueDelaySeconds = 120
+
+// normalizeProviders 将单个 provider 配置段转为 providers 列表，并填充角色与权重的默认值
+// 之后只需读取 Providers (需在 validate 之后调用)
+func (c *Config) normalizeProviders() {
+	if len(c.Providers) == 0 {
+		c.Providers = []ProviderConfig{c.Provider}
+		c.Provider = ProviderConfig{}
+	}
+	for i := range c.Providers {
+		if c.Providers[i].Role == "" {
+			c.Providers[i].Role = RolePrimary
+		}
+		if c.Providers[i].Weight == 0 {
+			c.Providers[i].Weight = 1
+		}
+	}
+}
+
+// Primary 返回第一个 primary 提供商 (当前查询使用的提供商)
+func (c *Config) Primary() ProviderConfig {
+	for _, p := range c.Providers {
+		if p.Role == RolePrimary {
+			return p
+		}
+	}
+	return ProviderConfig{}
+}
+
+// AdaptiveConcurrencyConfig 按上游耗时与错误率自动调整实际并发 (AIMD)
+type AdaptiveConcurrencyConfig struct {
+	Enabled   bool    `mapstructure:"enabled"`
+	MinLimit  int     `mapstructure:"min_limit"`  // 并发下限
+	LatencyMs int     `mapstructure:"latency_ms"` // 窗口平均耗时超过该值时减半
+	ErrorRate float64 `mapstructure:"error_rate"` // 窗口错误率超过该值时减半 (0~1)
 }
 
 type QuotaConfig struct {
 	SecretID   string `mapstructure:"secret_id"`   // 腾讯云官方 AKID
 	SecretKey  string `mapstructure:"secret_key"`  // 腾讯云官方 Key
 	InstanceID string `mapstructure:"instance_id"` // 资源包 ID
+
+	SecretIDFile  string `mapstructure:"secret_id_file"`  // 从文件读取 secret_id
+	SecretKeyFile string `mapstructure:"secret_key_file"` // 从文件读取 secret_key
+
+	RefreshIntervalSeconds int   `mapstructure:"refresh_interval_seconds"` // 定期查询剩余配额 (/status 展示)，耗尽时暂停上游请求 (0 关闭)
+	RefreshTimeoutSeconds  int   `mapstructure:"refresh_timeout_seconds"`  // 单次查询的超时，超时保留上次的值
+	ProbeIntervalSeconds   int   `mapstructure:"probe_interval_seconds"`   // 暂停期间检查配额是否恢复的间隔
+	WarningThreshold       int64 `mapstructure:"warning_threshold"`        // 剩余配额低于该值时 /status 的 quota_warning 为 true 并告警 (0 关闭)
+}
+
+// HealthConfig 为 /readyz 的就绪判定条件，均为 0 时只检查启动完成与是否在关闭
+type HealthConfig struct {
+	MaxConsecutiveErrors   int `mapstructure:"max_consecutive_errors"`   // 上游连续失败达到该次数视为未就绪 (0 不检查)
+	QueueSaturationPercent int `mapstructure:"queue_saturation_percent"` // 首次查询队列使用率达到该比例视为未就绪 (0 不检查)
+	MaxPersistenceDropsPerMinute float64 `mapstructure:"max_persistence_drops_per_minute"` // 持久化丢弃速率超过该值视为未就绪 (0 不检查)
+}
+
+// AlertConfig 为告警 Webhook 配置，未配置 webhooks 时不启用
+type AlertConfig struct {
+	Webhooks             []AlertWebhookConfig `mapstructure:"webhooks"`
+	CheckIntervalSeconds int                  `mapstructure:"check_interval_seconds"` // 检查间隔
+	CooldownSeconds      int                  `mapstructure:"cooldown_seconds"`       // 同一类告警的最小推送间隔
+	ConsecutiveErrors    int                  `mapstructure:"consecutive_errors"`     // 上游连续失败达到该次数时告警 (0 关闭)
+	QuotaExhausted       bool                 `mapstructure:"quota_exhausted"`        // 配额耗尽、上游请求暂停时告警
+	QuotaLow             bool                 `mapstructure:"quota_low"`              // 剩余配额低于 quota.warning_threshold 时告警
+	PersistenceDrops     bool                 `mapstructure:"persistence_drops"`      // 持久化丢弃速率超过阈值时告警
+	PersistenceDropsPerMinute float64         `mapstructure:"persistence_drops_per_minute"` // 丢弃速率阈值 (条/分钟)，0 为出现丢弃即告警
+	QueueSaturationPercent int                `mapstructure:"queue_saturation_percent"` // 首次查询队列使用率 (%) 达到该比例时告警 (0 关闭)
+}
+
+// StatsDConfig 为 StatsD 指标推送配置，addr 为空时不启用
+type StatsDConfig struct {
+	Addr            string `mapstructure:"addr"`             // statsd / Datadog Agent 地址，如 127.0.0.1:8125
+	IntervalSeconds int    `mapstructure:"interval_seconds"` // 推送间隔
+	Prefix          string `mapstructure:"prefix"`           // 指标名前缀
+	Tags            bool   `mapstructure:"tags"`             // 使用 DogStatsD 标签，否则将标签值拼接进指标名
+}
+
+// PromPushConfig 为 Prometheus 推送配置，mode 为空时不启用
+type PromPushConfig struct {
+	Mode            string            `mapstructure:"mode"`     // pushgateway / remote_write
+	URL             string            `mapstructure:"url"`      // Pushgateway 根地址或 remote-write 接收地址
+	IntervalSeconds int               `mapstructure:"interval_seconds"`
+	Job             string            `mapstructure:"job"`
+	Instance        string            `mapstructure:"instance"` // 默认主机名
+	Labels          map[string]string `mapstructure:"labels"`   // 附加到所有指标的标签
+	BearerToken     string            `mapstructure:"bearer_token"`
+	Username        string            `mapstructure:"username"` // Basic 认证
+	Password        string            `mapstructure:"password"`
+	BearerTokenFile string            `mapstructure:"bearer_token_file"` // 从文件读取 bearer_token
+	PasswordFile    string            `mapstructure:"password_file"`     // 从文件读取 password
+}
+
+// SlowCallConfig 上游慢调用记录，threshold_ms 为 0 时不启用
+type SlowCallConfig struct {
+	ThresholdMs int  `mapstructure:"threshold_ms"` // 上游调用耗时超过该值时记录完整请求参数
+	CaptureBody bool `mapstructure:"capture_body"` // 同时保存响应体 (最多 16KB)
+	MaxEntries  int  `mapstructure:"max_entries"`  // 保留的记录条数，写满后覆盖最早的
+}
+
+// SLOConfig 业务端口的服务等级目标 (百分比)，目标为 0 时不计算
+type SLOConfig struct {
+	AvailabilityTarget float64 `mapstructure:"availability_target"`  // 非 5xx 响应占比，如 99.9
+	LatencyTarget      float64 `mapstructure:"latency_target"`       // 耗时不超过 latency_threshold_ms 的请求占比，如 99
+	LatencyThresholdMs int     `mapstructure:"latency_threshold_ms"`
+}
+
+// ErrorReportConfig 为 panic 与提供商持续失败的错误上报配置，sentry_dsn 与 bugsnag_api_key 均为空时不启用
+type ErrorReportConfig struct {
+	SentryDSN        string `mapstructure:"sentry_dsn"`        // 如 https://<key>@o0.ingest.sentry.io/<project>
+	BugsnagAPIKey    string `mapstructure:"bugsnag_api_key"`
+	BugsnagEndpoint  string `mapstructure:"bugsnag_endpoint"`  // 自建 Bugsnag 时修改
+	Environment      string `mapstructure:"environment"`       // Sentry environment / Bugsnag releaseStage
+	ProviderFailures int    `mapstructure:"provider_failures"` // 同一提供商连续失败达到该次数时上报一次，0 为只上报 panic
+
+	SentryDSNFile     string `mapstructure:"sentry_dsn_file"`      // 从文件读取 sentry_dsn
+	BugsnagAPIKeyFile string `mapstructure:"bugsnag_api_key_file"` // 从文件读取 bugsnag_api_key
+}
+
+// Enabled 是否配置了任一上报目的地
+func (c ErrorReportConfig) Enabled() bool {
+	return c.SentryDSN != "" || c.BugsnagAPIKey != ""
+}
+
+// AlertWebhookConfig 单个告警接收地址
+type AlertWebhookConfig struct {
+	URL  string `mapstructure:"url"`
+	Type string `mapstructure:"type"` // json / dingtalk / wecom / slack
+}
+
+// FallbackConfig 为 fallback / 未命中时的响应定制
+type FallbackConfig struct {
+	Fallback ResponseOverride `mapstructure:"fallback"` // 解析结果为 fallback
+	Pending  ResponseOverride `mapstructure:"pending"`  // 未命中，已排队或正在解析
+}
+
+// ResponseOverride 覆盖响应的状态码、Tag 与纯文本 body，零值保持默认行为
+type ResponseOverride struct {
+	Status int    `mapstructure:"status"`
+	Tag    string `mapstructure:"tag"`
+	Body   string `mapstructure:"body"` // 纯文本响应的 body (默认为 Tag)，JSON 响应不受影响
+}
+
+// ACLConfig 为来源 IP 访问控制，deny 优先；allow 为空表示允许所有未被拒绝的地址
+type ACLConfig struct {
+	Allow []string `mapstructure:"allow"`
+	Deny  []string `mapstructure:"deny"`
+}
+
+// AdminConfig 为管理接口配置，独立监听并使用 Bearer Token 鉴权
+type AdminConfig struct {
+	Addr      string    `mapstructure:"addr"` // 留空不启用，支持 unix://
+	Token     string    `mapstructure:"token"`
+	TokenFile string    `mapstructure:"token_file"` // 从文件读取 token
+	TLS       TLSConfig `mapstructure:"tls"`
+}
+
+// TLSConfig 为 HTTPS 配置，证书留空则使用明文 HTTP
+type TLSConfig struct {
+	CertFile     string `mapstructure:"cert_file"`
+	KeyFile      string `mapstructure:"key_file"`
+	ClientCAFile string `mapstructure:"client_ca_file"` // 配置后校验客户端证书 (mTLS)
+	ClientAuth   string `mapstructure:"client_auth"`    // require / optional
+
+	// ACME 自动签发 (配置后忽略 cert_file / key_file)
+	ACMEDomains  []string `mapstructure:"acme_domains"`
+	ACMEEmail    string   `mapstructure:"acme_email"`
+	ACMECacheDir string   `mapstructure:"acme_cache_dir"`
+}
+
+// BackupConfig 为缓存定时备份配置 (S3 兼容存储)
+type BackupConfig struct {
+	Enabled         bool   `mapstructure:"enabled"`
+	Endpoint        string `mapstructure:"endpoint"`
+	Region          string `mapstructure:"region"`
+	Bucket          string `mapstructure:"bucket"`
+	AccessKey       string `mapstructure:"access_key"`
+	SecretKey       string `mapstructure:"secret_key"`
+	AccessKeyFile   string `mapstructure:"access_key_file"`  // 从文件读取 access_key
+	SecretKeyFile   string `mapstructure:"secret_key_file"`  // 从文件读取 secret_key
+	Prefix          string `mapstructure:"prefix"`           // 对象 Key 前缀
+	IntervalMinutes int    `mapstructure:"interval_minutes"` // 备份间隔 (分钟)
+	Retention       int    `mapstructure:"retention"`        // 保留份数
 }
 
 // SetDefaults 设置所有配置默认值
 func SetDefaults() {
 	viper.SetDefault("log_level", "info")
+	viper.SetDefault("log_format", "text")
+	viper.SetDefault("config_watch", false)
+	viper.SetDefault("remote_config.watch", false)
+	viper.SetDefault("access_log.format", "text")
+	viper.SetDefault("access_log.max_size_mb", 100)
+	viper.SetDefault("access_log.max_backups", 5)
 
 	// Server
 	viper.SetDefault("listen_addr", "127.0.0.1:8080")
 	viper.SetDefault("monitor_addr", "127.0.0.1:9090")
+	viper.SetDefault("dns_zone", "ip.resolver.local")
+	viper.SetDefault("compression", true)
+	viper.SetDefault("http2", true)
+	viper.SetDefault("h2c", false)
+	viper.SetDefault("api_tls.acme_cache_dir", "./.acme")
+	viper.SetDefault("monitor_tls.acme_cache_dir", "./.acme")
 	viper.SetDefault("worker_concurrency", 8)
+	viper.SetDefault("worker_min_concurrency", 1)
+	viper.SetDefault("worker_max_concurrency", 0)
+	viper.SetDefault("queue_size", 4096)
+	viper.SetDefault("queue_shards", 1)
+	viper.SetDefault("worker_batch_size", 1)
+	viper.SetDefault("provider_timeout_ms", 3000)
+	viper.SetDefault("provider_rate_limit", 0)
+	viper.SetDefault("adaptive_concurrency.enabled", false)
+	viper.SetDefault("adaptive_concurrency.min_limit", 1)
+	viper.SetDefault("adaptive_concurrency.latency_ms", 1000)
+	viper.SetDefault("adaptive_concurrency.error_rate", 0.2)
+	viper.SetDefault("refresh_shed_queue_depth", 0)
+	viper.SetDefault("refresh_shed_inflight", 0)
+	viper.SetDefault("fetch_max_retries", 2)
+	viper.SetDefault("fetch_retry_backoff_ms", 1000)
+	viper.SetDefault("fetch_transient_max_requeues", 10)
+	viper.SetDefault("lookup_max_wait_ms", 5000)
+	viper.SetDefault("lookup_callback_enabled", false)
+	viper.SetDefault("lookup_callback_timeout_ms", 60000)
+	viper.SetDefault("lookup_callback_allowed_hosts", []string{})
+	viper.SetDefault("lookup_callback_allow_private", false)
+	viper.SetDefault("auth_request_header", "X-Real-IP")
+	viper.SetDefault("lookup_path_prefix", "")
+	viper.SetDefault("legacy_lookup_route", true)
+	viper.SetDefault("preload_rate_per_second", 10)
+	viper.SetDefault("refresh_crawl_interval_seconds", 0)
+	viper.SetDefault("refresh_crawl_rate_per_second", 5)
+	viper.SetDefault("inflight_max_age_seconds", 300)
+	viper.SetDefault("shutdown_drain_timeout_seconds", 10)
+	viper.SetDefault("queue_spill_path", "")
+	viper.SetDefault("queue_spill_max_items", 1000000)
+	viper.SetDefault("ipv6_prefix_len", 0)
 
 	// Cache
 	viper.SetDefault("cache_ttl_seconds", int64(30*24*60*60)) // 30 天
 	viper.SetDefault("cache_refresh_ratio", 10)
+	viper.SetDefault("cache_refresh_jitter_percent", 50)
+	viper.SetDefault("hit_ratio_windows", []string{"1m", "5m", "1h"})
+	viper.SetDefault("error_history_size", 100)
+	viper.SetDefault("counter_persist_interval_seconds", 60)
+	viper.SetDefault("quota.probe_interval_seconds", 300)
+	viper.SetDefault("quota.refresh_interval_seconds", 60)
+	viper.SetDefault("quota.refresh_timeout_seconds", 5)
 	viper.SetDefault("cache_store_path", "./.cache.db")
+	viper.SetDefault("cache_eviction_policy", "random")
+
+	// Backup
+	viper.SetDefault("backup.enabled", false)
+	viper.SetDefault("backup.region", "us-east-1")
+	viper.SetDefault("backup.prefix", "ip-resolver/")
+	viper.SetDefault("backup.interval_minutes", 24*60) // 每天一次
+	viper.SetDefault("backup.retention", 7)
+	viper.SetDefault("health.max_consecutive_errors", 0)
+	viper.SetDefault("health.queue_saturation_percent", 90)
+	viper.SetDefault("health.max_persistence_drops_per_minute", 0)
+	viper.SetDefault("alert.check_interval_seconds", 30)
+	viper.SetDefault("alert.cooldown_seconds", 600)
+	viper.SetDefault("alert.consecutive_errors", 10)
+	viper.SetDefault("alert.quota_exhausted", true)
+	viper.SetDefault("alert.quota_low", true)
+	viper.SetDefault("alert.persistence_drops", true)
+	viper.SetDefault("alert.persistence_drops_per_minute", 0)
+	viper.SetDefault("alert.queue_saturation_percent", 80)
+
+	// StatsD
+	viper.SetDefault("statsd.interval_seconds", 10)
+	viper.SetDefault("statsd.prefix", "ip_resolver.")
+
+	// Prometheus 推送
+	viper.SetDefault("prometheus_push.interval_seconds", 15)
+	viper.SetDefault("prometheus_push.job", "ip-resolver")
+
+	// 慢调用记录
+	viper.SetDefault("slow_call.max_entries", 100)
+
+	// SLO
+	viper.SetDefault("slo.latency_threshold_ms", 300)
+
+	// 错误上报
+	viper.SetDefault("error_report.bugsnag_endpoint", "https://notify.bugsnag.com")
+	viper.SetDefault("error_report.environment", "production")
+	viper.SetDefault("error_report.provider_failures", 5)
+}
+
+// ProviderTimeout 返回提供商的请求超时，未单独配置时使用 provider_timeout_ms
+func (c *Config) ProviderTimeout(p ProviderConfig) time.Duration {
+	if p.TimeoutMs > 0 {
+		return time.Duration(p.TimeoutMs) * time.Millisecond
+	}
+	return time.Duration(c.ProviderTimeoutMs) * time.Millisecond
 }
 
-// LoadConfig 加载配置文件并反序列化
-func LoadConfig(path string) (*Config, error) {
+// Sources 一次加载实际使用的配置来源
+type Sources struct {
+	File   string   // 主配置文件，为空表示仅使用默认值与环境变量
+	Files  []string // 合并的全部配置文件 (含 include 与 profile 覆盖文件)，按合并顺序排列
+	Remote string   // 远程配置 (provider 与 key)，未使用时为空
+}
+
+// LoadConfig 加载配置文件并反序列化，环境变量 (IPRESOLVER_*) 优先于配置文件
+// 配置文件不存在时仅使用默认值与环境变量，便于容器部署；
+// ctx 用于中止远程配置读取 (热加载时随进程退出取消)；成功时同时返回本次使用的配置来源
+func LoadConfig(ctx context.Context, path string) (*Config, Sources, error) {
 	SetDefaults()
+	bindEnv()
 
 	if path != "" {
 		viper.SetConfigFile(path)
@@ -69,14 +549,229 @@ func LoadConfig(path string) (*Config, error) {
 		viper.AddConfigPath(".")
 	}
 
+	var src Sources
 	if err := viper.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("读取配置失败: %w", err)
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) && !errors.Is(err, fs.ErrNotExist) {
+			return nil, Sources{}, fmt.Errorf("读取配置失败: %w", err)
+		}
+		if p := viper.GetString("profile"); p != "" {
+			return nil, Sources{}, fmt.Errorf("指定了 profile %q 但未找到配置文件", p)
+		}
+	} else {
+		src.File = viper.ConfigFileUsed()
+		layers, err := mergeLayers(src.File)
+		if err != nil {
+			return nil, Sources{}, err
+		}
+		src.Files = layers
+	}
+
+	remote, err := mergeRemote(ctx)
+	if err != nil {
+		return nil, Sources{}, err
 	}
+	src.Remote = remote
 
 	var cfg Config
 	if err := viper.Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("解析配置失败: %w", err)
+		return nil, Sources{}, fmt.Errorf("解析配置失败: %w", err)
+	}
+
+	if err := cfg.readSecretFiles(); err != nil {
+		return nil, Sources{}, err
+	}
+	if err := cfg.decryptValues(); err != nil {
+		return nil, Sources{}, err
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, Sources{}, err
 	}
+	cfg.normalizeProviders()
+
+	return &cfg, src, nil
+}
 
-	return &cfg, nil
+// validate 检查配置取值与相互关联的配置项，一次列出全部问题
+func (c *Config) validate() error {
+	var p problems
+
+	c.validateAddrs(&p)
+	c.validateProviders(&p)
+	c.validateRemote(&p)
+	if c.CacheTTLSeconds <= 0 {
+		p.add("cache_ttl_seconds 必须大于 0: %d", c.CacheTTLSeconds)
+	}
+	if c.CacheRefreshRatio < 0 || c.CacheRefreshRatio > 99 {
+		p.add("cache_refresh_ratio 需在 [0, 99] 之间: %d", c.CacheRefreshRatio)
+	}
+	if c.Backup.Enabled {
+		if c.Backup.Bucket == "" || c.Backup.AccessKey == "" || c.Backup.SecretKey == "" {
+			p.add("backup.enabled 时 backup.bucket、access_key 与 secret_key 不能为空")
+		}
+		if c.Backup.IntervalMinutes <= 0 {
+			p.add("backup.interval_minutes 必须大于 0: %d", c.Backup.IntervalMinutes)
+		}
+	}
+	if c.LookupCallbackEnabled && len(c.LookupCallbackAllowedHosts) == 0 {
+		p.add("lookup_callback_enabled 时 lookup_callback_allowed_hosts 不能为空")
+	}
+	if c.WorkerConcurrency <= 0 {
+		p.add("worker_concurrency 必须大于 0: %d", c.WorkerConcurrency)
+	}
+	if c.WorkerMaxConcurrency > 0 {
+		if c.WorkerMinConcurrency <= 0 || c.WorkerMinConcurrency > c.WorkerMaxConcurrency {
+			p.add("worker_min_concurrency (%d) 必须在 1 与 worker_max_concurrency (%d) 之间", c.WorkerMinConcurrency, c.WorkerMaxConcurrency)
+		}
+		if c.QueueSize < c.WorkerMaxConcurrency {
+			p.add("queue_size (%d) 不能小于 worker_max_concurrency (%d)", c.QueueSize, c.WorkerMaxConcurrency)
+		}
+	}
+	if c.ProviderTimeoutMs <= 0 {
+		p.add("provider_timeout_ms 必须大于 0: %d", c.ProviderTimeoutMs)
+	}
+	if c.ProviderRateLimit < 0 {
+		p.add("provider_rate_limit 不能为负数: %v", c.ProviderRateLimit)
+	}
+	if ac := c.AdaptiveConcurrency; ac.Enabled {
+		if ac.MinLimit <= 0 || ac.LatencyMs <= 0 {
+			p.add("adaptive_concurrency.min_limit 与 latency_ms 必须大于 0")
+		}
+		if ac.ErrorRate <= 0 || ac.ErrorRate > 1 {
+			p.add("adaptive_concurrency.error_rate 需在 (0, 1] 之间: %v", ac.ErrorRate)
+		}
+	}
+	if c.Quota.RefreshIntervalSeconds > 0 {
+		if c.Quota.ProbeIntervalSeconds <= 0 {
+			p.add("quota.probe_interval_seconds 必须大于 0: %d", c.Quota.ProbeIntervalSeconds)
+		}
+		if c.Quota.RefreshTimeoutSeconds <= 0 {
+			p.add("quota.refresh_timeout_seconds 必须大于 0: %d", c.Quota.RefreshTimeoutSeconds)
+		}
+	}
+	if c.CacheRefreshJitterPercent < 0 || c.CacheRefreshJitterPercent > 100 {
+		p.add("cache_refresh_jitter_percent 需在 [0, 100] 之间: %d", c.CacheRefreshJitterPercent)
+	}
+	for i, h := range c.Alert.Webhooks {
+		if h.URL == "" {
+			p.add("alert.webhooks[%d] 的 url 不能为空", i)
+		}
+		switch h.Type {
+		case "", "json", "dingtalk", "wecom", "slack":
+		default:
+			p.add("alert.webhooks[%d] 的 type 仅支持 json / dingtalk / wecom / slack: %q", i, h.Type)
+		}
+	}
+	if c.StatsD.Addr != "" && c.StatsD.IntervalSeconds <= 0 {
+		p.add("statsd.interval_seconds 必须大于 0: %d", c.StatsD.IntervalSeconds)
+	}
+	switch c.PromPush.Mode {
+	case "":
+	case "pushgateway", "remote_write":
+		if c.PromPush.URL == "" {
+			p.add("prometheus_push.url 不能为空")
+		}
+		if c.PromPush.IntervalSeconds <= 0 {
+			p.add("prometheus_push.interval_seconds 必须大于 0: %d", c.PromPush.IntervalSeconds)
+		}
+		if c.PromPush.Job == "" {
+			p.add("prometheus_push.job 不能为空")
+		}
+	default:
+		p.add("prometheus_push.mode 仅支持 pushgateway / remote_write: %q", c.PromPush.Mode)
+	}
+	if c.SlowCall.ThresholdMs < 0 {
+		p.add("slow_call.threshold_ms 不能为负数: %d", c.SlowCall.ThresholdMs)
+	}
+	if c.SlowCall.ThresholdMs > 0 && (c.SlowCall.MaxEntries <= 0 || c.SlowCall.MaxEntries > 10000) {
+		p.add("slow_call.max_entries 需在 [1, 10000] 之间: %d", c.SlowCall.MaxEntries)
+	}
+	if v := c.SLO.AvailabilityTarget; v < 0 || v >= 100 {
+		p.add("slo.availability_target 需在 [0, 100) 之间: %v", v)
+	}
+	if v := c.SLO.LatencyTarget; v < 0 || v >= 100 {
+		p.add("slo.latency_target 需在 [0, 100) 之间: %v", v)
+	}
+	if c.SLO.LatencyTarget > 0 && c.SLO.LatencyThresholdMs <= 0 {
+		p.add("slo.latency_threshold_ms 必须大于 0: %d", c.SLO.LatencyThresholdMs)
+	}
+	if dsn := c.ErrorReport.SentryDSN; dsn != "" {
+		if u, err := url.Parse(dsn); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.User == nil || u.Path == "" {
+			p.add("error_report.sentry_dsn 格式应为 https://<key>@<host>/<project>")
+		}
+	}
+	if c.ErrorReport.ProviderFailures < 0 {
+		p.add("error_report.provider_failures 不能为负数: %d", c.ErrorReport.ProviderFailures)
+	}
+	if c.Alert.QueueSaturationPercent < 0 || c.Alert.QueueSaturationPercent > 100 {
+		p.add("alert.queue_saturation_percent 需在 [0, 100] 之间: %d", c.Alert.QueueSaturationPercent)
+	}
+	if len(c.Alert.Webhooks) > 0 && c.Alert.CheckIntervalSeconds <= 0 {
+		p.add("alert.check_interval_seconds 必须大于 0: %d", c.Alert.CheckIntervalSeconds)
+	}
+	if c.Quota.WarningThreshold < 0 {
+		p.add("quota.warning_threshold 不能为负数: %d", c.Quota.WarningThreshold)
+	}
+	if c.Health.MaxConsecutiveErrors < 0 {
+		p.add("health.max_consecutive_errors 不能为负数: %d", c.Health.MaxConsecutiveErrors)
+	}
+	if c.Health.MaxPersistenceDropsPerMinute < 0 {
+		p.add("health.max_persistence_drops_per_minute 不能为负数: %v", c.Health.MaxPersistenceDropsPerMinute)
+	}
+	if c.Alert.PersistenceDropsPerMinute < 0 {
+		p.add("alert.persistence_drops_per_minute 不能为负数: %v", c.Alert.PersistenceDropsPerMinute)
+	}
+	if c.Health.QueueSaturationPercent < 0 || c.Health.QueueSaturationPercent > 100 {
+		p.add("health.queue_saturation_percent 需在 [0, 100] 之间: %d", c.Health.QueueSaturationPercent)
+	}
+	if c.MonitorPprof && c.Admin.Token == "" {
+		p.add("monitor_pprof 需要配置 admin.token")
+	}
+	if c.LogFormat != "text" && c.LogFormat != "json" {
+		p.add("log_format 仅支持 text / json: %q", c.LogFormat)
+	}
+	if c.LogLevel != "debug" && c.LogLevel != "info" {
+		p.add("log_level 仅支持 debug / info: %q", c.LogLevel)
+	}
+	for _, w := range c.HitRatioWindows {
+		d, err := time.ParseDuration(w)
+		if err != nil || d < time.Second || d > 24*time.Hour {
+			p.add("hit_ratio_windows 的窗口需在 [1s, 24h] 之间: %q", w)
+		}
+	}
+	if c.CounterPersistIntervalSeconds < 0 {
+		p.add("counter_persist_interval_seconds 不能为负数: %d", c.CounterPersistIntervalSeconds)
+	}
+	if c.ErrorHistorySize < 0 || c.ErrorHistorySize > 10000 {
+		p.add("error_history_size 需在 [0, 10000] 之间: %d", c.ErrorHistorySize)
+	}
+	if c.QueueShards < 1 || c.QueueShards > c.QueueSize {
+		p.add("queue_shards 需在 [1, queue_size] 之间: %d", c.QueueShards)
+	}
+	if c.QueueSpillMaxItems < 0 {
+		p.add("queue_spill_max_items 不能为负数: %d", c.QueueSpillMaxItems)
+	}
+	if c.FetchTransientMaxRequeues < 0 {
+		p.add("fetch_transient_max_requeues 不能为负数: %d", c.FetchTransientMaxRequeues)
+	}
+	// 速率换算为 time.Second / rate 的定时器间隔，过大时间隔为 0 会导致 panic
+	if c.PreloadRatePerSecond < 0 || c.PreloadRatePerSecond > maxEnqueueRatePerSecond {
+		p.add("preload_rate_per_second 需在 0-%d 之间: %d", maxEnqueueRatePerSecond, c.PreloadRatePerSecond)
+	}
+	if c.RefreshCrawlRatePerSecond < 0 || c.RefreshCrawlRatePerSecond > maxEnqueueRatePerSecond {
+		p.add("refresh_crawl_rate_per_second 需在 0-%d 之间: %d", maxEnqueueRatePerSecond, c.RefreshCrawlRatePerSecond)
+	}
+	if c.InflightMaxAgeSeconds < 0 {
+		p.add("inflight_max_age_seconds 不能为负数: %d", c.InflightMaxAgeSeconds)
+	} else if c.InflightMaxAgeSeconds > 0 && c.InflightMaxAgeSeconds <= MaxRequeueDelaySeconds {
+		p.add("inflight_max_age_seconds 需大于重新入队的最长等待时间 %d 秒 (或为 0 关闭): %d", MaxRequeueDelaySeconds, c.InflightMaxAgeSeconds)
+	}
+	if c.ShutdownDrainTimeoutSeconds < 0 {
+		p.add("shutdown_drain_timeout_seconds 不能为负数: %d", c.ShutdownDrainTimeoutSeconds)
+	}
+	if c.QueueSize < c.WorkerConcurrency {
+		p.add("queue_size (%d) 不能小于 worker_concurrency (%d)", c.QueueSize, c.WorkerConcurrency)
+	}
+	return p.err()
 }
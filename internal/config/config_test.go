@@ -0,0 +1,298 @@
+package config
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// minimalYAML 通过校验所需的最少配置
+const minimalYAML = `
+provider:
+  name: "38599"
+  secret_id: "id"
+  secret_key: "key"
+`
+
+// loadYAML 以干净的 viper 状态加载临时目录下的 config.yaml
+func loadYAML(t *testing.T, content string) (*Config, error) {
+	t.Helper()
+	cfg, _, err := loadFiles(t, map[string]string{"config.yaml": content}, nil)
+	return cfg, err
+}
+
+// loadFiles 将 files (文件名 -> 内容) 写入同一临时目录，按 args 设置命令行参数后加载其中的 config.yaml
+func loadFiles(t *testing.T, files map[string]string, args []string) (*Config, Sources, error) {
+	t.Helper()
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if args != nil {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		BindFlags(fs)
+		if err := fs.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		viper.Reset() // BindFlags 设置的默认值由 LoadConfig 重新设置
+		ApplyFlags(fs)
+	}
+	return LoadConfig(context.Background(), filepath.Join(dir, "config.yaml"))
+}
+
+func TestValidateInflightMaxAge(t *testing.T) {
+	tests := []struct {
+		seconds string
+		ok      bool
+	}{
+		{"0", true},
+		{"121", true},
+		{"300", true},
+		{"120", false}, // 等于重新入队的最长等待时间
+		{"30", false},
+		{"-1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.seconds, func(t *testing.T) {
+			_, err := loadYAML(t, minimalYAML+"inflight_max_age_seconds: "+tt.seconds+"\n")
+			if (err == nil) != tt.ok {
+				t.Fatalf("err = %v, want ok = %v", err, tt.ok)
+			}
+			if err != nil && !strings.Contains(err.Error(), "inflight_max_age_seconds") {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateEnqueueRates(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+		ok    bool
+	}{
+		{"preload_rate_per_second", "0", true},
+		{"preload_rate_per_second", "10000", true},
+		{"preload_rate_per_second", "10001", false},
+		{"preload_rate_per_second", "2000000000", false},
+		{"preload_rate_per_second", "-1", false},
+		{"refresh_crawl_rate_per_second", "5", true},
+		{"refresh_crawl_rate_per_second", "10001", false},
+		{"refresh_crawl_rate_per_second", "-5", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			_, err := loadYAML(t, minimalYAML+tt.key+": "+tt.value+"\n")
+			if (err == nil) != tt.ok {
+				t.Fatalf("err = %v, want ok = %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+// 远程配置覆盖配置文件中的同名项，远程内容中的 remote_config 被忽略
+func TestMergeRemote(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/ip-resolver" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("X-Consul-Index", "7")
+		fmt.Fprint(w, "log_level: debug\nremote_config:\n  key: elsewhere\n")
+	}))
+	defer srv.Close()
+
+	remote := minimalYAML + "log_level: warn\ncache_ttl_seconds: 60\nremote_config:\n  provider: consul\n  endpoint: " + srv.URL + "\n  key: ip-resolver\n"
+	cfg, src, err := loadFiles(t, map[string]string{"config.yaml": remote}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LogLevel != "debug" || cfg.CacheTTLSeconds != 60 || cfg.Remote.Key != "ip-resolver" {
+		t.Fatalf("log_level=%q cache_ttl_seconds=%d remote_config.key=%q", cfg.LogLevel, cfg.CacheTTLSeconds, cfg.Remote.Key)
+	}
+	if src.Remote != "consul ip-resolver" || filepath.Base(src.File) != "config.yaml" {
+		t.Fatalf("Sources = %+v", src)
+	}
+
+	// 配置中心不可用时加载失败
+	srv.Close()
+	if _, err := loadYAML(t, remote); err == nil {
+		t.Fatal("load succeeded with remote config unavailable")
+	}
+}
+
+// 环境变量覆盖配置文件，未出现在配置文件中的项 (如只通过环境变量提供的密钥) 同样生效
+func TestEnvOverrides(t *testing.T) {
+	const file = `
+provider:
+  name: "38599"
+  secret_id: "file-id"
+log_level: "info"
+trusted_proxies: ["10.0.0.0/8"]
+`
+	tests := []struct {
+		env   string
+		value string
+		check func(*Config) bool
+	}{
+		{"IPRESOLVER_LOG_LEVEL", "debug", func(c *Config) bool { return c.LogLevel == "debug" }},
+		{"IPRESOLVER_PROVIDER_SECRET_ID", "env-id", func(c *Config) bool { return c.Providers[0].SecretID == "env-id" }},
+		{"IPRESOLVER_HTTP2", "true", func(c *Config) bool { return c.HTTP2 }},
+		{"IPRESOLVER_TRUSTED_PROXIES", "127.0.0.1,192.168.0.0/16", func(c *Config) bool {
+			return len(c.TrustedProxies) == 2 && c.TrustedProxies[1] == "192.168.0.0/16"
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			t.Setenv("IPRESOLVER_PROVIDER_SECRET_KEY", "env-key") // 配置文件中没有
+			t.Setenv(tt.env, tt.value)
+			cfg, err := loadYAML(t, file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(cfg) || cfg.Providers[0].SecretKey != "env-key" {
+				t.Fatalf("%s=%s not applied", tt.env, tt.value)
+			}
+		})
+	}
+}
+
+// 命令行参数 > 环境变量 > 配置文件 > 默认值；未显式指定的参数不覆盖其他来源
+func TestFlagPrecedence(t *testing.T) {
+	file := minimalYAML + "dns_zone: file.local\nworker_concurrency: 8\n"
+	tests := []struct {
+		name        string
+		env         string // IPRESOLVER_DNS_ZONE，空为不设置
+		args        []string
+		zone        string
+		concurrency int
+	}{
+		{"file", "", []string{}, "file.local", 8},
+		{"env over file", "env.local", []string{}, "env.local", 8},
+		{"flag over env", "env.local", []string{"-dns_zone", "flag.local"}, "flag.local", 8},
+		{"flag over file", "", []string{"-worker_concurrency", "16"}, "file.local", 16},
+		{"unrelated flag keeps env", "env.local", []string{"-worker_concurrency", "16"}, "env.local", 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != "" {
+				t.Setenv("IPRESOLVER_DNS_ZONE", tt.env)
+			}
+			cfg, _, err := loadFiles(t, map[string]string{"config.yaml": file}, tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.DNSZone != tt.zone || cfg.WorkerConcurrency != tt.concurrency {
+				t.Fatalf("dns_zone=%q worker_concurrency=%d, want %q %d", cfg.DNSZone, cfg.WorkerConcurrency, tt.zone, tt.concurrency)
+			}
+		})
+	}
+}
+
+func TestFlagValidation(t *testing.T) {
+	// 参数同样经过校验，非法取值加载失败
+	if _, _, err := loadFiles(t, map[string]string{"config.yaml": minimalYAML}, []string{"-preload_rate_per_second", "-1"}); err == nil {
+		t.Fatal("invalid flag value accepted")
+	}
+}
+
+// include 在前、引用它的文件在后，profile 覆盖文件最后；嵌套段逐项合并，列表整体替换
+func TestConfigLayers(t *testing.T) {
+	files := map[string]string{
+		"base.yaml": minimalYAML + `
+dns_zone: base.local
+worker_concurrency: 4
+trusted_proxies: ["10.0.0.0/8", "172.16.0.0/12"]
+admin:
+  token: base-token
+`,
+		"config.yaml": `
+include: ["base.yaml"]
+worker_concurrency: 8
+admin:
+  addr: "127.0.0.1:9091"
+`,
+		"config.prod.yaml": `
+include: ["prod-extra.yaml"]
+dns_zone: prod.local
+`,
+		"prod-extra.yaml": `
+trusted_proxies: ["127.0.0.1"]
+worker_concurrency: 16
+`,
+	}
+	tests := []struct {
+		name        string
+		args        []string
+		zone        string
+		concurrency int
+		proxies     int
+		layers      []string
+	}{
+		{"include", nil, "base.local", 8, 2, []string{"base.yaml", "config.yaml"}},
+		{"profile", []string{"-profile", "prod"}, "prod.local", 16, 1, []string{"base.yaml", "config.yaml", "prod-extra.yaml", "config.prod.yaml"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, src, err := loadFiles(t, files, tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.DNSZone != tt.zone || cfg.WorkerConcurrency != tt.concurrency || len(cfg.TrustedProxies) != tt.proxies {
+				t.Fatalf("dns_zone=%q worker_concurrency=%d trusted_proxies=%v", cfg.DNSZone, cfg.WorkerConcurrency, cfg.TrustedProxies)
+			}
+			if cfg.Admin.Token != "base-token" || cfg.Admin.Addr != "127.0.0.1:9091" {
+				t.Fatalf("nested section not merged: %+v", cfg.Admin)
+			}
+			var got []string
+			for _, f := range src.Files {
+				got = append(got, filepath.Base(f))
+			}
+			if !slices.Equal(got, tt.layers) {
+				t.Fatalf("Files = %v, want %v", got, tt.layers)
+			}
+		})
+	}
+}
+
+func TestConfigLayersErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		args  []string
+		want  string
+	}{
+		{"include cycle", map[string]string{
+			"config.yaml": minimalYAML + "include: [\"a.yaml\"]\n",
+			"a.yaml":      "include: [\"config.yaml\"]\n",
+		}, nil, "循环引用"},
+		{"missing include", map[string]string{
+			"config.yaml": minimalYAML + "include: [\"missing.yaml\"]\n",
+		}, nil, "missing.yaml"},
+		{"missing profile", map[string]string{
+			"config.yaml": minimalYAML,
+		}, []string{"-profile", "staging"}, "staging"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := loadFiles(t, tt.files, tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
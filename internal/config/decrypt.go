@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"ip-resolver/internal/agecrypt"
+	"reflect"
+	"strings"
+)
+
+// EncPrefix 加密配置值的前缀，其后为 age 密文 (ASCII armor 或 base64 编码的二进制)
+const EncPrefix = "enc:"
+
+// decryptValues 解密配置中全部 enc: 前缀的字符串 (需在 readSecretFiles 之后调用，*_file 的内容同样可以加密)
+func (c *Config) decryptValues() error {
+	return decryptAll(c, "", c.AgeIdentity)
+}
+
+// decryptAll 解密 ptr 指向的结构体中全部 enc: 前缀的字符串，prefix 为该结构体的配置路径 (用于错误信息)，
+// identity 为 age 私钥 (可含多行与注释)，仅在遇到加密值时才解析
+func decryptAll(ptr any, prefix, identity string) error {
+	var ids []agecrypt.Identity
+	decrypt := func(key, value string) (string, error) {
+		if ids == nil {
+			if identity == "" {
+				return "", fmt.Errorf("%s 为加密值，但未配置 age_identity 或 age_identity_file", key)
+			}
+			var err error
+			if ids, err = agecrypt.ParseIdentities(identity); err != nil {
+				return "", fmt.Errorf("解析 age_identity 失败: %w", err)
+			}
+		}
+		data, err := agecrypt.Unarmor(strings.TrimPrefix(value, EncPrefix))
+		if err != nil {
+			return "", fmt.Errorf("%s 解密失败: %w", key, err)
+		}
+		plain, err := agecrypt.Decrypt(data, ids)
+		if err != nil {
+			return "", fmt.Errorf("%s 解密失败: %w", key, err)
+		}
+		return string(plain), nil
+	}
+	return walkStrings(reflect.ValueOf(ptr).Elem(), prefix, func(key string, s string) (string, bool, error) {
+		if !strings.HasPrefix(s, EncPrefix) {
+			return s, false, nil
+		}
+		plain, err := decrypt(key, s)
+		return plain, true, err
+	})
+}
+
+// walkStrings 遍历结构体 / 列表 / map 中的字符串，fn 返回 changed 时写回新值；key 为配置路径 (如 providers[1].secret_key)
+func walkStrings(v reflect.Value, key string, fn func(key, s string) (string, bool, error)) error {
+	join := func(name string) string {
+		if key == "" {
+			return name
+		}
+		return key + "." + name
+	}
+	switch v.Kind() {
+	case reflect.String:
+		s, changed, err := fn(key, v.String())
+		if err != nil {
+			return err
+		}
+		if changed {
+			v.SetString(s)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			tag := strings.Split(t.Field(i).Tag.Get("mapstructure"), ",")[0]
+			if tag == "" || !t.Field(i).IsExported() {
+				continue
+			}
+			if err := walkStrings(v.Field(i), join(tag), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := walkStrings(v.Index(i), fmt.Sprintf("%s[%d]", key, i), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			s, changed, err := fn(join(fmt.Sprint(iter.Key())), iter.Value().String())
+			if err != nil {
+				return err
+			}
+			if changed {
+				v.SetMapIndex(iter.Key(), reflect.ValueOf(s).Convert(v.Type().Elem()))
+			}
+		}
+	}
+	return nil
+}
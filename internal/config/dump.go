@@ -0,0 +1,117 @@
+package config
+
+import (
+	"bytes"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// redactedValue 输出生效配置时替换密钥的占位符
+const redactedValue = "******"
+
+// Redacted 返回隐藏密钥后的配置副本，URL 中的密码与查询参数 (如钉钉 access_token) 一并隐藏；
+// Webhook 地址的路径同样可能携带密钥 (如 Slack /services/T…/B…/<secret>)，只保留协议与主机
+func (c *Config) Redacted() *Config {
+	r := *c
+	r.Providers = slices.Clone(c.Providers)
+	for _, s := range r.secretFields() {
+		if *s.dst != "" {
+			*s.dst = redactedValue
+		}
+	}
+	r.Alert.Webhooks = slices.Clone(c.Alert.Webhooks)
+	for i := range r.Alert.Webhooks {
+		r.Alert.Webhooks[i].URL = redactURL(r.Alert.Webhooks[i].URL, true)
+	}
+	r.ChangeWebhookURL = redactURL(r.ChangeWebhookURL, true)
+	r.PromPush.URL = redactURL(r.PromPush.URL, false)
+	return &r
+}
+
+// redactURL 隐藏 URL 中的密码与查询参数值，maskPath 时路径整体替换为占位符
+func redactURL(raw string, maskPath bool) string {
+	if raw == "" {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redactedValue
+	}
+	if maskPath && u.Path != "" && u.Path != "/" {
+		u.Path = "/" + redactedValue
+		u.RawPath = u.Path // 保留占位符中的 * 不被转义
+	}
+	u.Fragment, u.RawFragment = "", ""
+	// url.UserPassword 会转义占位符中的 *，去掉密码后再补回
+	_, hasPassword := u.User.Password()
+	if hasPassword {
+		u.User = url.User(u.User.Username())
+	}
+	if u.RawQuery != "" {
+		var keys []string
+		for k := range u.Query() {
+			keys = append(keys, url.QueryEscape(k)+"="+redactedValue)
+		}
+		slices.Sort(keys)
+		u.RawQuery = strings.Join(keys, "&")
+	}
+	out := u.String()
+	if hasPassword {
+		user := u.User.String()
+		out = strings.Replace(out, "//"+user+"@", "//"+user+":"+redactedValue+"@", 1)
+	}
+	return out
+}
+
+// DumpYAML 以配置文件的格式 (key 与字段顺序同 config.yaml) 输出隐藏密钥后的配置
+func (c *Config) DumpYAML() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(toNode(reflect.ValueOf(*c.Redacted()))); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RedactedMap 隐藏密钥后的配置，key 同配置文件 (用于 JSON 输出)
+func (c *Config) RedactedMap() (map[string]any, error) {
+	var m map[string]any
+	err := toNode(reflect.ValueOf(*c.Redacted())).Decode(&m)
+	return m, err
+}
+
+// toNode 按 mapstructure 名称将配置转为 YAML 节点，保留结构体字段顺序
+func toNode(v reflect.Value) *yaml.Node {
+	switch v.Kind() {
+	case reflect.Struct:
+		n := &yaml.Node{Kind: yaml.MappingNode}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("mapstructure"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, toNode(v.Field(i)))
+		}
+		return n
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Struct {
+			n := &yaml.Node{Kind: yaml.SequenceNode}
+			for i := 0; i < v.Len(); i++ {
+				n.Content = append(n.Content, toNode(v.Index(i)))
+			}
+			return n
+		}
+	}
+	var n yaml.Node
+	_ = n.Encode(v.Interface())
+	return &n
+}
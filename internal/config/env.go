@@ -0,0 +1,57 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix 环境变量前缀，配置项 provider.secret_key 对应 IPRESOLVER_PROVIDER_SECRET_KEY
+const EnvPrefix = "IPRESOLVER"
+
+// bindEnv 启用环境变量覆盖，优先级高于配置文件
+// AutomaticEnv 只对 viper 已知的 key 生效，未设置默认值的项 (如 provider.secret_key) 需逐一绑定，
+// 否则仅通过环境变量配置时不会被 Unmarshal 读取
+func bindEnv() {
+	viper.SetEnvPrefix(EnvPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
+
+	for _, k := range settableKeys(reflect.TypeOf(Config{}), "") {
+		_ = viper.BindEnv(k.name)
+	}
+}
+
+// configKey 可通过环境变量与命令行参数设置的配置项
+type configKey struct {
+	name string
+	kind reflect.Kind
+}
+
+// settableKeys 列出可通过环境变量与命令行参数设置的配置项
+// 列表以逗号分隔 (如 IPRESOLVER_TRUSTED_PROXIES=10.0.0.0/8,127.0.0.1)；结构体列表与 map 只能通过配置文件设置
+func settableKeys(t reflect.Type, prefix string) []configKey {
+	var keys []configKey
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("mapstructure"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		name = prefix + name
+
+		switch kind := f.Type.Kind(); kind {
+		case reflect.Struct:
+			keys = append(keys, settableKeys(f.Type, name+".")...)
+		case reflect.Map:
+		case reflect.Slice:
+			if f.Type.Elem().Kind() != reflect.Struct {
+				keys = append(keys, configKey{name, kind})
+			}
+		default:
+			keys = append(keys, configKey{name, kind})
+		}
+	}
+	return keys
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"flag"
+	"reflect"
+
+	"github.com/spf13/viper"
+)
+
+// BindFlags 为每个可设置的配置项注册同名命令行参数 (如 -worker_concurrency 16、-provider.name 30498)
+// 参数优先级最高: 命令行参数 > 环境变量 > 配置文件 > 默认值；列表以逗号分隔
+func BindFlags(fs *flag.FlagSet) {
+	SetDefaults()
+	for _, k := range settableKeys(reflect.TypeOf(Config{}), "") {
+		usage := "覆盖配置项 " + k.name
+		switch k.kind {
+		case reflect.Bool:
+			fs.Bool(k.name, viper.GetBool(k.name), usage)
+			continue
+		case reflect.Slice:
+			usage += " (`list`，逗号分隔)"
+		case reflect.String:
+		default:
+			// 反引号中的内容作为 -h 中显示的参数类型
+			usage += " (`" + k.kind.String() + "`)"
+		}
+		fs.String(k.name, viper.GetString(k.name), usage)
+	}
+}
+
+// ApplyFlags 将命令行中显式指定的参数写入配置 (需在 Parse 之后、LoadConfig 之前调用)，热加载时同样生效
+func ApplyFlags(fs *flag.FlagSet) {
+	fs.Visit(func(f *flag.Flag) {
+		if isConfigKey(f.Name) {
+			viper.Set(f.Name, f.Value.String())
+		}
+	})
+}
+
+func isConfigKey(name string) bool {
+	for _, k := range settableKeys(reflect.TypeOf(Config{}), "") {
+		if k.name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// includeKey 配置文件中引用其他配置文件的列表，路径相对于所在文件
+const includeKey = "include"
+
+// ProfilePath 返回 profile 覆盖文件路径: 与主配置文件同目录，文件名插入 profile，如 config.yaml + prod -> config.prod.yaml
+func ProfilePath(path, profile string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + profile + ext
+}
+
+// mergeLayers 在主配置文件 (已由 ReadInConfig 读取) 的基础上合并 include 与 profile 覆盖文件。
+// 合并顺序: 被 include 的文件在前、引用它的文件在后，profile 覆盖文件 (及其 include) 最后，后者覆盖前者；
+// 返回按合并顺序排列的全部配置文件
+func mergeLayers(base string) ([]string, error) {
+	layers, err := configLayers(base, nil)
+	if err != nil {
+		return nil, err
+	}
+	if profile := viper.GetString("profile"); profile != "" {
+		overlay := ProfilePath(base, profile)
+		more, err := configLayers(overlay, layers)
+		if err != nil {
+			return nil, fmt.Errorf("读取 profile %q 失败: %w", profile, err)
+		}
+		layers = append(layers, more...)
+	}
+	if len(layers) == 1 {
+		return layers, nil
+	}
+
+	merged := map[string]any{}
+	for _, f := range layers {
+		m, err := readLayer(f)
+		if err != nil {
+			return nil, err
+		}
+		mergeMap(merged, m)
+	}
+	delete(merged, includeKey)
+	// 主配置文件已在配置层中，合并结果覆盖其上 (主文件的取值已按顺序体现在 merged 中)
+	if err := viper.MergeConfigMap(merged); err != nil {
+		return nil, fmt.Errorf("合并配置文件失败: %w", err)
+	}
+	return layers, nil
+}
+
+// configLayers 递归展开 path 的 include，返回按合并顺序排列的文件列表；seen 为已合并的文件，重复引用时跳过
+func configLayers(path string, seen []string) ([]string, error) {
+	var out []string
+	var visit func(path string, stack []string) error
+	visit = func(path string, stack []string) error {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(stack, abs) {
+			return fmt.Errorf("配置文件循环引用: %s", strings.Join(append(stack, abs), " -> "))
+		}
+		if slices.Contains(seen, abs) || slices.Contains(out, abs) {
+			return nil
+		}
+		v := viper.New()
+		v.SetConfigFile(abs)
+		if err := v.ReadInConfig(); err != nil {
+			return fmt.Errorf("读取配置文件 %s 失败: %w", path, err)
+		}
+		for _, inc := range v.GetStringSlice(includeKey) {
+			if !filepath.IsAbs(inc) {
+				inc = filepath.Join(filepath.Dir(abs), inc)
+			}
+			if err := visit(inc, append(stack, abs)); err != nil {
+				return err
+			}
+		}
+		out = append(out, abs)
+		return nil
+	}
+	if err := visit(path, nil); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// readLayer 读取单个配置文件为 map
+func readLayer(path string) (map[string]any, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("读取配置文件 %s 失败: %w", path, err)
+	}
+	return v.AllSettings(), nil
+}
+
+// mergeMap 将 src 深度合并到 dst，嵌套 map 逐项合并，其余类型 (含列表) 整体替换
+func mergeMap(dst, src map[string]any) {
+	for k, sv := range src {
+		if sm, ok := sv.(map[string]any); ok {
+			if dm, ok := dst[k].(map[string]any); ok {
+				mergeMap(dm, sm)
+				continue
+			}
+		}
+		dst[k] = sv
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"ip-resolver/internal/remoteconfig"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+)
+
+// remoteLoadTimeout 单次加载读取远程配置 (含登录/认证) 的总时长上限，
+// 热加载在主循环中同步执行，配置中心无响应时不能长时间阻塞信号处理
+const remoteLoadTimeout = 15 * time.Second
+
+// remoteFromViper 读取 remote_config 段 (逐项读取以包含环境变量与命令行覆盖)
+func remoteFromViper() RemoteConfig {
+	return RemoteConfig{
+		Provider:     viper.GetString("remote_config.provider"),
+		Endpoint:     viper.GetString("remote_config.endpoint"),
+		Key:          viper.GetString("remote_config.key"),
+		Group:        viper.GetString("remote_config.group"),
+		Namespace:    viper.GetString("remote_config.namespace"),
+		Token:        viper.GetString("remote_config.token"),
+		TokenFile:    viper.GetString("remote_config.token_file"),
+		Username:     viper.GetString("remote_config.username"),
+		Password:     viper.GetString("remote_config.password"),
+		PasswordFile: viper.GetString("remote_config.password_file"),
+		Watch:        viper.GetBool("remote_config.watch"),
+	}
+}
+
+// mergeRemote 读取远程配置并合并到配置文件层之上 (需在 ReadInConfig 之后调用)。
+// 每次加载都会重新读取配置文件，远程删除的配置项随之失效；
+// remote_config 本身的取值错误留给 validate 统一报告；返回读取的远程配置 (provider 与 key)，未启用时为空
+func mergeRemote(ctx context.Context) (string, error) {
+	rc := remoteFromViper()
+	if !slices.Contains(remoteconfig.Providers, rc.Provider) || rc.Endpoint == "" || rc.Key == "" {
+		return "", nil
+	}
+	identity := viper.GetString("age_identity")
+	for _, s := range []secretField{
+		{"remote_config.token", &rc.Token, rc.TokenFile},
+		{"remote_config.password", &rc.Password, rc.PasswordFile},
+		{"age_identity", &identity, viper.GetString("age_identity_file")},
+	} {
+		if err := s.read(); err != nil {
+			return "", err
+		}
+	}
+	if err := decryptAll(&rc, "remote_config", identity); err != nil {
+		return "", err
+	}
+
+	client, err := remoteconfig.New(rc.Options())
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, remoteLoadTimeout)
+	defer cancel()
+	data, err := client.Fetch(ctx)
+	if err != nil {
+		return "", fmt.Errorf("读取远程配置失败: %w", err)
+	}
+	var m map[string]any
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return "", fmt.Errorf("解析远程配置失败 (%s %s): %w", rc.Provider, rc.Key, err)
+	}
+	// 连接参数只能在本地指定，避免远程内容把实例指向别处
+	delete(m, "remote_config")
+	if err := viper.MergeConfigMap(m); err != nil {
+		return "", fmt.Errorf("合并远程配置失败: %w", err)
+	}
+	return rc.Provider + " " + rc.Key, nil
+}
+
+// validateRemote 检查远程配置中心参数
+func (c *Config) validateRemote(p *problems) {
+	r := c.Remote
+	if !r.Enabled() {
+		return
+	}
+	if !slices.Contains(remoteconfig.Providers, r.Provider) {
+		p.add("remote_config.provider 不支持 %q，可选: %v", r.Provider, remoteconfig.Providers)
+	}
+	if u, err := url.Parse(r.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		p.add("remote_config.endpoint 需为 http(s) 地址: %q", r.Endpoint)
+	}
+	if r.Key == "" {
+		p.add("remote_config.key 不能为空")
+	}
+	if r.Password != "" && r.Username == "" {
+		p.add("remote_config.password 需配合 remote_config.username 使用")
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// secretField 敏感配置项及其 *_file 变体
+type secretField struct {
+	key  string
+	dst  *string
+	path string
+}
+
+// secretFields 列出全部敏感配置项，读取 *_file 与输出生效配置时隐藏均依赖该列表
+func (c *Config) secretFields() []secretField {
+	fields := []secretField{
+		{"provider.secret_id", &c.Provider.SecretID, c.Provider.SecretIDFile},
+		{"provider.secret_key", &c.Provider.SecretKey, c.Provider.SecretKeyFile},
+		{"ipv6_provider.secret_id", &c.IPv6Provider.SecretID, c.IPv6Provider.SecretIDFile},
+		{"ipv6_provider.secret_key", &c.IPv6Provider.SecretKey, c.IPv6Provider.SecretKeyFile},
+		{"quota.secret_id", &c.Quota.SecretID, c.Quota.SecretIDFile},
+		{"quota.secret_key", &c.Quota.SecretKey, c.Quota.SecretKeyFile},
+		{"backup.access_key", &c.Backup.AccessKey, c.Backup.AccessKeyFile},
+		{"backup.secret_key", &c.Backup.SecretKey, c.Backup.SecretKeyFile},
+		{"admin.token", &c.Admin.Token, c.Admin.TokenFile},
+		{"prometheus_push.bearer_token", &c.PromPush.BearerToken, c.PromPush.BearerTokenFile},
+		{"prometheus_push.password", &c.PromPush.Password, c.PromPush.PasswordFile},
+		{"error_report.sentry_dsn", &c.ErrorReport.SentryDSN, c.ErrorReport.SentryDSNFile},
+		{"error_report.bugsnag_api_key", &c.ErrorReport.BugsnagAPIKey, c.ErrorReport.BugsnagAPIKeyFile},
+		{"remote_config.token", &c.Remote.Token, c.Remote.TokenFile},
+		{"remote_config.password", &c.Remote.Password, c.Remote.PasswordFile},
+		{"age_identity", &c.AgeIdentity, c.AgeIdentityFile},
+	}
+	for i := range c.Providers {
+		pc := &c.Providers[i]
+		key := fmt.Sprintf("providers[%d]", i)
+		fields = append(fields,
+			secretField{key + ".secret_id", &pc.SecretID, pc.SecretIDFile},
+			secretField{key + ".secret_key", &pc.SecretKey, pc.SecretKeyFile},
+		)
+	}
+	return fields
+}
+
+// readSecretFiles 读取 *_file 指定的密钥文件 (Docker secrets / Kubernetes Secret 挂载等)，
+// 密钥无需写入配置文件或环境变量；热加载时重新读取，轮换密钥后发送 SIGHUP 即可生效
+func (c *Config) readSecretFiles() error {
+	for _, s := range c.secretFields() {
+		if err := s.read(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// read 配置了 *_file 时读取文件内容写入 dst
+func (s secretField) read() error {
+	if s.path == "" {
+		return nil
+	}
+	if *s.dst != "" {
+		return fmt.Errorf("%s 与 %s_file 不能同时配置", s.key, s.key)
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("读取 %s_file 失败: %w", s.key, err)
+	}
+	// 文件末尾的换行不属于密钥
+	*s.dst = strings.TrimRight(string(data), "\r\n")
+	if *s.dst == "" {
+		return fmt.Errorf("%s_file 内容为空: %s", s.key, s.path)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"fmt"
+	"ip-resolver/internal/provider"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ValidationError 配置校验发现的全部问题，启动时一次列出，避免逐个修改后反复重启
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("配置校验失败 (%d 项):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// problems 收集校验问题
+type problems []string
+
+func (p *problems) add(format string, args ...any) {
+	*p = append(*p, fmt.Sprintf(format, args...))
+}
+
+func (p problems) err() error {
+	if len(p) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: p}
+}
+
+// validateAddrs 检查监听地址格式: host:port，或 unix:///path (支持 Unix Socket 的监听)
+func (c *Config) validateAddrs(p *problems) {
+	for _, a := range []struct {
+		key      string
+		addr     string
+		required bool
+		unix     bool
+	}{
+		{"listen_addr", c.ListenAddr, true, true},
+		{"monitor_addr", c.MonitorAddr, true, false},
+		{"admin.addr", c.Admin.Addr, false, true},
+		{"grpc_addr", c.GRPCAddr, false, true},
+		{"dns_addr", c.DNSAddr, false, false},
+	} {
+		if a.addr == "" {
+			if a.required {
+				p.add("%s 不能为空", a.key)
+			}
+			continue
+		}
+		if path, ok := strings.CutPrefix(a.addr, "unix://"); ok {
+			if !a.unix {
+				p.add("%s 不支持 Unix Socket: %q", a.key, a.addr)
+			} else if path == "" {
+				p.add("%s 缺少 Unix Socket 路径: %q", a.key, a.addr)
+			}
+			continue
+		}
+		_, port, err := net.SplitHostPort(a.addr)
+		if err != nil {
+			p.add("%s 格式应为 host:port: %q", a.key, a.addr)
+			continue
+		}
+		if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+			p.add("%s 端口无效: %q", a.key, a.addr)
+		}
+	}
+}
+
+// validateProviders 检查所选提供商是否存在及其凭证是否齐全
+func (c *Config) validateProviders(p *problems) {
+	switch {
+	case len(c.Providers) == 0:
+		validateProvider(p, "provider", c.Provider)
+	case c.Provider.Name != "":
+		p.add("provider 与 providers 不能同时配置 (单个提供商可写为 providers 中的一项)")
+	default:
+		primaries := 0
+		seen := make(map[string]bool, len(c.Providers))
+		for i, pc := range c.Providers {
+			key := fmt.Sprintf("providers[%d]", i)
+			validateProvider(p, key, pc)
+			switch pc.Role {
+			case "", RolePrimary:
+				primaries++
+			case RoleFallback, RoleShadow:
+			default:
+				p.add("%s.role 仅支持 primary / fallback / shadow: %q", key, pc.Role)
+			}
+			if pc.Weight < 0 {
+				p.add("%s.weight 不能为负数: %d", key, pc.Weight)
+			}
+			if seen[pc.Name] {
+				p.add("%s.name 重复: %q", key, pc.Name)
+			}
+			seen[pc.Name] = true
+		}
+		if primaries == 0 {
+			p.add("providers 中至少需要一个 role 为 primary 的提供商")
+		}
+	}
+	if c.IPv6PrefixLen < 0 || c.IPv6PrefixLen > 128 {
+		p.add("ipv6_prefix_len 需在 [0, 128] 之间: %d", c.IPv6PrefixLen)
+	}
+	if c.IPv6PrefixLen > 0 && c.IPv6Provider.Name != "" {
+		validateProvider(p, "ipv6_provider", c.IPv6Provider)
+	}
+	if c.Quota.InstanceID != "" {
+		requireSecret(p, "quota.secret_id", c.Quota.SecretID)
+		requireSecret(p, "quota.secret_key", c.Quota.SecretKey)
+	}
+}
+
+func validateProvider(p *problems, key string, pc ProviderConfig) {
+	if names := provider.Names(); !slices.Contains(names, pc.Name) {
+		p.add("%s.name 未知: %q (支持: %s)", key, pc.Name, strings.Join(names, " / "))
+		return
+	}
+	requireSecret(p, key+".secret_id", pc.SecretID)
+	requireSecret(p, key+".secret_key", pc.SecretKey)
+	if pc.TimeoutMs < 0 {
+		p.add("%s.timeout_ms 不能为负数: %d", key, pc.TimeoutMs)
+	}
+}
+
+// requireSecret 密钥可由配置文件、*_file 或环境变量提供，提示中列出全部方式
+func requireSecret(p *problems, key, value string) {
+	if value != "" {
+		return
+	}
+	// 列表项无法通过环境变量设置
+	if strings.Contains(key, "[") {
+		p.add("%s 不能为空 (可通过 %s 或 %s_file 配置)", key, key, key)
+		return
+	}
+	env := EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+	p.add("%s 不能为空 (可通过 %s、%s_file 或环境变量 %s 配置)", key, key, key, env)
+}
@@ -0,0 +1,211 @@
+package dnsserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ip-resolver/internal/logging"
+	"ip-resolver/internal/worker"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+var log = logging.For("DNS")
+
+// ======== 硬编码参数 =========
+const (
+	maxTTL        = 3600 // TXT 记录最大 TTL (秒)
+	queryTimeout  = 2 * time.Second
+	udpWorkers    = 128  // 处理查询的固定 goroutine 数
+	packetBacklog = 1024 // 等待处理的查询上限，超出时丢弃 (客户端会重试)
+)
+
+// Server 极简 DNS (UDP) 服务: 以 TXT 记录返回 IP 的 Tag
+// 查询格式: <ip>.<zone>，如 1.2.3.4.ip.resolver.local；IPv6 地址中的 ':' 以 '-' 代替
+type Server struct {
+	addr string
+	zone string // 规范化为小写并以 '.' 结尾
+	mgr  *worker.Manager
+
+	mu   sync.Mutex
+	conn net.PacketConn
+	wg   sync.WaitGroup
+
+	dropped atomic.Int64 // 因积压过多丢弃的查询数
+}
+
+// packet 待处理的 UDP 查询
+type packet struct {
+	req  []byte
+	addr net.Addr
+}
+
+func New(addr, zone string, mgr *worker.Manager) *Server {
+	zone = strings.ToLower(strings.Trim(zone, "."))
+	return &Server{
+		addr: addr,
+		zone: "." + zone + ".",
+		mgr:  mgr,
+	}
+}
+
+// ListenAndServe 阻塞处理查询，直到 Close 被调用
+func (s *Server) ListenAndServe() error {
+	conn, err := net.ListenPacket("udp", s.addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(conn)
+}
+
+// Serve 在已有的 UDP 连接上处理查询 (用于重启时继承监听)
+func (s *Server) Serve(conn net.PacketConn) error {
+	s.mu.Lock()
+	s.conn = conn
+	s.mu.Unlock()
+
+	// 固定数量的 worker 处理查询，突发流量时丢弃而不是无限创建 goroutine
+	packets := make(chan packet, packetBacklog)
+	s.wg.Add(udpWorkers)
+	for range udpWorkers {
+		go s.worker(conn, packets)
+	}
+	defer close(packets)
+
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		req := make([]byte, n)
+		copy(req, buf[:n])
+
+		select {
+		case packets <- packet{req: req, addr: addr}:
+		default:
+			if n := s.dropped.Add(1); n&(n-1) == 0 { // 第 1、2、4、8... 次丢弃时记录
+				log.Warn("查询积压过多, 丢弃", "addr", addr.String(), "dropped", n)
+			}
+		}
+	}
+}
+
+func (s *Server) worker(conn net.PacketConn, packets <-chan packet) {
+	defer s.wg.Done()
+	for p := range packets {
+		if resp := s.handle(p.req); resp != nil {
+			_, _ = conn.WriteTo(resp, p.addr)
+		}
+	}
+}
+
+func (s *Server) Close() {
+	s.mu.Lock()
+	conn := s.conn
+	s.mu.Unlock()
+
+	if conn != nil {
+		_ = conn.Close()
+	}
+	s.wg.Wait()
+}
+
+func (s *Server) handle(req []byte) []byte {
+	var p dnsmessage.Parser
+	hdr, err := p.Start(req)
+	if err != nil || hdr.Response {
+		return nil
+	}
+	q, err := p.Question()
+	if err != nil {
+		return nil
+	}
+
+	respHdr := dnsmessage.Header{
+		ID:               hdr.ID,
+		Response:         true,
+		Authoritative:    true,
+		RecursionDesired: hdr.RecursionDesired,
+		OpCode:           hdr.OpCode,
+		RCode:            dnsmessage.RCodeSuccess,
+	}
+
+	name := strings.ToLower(q.Name.String())
+	ip, ok := s.parseName(name)
+
+	var tag string
+	var ttl uint32
+	switch {
+	case hdr.OpCode != 0:
+		respHdr.RCode = dnsmessage.RCodeNotImplemented
+	case !strings.HasSuffix(name, s.zone):
+		respHdr.RCode = dnsmessage.RCodeRefused
+	case !ok:
+		respHdr.RCode = dnsmessage.RCodeNameError
+	case q.Type == dnsmessage.TypeTXT || q.Type == dnsmessage.TypeALL:
+		ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+		res, err := s.mgr.Resolve(ctx, ip, false)
+		cancel()
+		if err != nil {
+			respHdr.RCode = dnsmessage.RCodeNameError
+			break
+		}
+		// 未命中时返回空应答 (NODATA)，客户端稍后重试即可
+		tag = res.Tag
+		ttl = uint32(min(res.TTL, maxTTL))
+	}
+
+	b := dnsmessage.NewBuilder(make([]byte, 0, 512), respHdr)
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		return nil
+	}
+	if err := b.Question(q); err != nil {
+		return nil
+	}
+	if tag != "" {
+		if err := b.StartAnswers(); err != nil {
+			return nil
+		}
+		err := b.TXTResource(dnsmessage.ResourceHeader{
+			Name:  q.Name,
+			Class: dnsmessage.ClassINET,
+			TTL:   ttl,
+		}, dnsmessage.TXTResource{TXT: []string{tag}})
+		if err != nil {
+			log.Error("构建应答失败", "name", q.Name.String(), "err", err)
+			return nil
+		}
+	}
+
+	resp, err := b.Finish()
+	if err != nil {
+		return nil
+	}
+	return resp
+}
+
+// parseName 从查询名中提取 IP，如 1.2.3.4.ip.resolver.local. -> 1.2.3.4
+func (s *Server) parseName(name string) (string, bool) {
+	if !strings.HasSuffix(name, s.zone) {
+		return "", false
+	}
+	label := strings.TrimSuffix(name, s.zone)
+	if strings.Contains(label, "-") {
+		label = strings.ReplaceAll(label, "-", ":")
+	}
+	if net.ParseIP(label) == nil {
+		return "", false
+	}
+	return label, true
+}
@@ -0,0 +1,94 @@
+package dnsserver
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func query(t *testing.T, id uint16, name string, typ dnsmessage.Type) []byte {
+	t.Helper()
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, RecursionDesired: true})
+	if err := b.StartQuestions(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Question(dnsmessage.Question{Name: dnsmessage.MustNewName(name), Type: typ, Class: dnsmessage.ClassINET}); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := b.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+// 不涉及解析的查询 (区域外、非 TXT、无法解析的名称) 经固定 worker 处理，
+// 并发数远超 worker 数时全部得到应答，Close 后 worker 全部退出
+func TestServeWorkers(t *testing.T) {
+	tests := []struct {
+		name  string
+		qname string
+		typ   dnsmessage.Type
+		rcode dnsmessage.RCode
+	}{
+		{"outside zone", "1.2.3.4.example.com.", dnsmessage.TypeTXT, dnsmessage.RCodeRefused},
+		{"not an ip", "foo.ip.resolver.local.", dnsmessage.TypeTXT, dnsmessage.RCodeNameError},
+		{"non-txt", "1.2.3.4.ip.resolver.local.", dnsmessage.TypeA, dnsmessage.RCodeSuccess},
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New("", "ip.resolver.local", nil)
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(conn) }()
+
+	client, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	const rounds = udpWorkers * 2 / 3 // 每轮 3 个查询，总数超过 worker 数
+	for i := range rounds {
+		for j, tt := range tests {
+			if _, err := client.WriteTo(query(t, uint16(i*len(tests)+j), tt.qname, tt.typ), conn.LocalAddr()); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	got := make(map[uint16]dnsmessage.RCode)
+	buf := make([]byte, 512)
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for len(got) < rounds*len(tests) {
+		n, _, err := client.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("got %d of %d responses: %v", len(got), rounds*len(tests), err)
+		}
+		var p dnsmessage.Parser
+		hdr, err := p.Start(buf[:n])
+		if err != nil || !hdr.Response {
+			t.Fatalf("bad response: %v", err)
+		}
+		got[hdr.ID] = hdr.RCode
+	}
+	for id, rcode := range got {
+		if tt := tests[int(id)%len(tests)]; rcode != tt.rcode {
+			t.Fatalf("%s: rcode = %v, want %v", tt.name, rcode, tt.rcode)
+		}
+	}
+
+	s.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not return after Close")
+	}
+}
@@ -0,0 +1,362 @@
+package errreport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"ip-resolver/internal/logging"
+	"ip-resolver/internal/requestid"
+	"ip-resolver/internal/version"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var log = logging.For("ErrReport")
+
+// ======== 硬编码参数 =========
+const (
+	sendTimeout    = 5 * time.Second
+	queueSize      = 64 // 待发送事件上限，服务异常时宁可丢弃也不阻塞 Worker
+	defaultBugsnag = "https://notify.bugsnag.com"
+	notifierName   = "ip-resolver"
+	notifierURL    = "https://github.com/BaeKey/ip-resolver"
+)
+
+// Event 上报的单个错误事件
+type Event struct {
+	Kind      string // panic (Worker 或 HTTP Handler) / provider_failure
+	Message   string
+	IP        string
+	Provider  string
+	RequestID string
+	JobID     string
+	Stack     string // panic 时的协程栈
+	Extra     map[string]any
+	Time      time.Time
+}
+
+// Options 上报配置，SentryDSN 与 BugsnagAPIKey 可同时配置
+type Options struct {
+	SentryDSN       string
+	BugsnagAPIKey   string
+	BugsnagEndpoint string // 默认 https://notify.bugsnag.com (自建服务时修改)
+	Environment     string
+}
+
+// sink 一个上报目的地
+type sink interface {
+	name() string
+	request(ctx context.Context, e Event) (*http.Request, error)
+}
+
+// Reporter 将 panic 与上游持续失败异步转发到 Sentry / Bugsnag
+// nil Reporter 的 Report 为空操作，调用方无需判断是否启用
+type Reporter struct {
+	sinks  []sink
+	client *http.Client
+	queue  chan Event
+
+	dropped atomic.Int64 // 队列已满被丢弃的事件数
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func New(opts Options) (*Reporter, error) {
+	host, _ := os.Hostname()
+	meta := eventMeta{host: host, env: opts.Environment, release: version.Short()}
+
+	r := &Reporter{
+		client: &http.Client{Timeout: sendTimeout},
+		queue:  make(chan Event, queueSize),
+		stop:   make(chan struct{}),
+	}
+	if opts.SentryDSN != "" {
+		s, err := newSentry(opts.SentryDSN, meta)
+		if err != nil {
+			return nil, err
+		}
+		r.sinks = append(r.sinks, s)
+	}
+	if opts.BugsnagAPIKey != "" {
+		endpoint := opts.BugsnagEndpoint
+		if endpoint == "" {
+			endpoint = defaultBugsnag
+		}
+		r.sinks = append(r.sinks, &bugsnag{apiKey: opts.BugsnagAPIKey, endpoint: endpoint, meta: meta})
+	}
+	return r, nil
+}
+
+func (r *Reporter) Start() {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		for {
+			select {
+			case e := <-r.queue:
+				r.send(e)
+			case <-r.stop:
+				// 退出前发送已排队的事件
+				for {
+					select {
+					case e := <-r.queue:
+						r.send(e)
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+}
+
+func (r *Reporter) Stop() {
+	close(r.stop)
+	r.wg.Wait()
+
+	if dropped := r.dropped.Load(); dropped > 0 {
+		log.Warn("部分错误事件因队列已满未上报", "dropped", dropped)
+	}
+}
+
+// Report 将事件加入发送队列，不阻塞调用方
+func (r *Reporter) Report(e Event) {
+	if r == nil || len(r.sinks) == 0 {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	select {
+	case r.queue <- e:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+// Middleware 上报 HTTP Handler 中的 panic 后继续向上抛出，由 net/http 照常中断连接并记录日志
+// (http.ErrAbortHandler 为主动中断，不上报)；nil Reporter 时原样返回 next
+func (r *Reporter) Middleware(next http.Handler) http.Handler {
+	if r == nil || len(r.sinks) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		defer func() {
+			if v := recover(); v != nil {
+				if v != http.ErrAbortHandler {
+					r.Report(Event{
+						Kind: "panic", Message: "panic: " + fmt.Sprint(v), RequestID: requestid.FromContext(req.Context()),
+						Stack: string(debug.Stack()), Extra: map[string]any{"method": req.Method, "path": req.URL.Path},
+					})
+				}
+				panic(v)
+			}
+		}()
+		next.ServeHTTP(w, req)
+	})
+}
+
+func (r *Reporter) send(e Event) {
+	for _, s := range r.sinks {
+		if err := r.post(s, e); err != nil {
+			log.Error("上报错误事件失败", "sink", s.name(), "kind", e.Kind, "err", err)
+		}
+	}
+}
+
+func (r *Reporter) post(s sink, e Event) error {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	req, err := s.request(ctx, e)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", notifierName+"/"+version.Short())
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// eventMeta 所有事件共用的进程信息
+type eventMeta struct {
+	host    string
+	env     string
+	release string
+}
+
+// tags 事件的可检索字段 (Sentry tags / Bugsnag metaData)
+func (e Event) tags() map[string]string {
+	t := map[string]string{"kind": e.Kind}
+	for k, v := range map[string]string{"ip": e.IP, "provider": e.Provider, "request_id": e.RequestID, "job_id": e.JobID} {
+		if v != "" {
+			t[k] = v
+		}
+	}
+	return t
+}
+
+// ======== Sentry =========
+
+// sentry 使用 Store API (/api/<project>/store/)，无需引入 SDK
+type sentry struct {
+	endpoint string
+	auth     string
+	meta     eventMeta
+}
+
+func newSentry(dsn string, meta eventMeta) (*sentry, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sentry dsn: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" || u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("invalid sentry dsn: expected https://<key>@<host>/<project>")
+	}
+	path := strings.Trim(u.Path, "/")
+	i := strings.LastIndex(path, "/")
+	project := path[i+1:]
+	if project == "" {
+		return nil, fmt.Errorf("invalid sentry dsn: missing project id")
+	}
+	prefix := ""
+	if i >= 0 {
+		prefix = "/" + path[:i]
+	}
+
+	auth := "Sentry sentry_version=7, sentry_client=" + notifierName + "/" + meta.release + ", sentry_key=" + u.User.Username()
+	if secret, ok := u.User.Password(); ok {
+		auth += ", sentry_secret=" + secret
+	}
+	return &sentry{
+		endpoint: u.Scheme + "://" + u.Host + prefix + "/api/" + project + "/store/",
+		auth:     auth,
+		meta:     meta,
+	}, nil
+}
+
+func (s *sentry) name() string { return "sentry" }
+
+func (s *sentry) request(ctx context.Context, e Event) (*http.Request, error) {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	level := "error"
+	if e.Kind == "panic" {
+		level = "fatal"
+	}
+	extra := map[string]any{}
+	for k, v := range e.Extra {
+		extra[k] = v
+	}
+	if e.Stack != "" {
+		extra["stack"] = e.Stack
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"event_id":    hex.EncodeToString(id),
+		"timestamp":   e.Time.UTC().Format(time.RFC3339),
+		"level":       level,
+		"logger":      notifierName,
+		"platform":    "go",
+		"message":     e.Message,
+		"server_name": s.meta.host,
+		"release":     s.meta.release,
+		"environment": s.meta.env,
+		"fingerprint": []string{e.Kind, e.Provider},
+		"tags":        e.tags(),
+		"extra":       extra,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Sentry-Auth", s.auth)
+	return req, nil
+}
+
+// ======== Bugsnag =========
+
+// bugsnag 使用 Error Reporting API (payload version 5)
+type bugsnag struct {
+	apiKey   string
+	endpoint string
+	meta     eventMeta
+}
+
+func (b *bugsnag) name() string { return "bugsnag" }
+
+func (b *bugsnag) request(ctx context.Context, e Event) (*http.Request, error) {
+	severity := "error"
+	if e.Kind == "provider_failure" {
+		severity = "warning"
+	}
+	reqMeta := map[string]any{}
+	for k, v := range e.tags() {
+		reqMeta[k] = v
+	}
+	meta := map[string]any{"request": reqMeta}
+	if len(e.Extra) > 0 {
+		meta["extra"] = e.Extra
+	}
+	if e.Stack != "" {
+		meta["stack"] = map[string]string{"trace": e.Stack}
+	}
+	where := e.Provider
+	if where == "" {
+		where = e.IP
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"apiKey":         b.apiKey,
+		"payloadVersion": "5",
+		"notifier":       map[string]string{"name": notifierName, "version": b.meta.release, "url": notifierURL},
+		"events": []map[string]any{{
+			"exceptions": []map[string]any{{
+				"errorClass": e.Kind,
+				"message":    e.Message,
+				"stacktrace": []any{},
+			}},
+			"severity":     severity,
+			"unhandled":    e.Kind == "panic",
+			"context":      where,
+			"groupingHash": e.Kind + "|" + e.Provider,
+			"app":          map[string]string{"version": b.meta.release, "releaseStage": b.meta.env},
+			"device":       map[string]string{"hostname": b.meta.host, "time": e.Time.UTC().Format(time.RFC3339)},
+			"metaData":     meta,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Bugsnag-Api-Key", b.apiKey)
+	req.Header.Set("Bugsnag-Payload-Version", "5")
+	req.Header.Set("Bugsnag-Sent-At", time.Now().UTC().Format(time.RFC3339))
+	return req, nil
+}
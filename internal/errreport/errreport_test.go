@@ -0,0 +1,63 @@
+package errreport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type nopSink struct{}
+
+func (nopSink) name() string { return "nop" }
+func (nopSink) request(ctx context.Context, e Event) (*http.Request, error) {
+	return nil, nil
+}
+
+func TestMiddlewareReportsPanic(t *testing.T) {
+	tests := []struct {
+		name     string
+		panicVal any
+		reported bool
+	}{
+		{"panic", "boom", true},
+		{"abort handler", http.ErrAbortHandler, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Reporter{sinks: []sink{nopSink{}}, queue: make(chan Event, 1)}
+			h := r.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic(tt.panicVal) }))
+
+			func() {
+				defer func() {
+					if v := recover(); v != tt.panicVal {
+						t.Fatalf("re-panicked with %v, want %v", v, tt.panicVal)
+					}
+				}()
+				h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/1.1.1.1", nil))
+			}()
+
+			select {
+			case e := <-r.queue:
+				if !tt.reported {
+					t.Fatalf("unexpected event %+v", e)
+				}
+				if e.Kind != "panic" || e.Stack == "" || e.Extra["path"] != "/1.1.1.1" {
+					t.Fatalf("event = %+v", e)
+				}
+			default:
+				if tt.reported {
+					t.Fatal("panic not reported")
+				}
+			}
+		})
+	}
+}
+
+func TestMiddlewareDisabled(t *testing.T) {
+	var r *Reporter
+	next := http.NotFoundHandler()
+	if got := r.Middleware(next); got == nil {
+		t.Fatal("nil reporter should return next")
+	}
+}
@@ -0,0 +1,93 @@
+// Package fanout 进程内事件扇出: 每个订阅者一个带缓冲的 channel，
+// 订阅者消费过慢时丢弃事件，发布方 (缓存写入、worker) 永不阻塞
+package fanout
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+const defaultBuffer = 256
+
+// Hub 事件扇出，零值可用
+type Hub[T any] struct {
+	mu      sync.RWMutex
+	subs    map[int]chan T
+	nextID  int
+	closed  bool
+	dropped atomic.Int64 // 订阅者消费过慢被丢弃的事件数
+}
+
+// Subscribe 订阅事件，返回事件通道与取消函数 (buf <= 0 时使用默认缓冲)；
+// Hub 已关闭时返回已关闭的通道
+func (h *Hub[T]) Subscribe(buf int) (<-chan T, func()) {
+	if buf <= 0 {
+		buf = defaultBuffer
+	}
+	ch := make(chan T, buf)
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		close(ch)
+		return ch, func() {}
+	}
+	if h.subs == nil {
+		h.subs = make(map[int]chan T)
+	}
+	id := h.nextID
+	h.nextID++
+	h.subs[id] = ch
+	h.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			if sub, ok := h.subs[id]; ok {
+				delete(h.subs, id)
+				close(sub)
+			}
+			h.mu.Unlock()
+		})
+	}
+	return ch, cancel
+}
+
+// Publish 向所有订阅者发送事件，缓冲已满的订阅者跳过并计入丢弃数
+func (h *Hub[T]) Publish(ev T) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, ch := range h.subs {
+		select {
+		case ch <- ev:
+		default:
+			h.dropped.Add(1)
+		}
+	}
+}
+
+// HasSubscribers 无订阅者时发布方可跳过事件构造
+func (h *Hub[T]) HasSubscribers() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subs) > 0
+}
+
+// Dropped 返回因订阅者过慢而丢弃的事件数
+func (h *Hub[T]) Dropped() int64 {
+	return h.dropped.Load()
+}
+
+// Close 关闭所有订阅通道，之后的订阅立即得到已关闭的通道
+func (h *Hub[T]) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for id, ch := range h.subs {
+		delete(h.subs, id)
+		close(ch)
+	}
+}
@@ -0,0 +1,49 @@
+package fanout
+
+import "testing"
+
+func TestHub(t *testing.T) {
+	var h Hub[int]
+	if h.HasSubscribers() {
+		t.Fatal("zero Hub has subscribers")
+	}
+	h.Publish(0) // 无订阅者时不计丢弃
+
+	fast, cancelFast := h.Subscribe(4)
+	slow, cancelSlow := h.Subscribe(1)
+	defer cancelSlow()
+	for i := 1; i <= 3; i++ {
+		h.Publish(i)
+	}
+
+	for want := 1; want <= 3; want++ {
+		if got := <-fast; got != want {
+			t.Fatalf("fast subscriber got %d, want %d", got, want)
+		}
+	}
+	if got := <-slow; got != 1 {
+		t.Fatalf("slow subscriber got %d, want 1", got)
+	}
+	if h.Dropped() != 2 {
+		t.Fatalf("Dropped = %d, want 2", h.Dropped())
+	}
+
+	cancelFast()
+	cancelFast() // 重复取消无副作用
+	if _, ok := <-fast; ok {
+		t.Fatal("channel open after cancel")
+	}
+
+	h.Close()
+	if _, ok := <-slow; ok {
+		t.Fatal("channel open after Close")
+	}
+	cancelSlow() // Close 之后取消无副作用
+	late, _ := h.Subscribe(0)
+	if _, ok := <-late; ok {
+		t.Fatal("subscribe after Close should return a closed channel")
+	}
+	if h.HasSubscribers() {
+		t.Fatal("subscribers left after Close")
+	}
+}
@@ -0,0 +1,286 @@
+// Package graceful 通过监听 FD 继承实现零停机重启
+//
+// 旧进程收到信号后 fork-exec 当前二进制，把所有监听 socket 作为 ExtraFiles 传给新进程；
+// 新进程启动完成后通过管道通知旧进程，旧进程随后优雅退出。整个过程中监听 socket 始终处于打开状态，
+// 不会出现 connection refused，旧进程上的在途请求也会正常处理完毕。
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// parentPollInterval WaitParent 检查旧进程是否已退出的间隔
+const parentPollInterval = 100 * time.Millisecond
+
+// 传递给新进程的环境变量
+const (
+	envInheritFDs = "IP_RESOLVER_INHERIT_FDS" // network:addr 列表 (按 FD 3 开始的顺序)
+	envReadyFD    = "IP_RESOLVER_READY_FD"    // 就绪通知管道的 FD
+)
+
+type entry struct {
+	key  string
+	file interface{ File() (*os.File, error) }
+}
+
+// Registry 记录当前进程的监听 socket，并管理从父进程继承的 FD
+type Registry struct {
+	mu        sync.Mutex
+	inherited map[string]*os.File
+	active    []entry
+	readyFD   int
+	parent    int // 拉起本进程的旧进程 PID (非重启启动时为 0)
+	handedOff bool
+}
+
+// New 解析继承自父进程的 FD (非重启启动时为空)
+func New() *Registry {
+	r := &Registry{inherited: make(map[string]*os.File)}
+
+	if list := os.Getenv(envInheritFDs); list != "" {
+		for i, key := range strings.Split(list, ",") {
+			r.inherited[key] = os.NewFile(uintptr(3+i), key)
+		}
+	}
+	if fd, err := strconv.Atoi(os.Getenv(envReadyFD)); err == nil {
+		r.readyFD = fd
+		r.parent = os.Getppid()
+	}
+
+	_ = os.Unsetenv(envInheritFDs)
+	_ = os.Unsetenv(envReadyFD)
+	return r
+}
+
+// Inherits 是否存在从父进程继承的对应 FD
+func (r *Registry) Inherits(network, addr string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.inherited[network+":"+addr] != nil
+}
+
+// take 取出继承的 FD (每个只能使用一次)
+func (r *Registry) take(key string) *os.File {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f := r.inherited[key]
+	delete(r.inherited, key)
+	return f
+}
+
+func (r *Registry) add(key string, file interface{ File() (*os.File, error) }) {
+	r.mu.Lock()
+	r.active = append(r.active, entry{key: key, file: file})
+	r.mu.Unlock()
+}
+
+// Listen 优先使用继承的 FD，否则新建监听。inherited 表示是否来自父进程
+func (r *Registry) Listen(network, addr string) (l net.Listener, inherited bool, err error) {
+	key := network + ":" + addr
+
+	if f := r.take(key); f != nil {
+		l, err = net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, false, fmt.Errorf("继承监听 %s 失败: %w", key, err)
+		}
+		inherited = true
+	} else {
+		l, err = net.Listen(network, addr)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	file, ok := l.(interface{ File() (*os.File, error) })
+	if !ok {
+		_ = l.Close()
+		return nil, false, fmt.Errorf("监听 %s 不支持导出 FD", key)
+	}
+	r.add(key, file)
+	return l, inherited, nil
+}
+
+// ListenPacket 同 Listen，用于 UDP
+func (r *Registry) ListenPacket(network, addr string) (net.PacketConn, error) {
+	key := network + ":" + addr
+
+	var conn net.PacketConn
+	var err error
+	if f := r.take(key); f != nil {
+		conn, err = net.FilePacketConn(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("继承监听 %s 失败: %w", key, err)
+		}
+	} else {
+		conn, err = net.ListenPacket(network, addr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	file, ok := conn.(interface{ File() (*os.File, error) })
+	if !ok {
+		_ = conn.Close()
+		return nil, fmt.Errorf("监听 %s 不支持导出 FD", key)
+	}
+	r.add(key, file)
+	return conn, nil
+}
+
+// Ready 新进程启动完成后通知父进程 (非重启启动时为空操作)，并关闭未被使用的继承 FD
+func (r *Registry) Ready() error {
+	r.mu.Lock()
+	for key, f := range r.inherited {
+		_ = f.Close()
+		delete(r.inherited, key)
+	}
+	fd := r.readyFD
+	r.readyFD = 0
+	r.mu.Unlock()
+
+	if fd == 0 {
+		return nil
+	}
+
+	f := os.NewFile(uintptr(fd), "ready")
+	defer f.Close()
+	_, err := f.Write([]byte{1})
+	return err
+}
+
+// WaitParent 由旧进程拉起时阻塞到旧进程退出 (退出后本进程被重新挂到其他父进程下)，返回 true；
+// 非重启启动或 ctx 先结束时返回 false。旧进程退出前会保存其状态，调用方可在此之后接管
+func (r *Registry) WaitParent(ctx context.Context) bool {
+	if r.parent == 0 {
+		return false
+	}
+
+	t := time.NewTicker(parentPollInterval)
+	defer t.Stop()
+	for os.Getppid() == r.parent {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-t.C:
+		}
+	}
+	return true
+}
+
+// HandedOff 监听是否已移交给新进程 (此时不应删除 Unix Socket 文件)
+func (r *Registry) HandedOff() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.handedOff
+}
+
+// Upgrade 启动新进程并移交所有监听，在 timeout 内等待其就绪
+// 返回 nil 后调用方应优雅退出；失败时新进程会被终止，当前进程继续服务
+func (r *Registry) Upgrade(timeout time.Duration) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	active := append([]entry(nil), r.active...)
+	r.mu.Unlock()
+
+	keys := make([]string, 0, len(active))
+	files := make([]*os.File, 0, len(active)+1)
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+
+	for _, e := range active {
+		f, err := e.file.File()
+		if err != nil {
+			return fmt.Errorf("导出 %s 失败: %w", e.key, err)
+		}
+		keys = append(keys, e.key)
+		files = append(files, f)
+	}
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer readyR.Close()
+	files = append(files, readyW)
+
+	env := make([]string, 0, len(os.Environ())+2)
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, envInheritFDs+"=") && !strings.HasPrefix(kv, envReadyFD+"=") {
+			env = append(env, kv)
+		}
+	}
+	env = append(env,
+		envInheritFDs+"="+strings.Join(keys, ","),
+		envReadyFD+"="+strconv.Itoa(3+len(keys)),
+	)
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = env
+	cmd.ExtraFiles = files
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("启动新进程失败: %w", err)
+	}
+	// 关闭本进程持有的写端，新进程退出时读端才能收到 EOF
+	_ = readyW.Close()
+	files = files[:len(files)-1]
+
+	ready := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		_, err := readyR.Read(buf)
+		if errors.Is(err, io.EOF) {
+			err = errors.New("新进程未就绪即退出")
+		}
+		ready <- err
+	}()
+
+	select {
+	case err := <-ready:
+		if err != nil {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+			return err
+		}
+	case <-time.After(timeout):
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return fmt.Errorf("等待新进程就绪超时 (%v)", timeout)
+	}
+
+	// 新进程接管后不再等待其退出，进程由 init 接管
+	go func() { _ = cmd.Process.Release() }()
+
+	r.mu.Lock()
+	r.handedOff = true
+	for _, e := range active {
+		if ul, ok := e.file.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+	}
+	r.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,31 @@
+package grpcserver
+
+import (
+	"context"
+	"ip-resolver/api/resolverpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// protectedMethods 需要管理 Token 的方法，与 HTTP 端的 adm.Protect 对应
+var protectedMethods = map[string]bool{
+	resolverpb.Resolver_Invalidate_FullMethodName: true,
+}
+
+// AuthInterceptor 要求受保护的方法在 metadata 中携带 authorization: Bearer <admin.token>，
+// 未配置 admin.token 时受保护方法一律拒绝
+func AuthInterceptor(authorized func(string) bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if protectedMethods[info.FullMethod] {
+			md, _ := metadata.FromIncomingContext(ctx)
+			values := md.Get("authorization")
+			if len(values) == 0 || !authorized(values[0]) {
+				return nil, status.Error(codes.Unauthenticated, "unauthorized")
+			}
+		}
+		return handler(ctx, req)
+	}
+}
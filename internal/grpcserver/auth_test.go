@@ -0,0 +1,43 @@
+package grpcserver
+
+import (
+	"context"
+	"ip-resolver/api/resolverpb"
+	"ip-resolver/internal/admin"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthInterceptor(t *testing.T) {
+	handler := func(ctx context.Context, req any) (any, error) { return "ok", nil }
+	tests := []struct {
+		name   string
+		token  string // admin.token
+		method string
+		auth   string // 请求携带的 authorization
+		want   codes.Code
+	}{
+		{"invalidate with token", "secret", resolverpb.Resolver_Invalidate_FullMethodName, "Bearer secret", codes.OK},
+		{"invalidate without token", "secret", resolverpb.Resolver_Invalidate_FullMethodName, "", codes.Unauthenticated},
+		{"invalidate wrong token", "secret", resolverpb.Resolver_Invalidate_FullMethodName, "Bearer nope", codes.Unauthenticated},
+		{"invalidate admin token unset", "", resolverpb.Resolver_Invalidate_FullMethodName, "Bearer ", codes.Unauthenticated},
+		{"resolve is public", "secret", resolverpb.Resolver_Resolve_FullMethodName, "", codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intercept := AuthInterceptor(admin.New(tt.token).AuthorizedHeader)
+			ctx := context.Background()
+			if tt.auth != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tt.auth))
+			}
+			_, err := intercept(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("code = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package grpcserver
+
+import (
+	"context"
+	"ip-resolver/api/resolverpb"
+	"ip-resolver/internal/worker"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server 基于 Manager 实现 gRPC Resolver 服务
+type Server struct {
+	resolverpb.UnimplementedResolverServer
+	mgr *worker.Manager
+}
+
+func New(mgr *worker.Manager) *Server {
+	return &Server{mgr: mgr}
+}
+
+func (s *Server) Resolve(ctx context.Context, req *resolverpb.ResolveRequest) (*resolverpb.ResolveResponse, error) {
+	resp, err := s.mgr.Resolve(ctx, req.GetIp(), req.GetWait())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return toProto(resp), nil
+}
+
+func (s *Server) BatchResolve(ctx context.Context, req *resolverpb.BatchResolveRequest) (*resolverpb.BatchResolveResponse, error) {
+	if len(req.GetIps()) > worker.MaxBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "too many ips (max %d)", worker.MaxBatchSize)
+	}
+
+	results := make(map[string]*resolverpb.ResolveResponse, len(req.GetIps()))
+	for _, ip := range req.GetIps() {
+		resp, err := s.mgr.Resolve(ctx, ip, false)
+		if err != nil {
+			results[ip] = &resolverpb.ResolveResponse{Ip: ip, Error: err.Error()}
+			continue
+		}
+		results[ip] = toProto(resp)
+	}
+	return &resolverpb.BatchResolveResponse{Results: results}, nil
+}
+
+func (s *Server) Invalidate(ctx context.Context, req *resolverpb.InvalidateRequest) (*resolverpb.InvalidateResponse, error) {
+	keys := append([]string(nil), req.GetKeys()...)
+	for _, ip := range req.GetIps() {
+		key, err := s.mgr.KeyForIP(ip)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%s: %v", ip, err)
+		}
+		keys = append(keys, key)
+	}
+
+	var removed int32
+	for _, key := range keys {
+		if s.mgr.Invalidate(key) {
+			removed++
+		}
+	}
+	return &resolverpb.InvalidateResponse{Removed: removed}, nil
+}
+
+func (s *Server) Stats(ctx context.Context, req *resolverpb.StatsRequest) (*resolverpb.StatsResponse, error) {
+	st := s.mgr.Stats()
+
+	resp := &resolverpb.StatsResponse{
+		CacheItems:     st.CacheItems,
+		DroppedUpdates: st.DroppedUpdates,
+		RetriedUpdates: st.RetriedUpdates,
+		QueueLength:    int64(st.QueueLength),
+	}
+	for _, hk := range st.HotKeys {
+		resp.HotKeys = append(resp.HotKeys, &resolverpb.HotKey{Key: hk.Key, Count: hk.Count})
+	}
+	return resp, nil
+}
+
+func toProto(resp worker.LookupResponse) *resolverpb.ResolveResponse {
+	return &resolverpb.ResolveResponse{
+		Ip:         resp.IP,
+		Key:        resp.Key,
+		Tag:        resp.Tag,
+		Province:   resp.Province,
+		Isp:        resp.ISP,
+		Cache:      resp.Cache,
+		TtlSeconds: resp.TTL,
+	}
+}
@@ -0,0 +1,105 @@
+package ipacl
+
+import (
+	"fmt"
+	"ip-resolver/internal/logging"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var log = logging.For("ACL")
+
+// ACL 基于直连地址的来源 IP 访问控制
+// deny 优先于 allow；allow 为空表示允许所有未被 deny 的地址；Unix Socket 连接不受限制
+type ACL struct {
+	name  string
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+// New 解析 CIDR 列表 (单个 IP 视为 /32 或 /128)，任一条目无效即返回错误
+func New(name string, allow, deny []string) (*ACL, error) {
+	a := &ACL{name: name}
+	var err error
+	if a.allow, err = parse(allow); err != nil {
+		return nil, err
+	}
+	if a.deny, err = parse(deny); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Enabled 是否配置了任何规则
+func (a *ACL) Enabled() bool {
+	return len(a.allow) > 0 || len(a.deny) > 0
+}
+
+// Allowed 判断 IP 是否允许访问
+func (a *ACL) Allowed(ip net.IP) bool {
+	for _, n := range a.deny {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	if len(a.allow) == 0 {
+		return true
+	}
+	for _, n := range a.allow {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware 拒绝不在允许范围内的连接 (403)
+func (a *ACL) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Unix Socket 的 RemoteAddr 为空或 "@"
+		if r.RemoteAddr == "" || r.RemoteAddr == "@" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		ip := net.ParseIP(host)
+		if ip == nil || !a.Allowed(ip) {
+			log.Info("拒绝来源", "listener", a.name, "client", host, "method", r.Method, "path", r.URL.Path)
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func parse(list []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, s := range list {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("无效的 IP: %q", s)
+			}
+			if ip.To4() != nil {
+				s += "/32"
+			} else {
+				s += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 CIDR %q: %w", s, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
@@ -0,0 +1,249 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ComponentKey 组件字段名，text 模式下渲染为行首的 [组件]
+const ComponentKey = "component"
+
+var (
+	level   slog.LevelVar
+	current atomic.Pointer[slog.Handler]
+)
+
+func init() {
+	var h slog.Handler = NewTextHandler(os.Stderr, &level)
+	current.Store(&h)
+	slog.SetDefault(slog.New(dispatch{}))
+}
+
+// Setup 按配置切换日志输出、格式 (text / json) 与等级，标准库 log 的输出也会经由 slog
+func Setup(out io.Writer, format, lvl string) {
+	SetLevel(lvl)
+
+	var h slog.Handler
+	if format == "json" {
+		h = slog.NewJSONHandler(out, &slog.HandlerOptions{Level: &level})
+	} else {
+		h = NewTextHandler(out, &level)
+	}
+	current.Store(&h)
+}
+
+// SetLevel 设置日志等级 (debug / info)，未知值按 info 处理
+func SetLevel(lvl string) {
+	if strings.EqualFold(lvl, "debug") {
+		level.Set(slog.LevelDebug)
+	} else {
+		level.Set(slog.LevelInfo)
+	}
+}
+
+// Level 返回当前日志等级 (debug / info)
+func Level() string {
+	if level.Level() <= slog.LevelDebug {
+		return "debug"
+	}
+	return "info"
+}
+
+// ToggleLevel 在 info 与 debug 之间切换，返回切换后的等级
+func ToggleLevel() string {
+	next := "debug"
+	if Level() == "debug" {
+		next = "info"
+	}
+	SetLevel(next)
+	slog.Info("日志等级已切换", "level", next)
+	return next
+}
+
+// HandleLevel GET 查看当前日志等级，PUT / POST ?level=debug|info 在运行时切换 (不落盘，重启后恢复配置值)
+func HandleLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		lvl := strings.ToLower(r.URL.Query().Get("level"))
+		if lvl != "debug" && lvl != "info" {
+			http.Error(w, "level must be debug or info", http.StatusBadRequest)
+			return
+		}
+		if lvl != Level() {
+			SetLevel(lvl)
+			slog.Info("日志等级已切换", "level", lvl)
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"level": Level()})
+}
+
+// For 返回带组件标签的 logger，可在 Setup 之前创建 (输出目标在写日志时才确定)
+func For(component string) *slog.Logger {
+	return slog.Default().With(ComponentKey, component)
+}
+
+// Fatal 记录错误后退出进程，替代 log.Fatalf
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// dispatch 把记录转交给当前生效的 handler，使 Setup 前创建的 logger 也能跟随配置切换
+type dispatch struct {
+	attrs []slog.Attr
+	group string
+}
+
+func (d dispatch) handler() slog.Handler {
+	h := *current.Load()
+	if d.group != "" {
+		h = h.WithGroup(d.group)
+	}
+	if len(d.attrs) > 0 {
+		h = h.WithAttrs(d.attrs)
+	}
+	return h
+}
+
+func (d dispatch) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= level.Level()
+}
+
+func (d dispatch) Handle(ctx context.Context, r slog.Record) error {
+	return d.handler().Handle(ctx, r)
+}
+
+func (d dispatch) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return dispatch{attrs: append(d.attrs[:len(d.attrs):len(d.attrs)], attrs...), group: d.group}
+}
+
+func (d dispatch) WithGroup(name string) slog.Handler {
+	if d.group != "" {
+		name = d.group + "." + name
+	}
+	return dispatch{attrs: d.attrs, group: name}
+}
+
+// TextHandler 保持以往 log 包的行格式:
+//
+//	2006/01/02 15:04:05 [组件] 消息 | key=value | key=value
+//
+// info 以外的等级在组件前追加 [DEBUG] / [WARN] / [ERROR]
+type TextHandler struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	level  slog.Leveler
+	attrs  []slog.Attr
+	prefix string
+}
+
+func NewTextHandler(out io.Writer, level slog.Leveler) *TextHandler {
+	return &TextHandler{mu: &sync.Mutex{}, out: out, level: level}
+}
+
+func (h *TextHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.level.Level()
+}
+
+func (h *TextHandler) Handle(_ context.Context, r slog.Record) error {
+	var buf bytes.Buffer
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	buf.WriteString(t.Format("2006/01/02 15:04:05 "))
+	if r.Level != slog.LevelInfo {
+		buf.WriteString("[" + r.Level.String() + "] ")
+	}
+
+	component := ""
+	var fields []slog.Attr
+	collect := func(a slog.Attr) bool {
+		if a.Key == ComponentKey && h.prefix == "" {
+			component = a.Value.String()
+			return true
+		}
+		if !a.Equal(slog.Attr{}) {
+			fields = append(fields, a)
+		}
+		return true
+	}
+	for _, a := range h.attrs {
+		collect(a)
+	}
+	bound := len(fields)
+	r.Attrs(collect)
+
+	if component != "" {
+		buf.WriteString("[" + component + "] ")
+	}
+	buf.WriteString(r.Message)
+	for i, a := range fields {
+		// WithAttrs 绑定的字段已带分组前缀
+		if i < bound {
+			writeAttr(&buf, "", a)
+		} else {
+			writeAttr(&buf, h.prefix, a)
+		}
+	}
+	buf.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.out.Write(buf.Bytes())
+	return err
+}
+
+func writeAttr(buf *bytes.Buffer, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, g := range a.Value.Group() {
+			writeAttr(buf, prefix, g)
+		}
+		return
+	}
+	// 多行内容 (如 panic 堆栈) 原样换行输出
+	if v := a.Value.String(); strings.Contains(v, "\n") {
+		fmt.Fprintf(buf, " | %s%s=\n%s", prefix, a.Key, strings.TrimRight(v, "\n"))
+		return
+	}
+	fmt.Fprintf(buf, " | %s%s=%s", prefix, a.Key, a.Value.String())
+}
+
+func (h *TextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	n := *h
+	n.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, a := range attrs {
+		if h.prefix != "" {
+			a.Key = h.prefix + a.Key
+		}
+		n.attrs = append(n.attrs, a)
+	}
+	return &n
+}
+
+func (h *TextHandler) WithGroup(name string) slog.Handler {
+	n := *h
+	n.prefix = h.prefix + name + "."
+	return &n
+}
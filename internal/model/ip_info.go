@@ -1,6 +1,7 @@
 package model
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 )
@@ -118,9 +119,33 @@ func (i *IPInfo) detectISPCode() {
 	}
 }
 
+// FallbackTag 无法识别省份或运营商时的 Tag
+const FallbackTag = "fallback"
+
 func (i *IPInfo) ToTag() string {
 	if i.ProvinceCode == "" || i.ISPCode == "" {
-		return "fallback"
+		return FallbackTag
 	}
 	return fmt.Sprintf("%s_%s", i.ProvinceCode, i.ISPCode)
 }
+
+// EncodeDetail 将省份/运营商原始信息编码为缓存附加信息
+func (i *IPInfo) EncodeDetail() string {
+	b, err := json.Marshal(i)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// ParseDetail 解析缓存附加信息，为空或格式错误时返回 nil
+func ParseDetail(detail string) *IPInfo {
+	if detail == "" {
+		return nil
+	}
+	var info IPInfo
+	if err := json.Unmarshal([]byte(detail), &info); err != nil {
+		return nil
+	}
+	return &info
+}
@@ -0,0 +1,69 @@
+package monitor
+
+import "time"
+
+// CounterState 需要跨重启保留的累计计数
+type CounterState struct {
+    Since         time.Time                   `json:"since"` // 开始计数的时间 (首次启动)
+    SavedAt       time.Time                   `json:"saved_at"`
+    TotalRequests int64                       `json:"total_requests"`
+    SuccessCount  int64                       `json:"success_count"`
+    FailCount     int64                       `json:"fail_count"`
+    PanicCount    int64                       `json:"panic_count"`
+    Providers     map[string]ProviderCounters `json:"providers,omitempty"`
+}
+
+// ProviderCounters 单个提供商的累计计数
+type ProviderCounters struct {
+    TotalRequests  int64   `json:"total_requests"`
+    SuccessCount   int64   `json:"success_count"`
+    FailCount      int64   `json:"fail_count"`
+    TotalLatencyMs float64 `json:"total_latency_ms"` // 用于恢复后继续计算平均耗时
+}
+
+// Counters 返回当前的累计计数，供持久化使用
+func (m *Monitor) Counters() CounterState {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    s := CounterState{
+        Since:         m.countersSince,
+        SavedAt:       time.Now(),
+        TotalRequests: m.TotalRequests,
+        SuccessCount:  m.SuccessCount,
+        FailCount:     m.FailCount,
+        PanicCount:    m.PanicCount,
+        Providers:     make(map[string]ProviderCounters, len(m.providers)),
+    }
+    for name, ps := range m.providers {
+        s.Providers[name] = ProviderCounters{
+            TotalRequests:  ps.TotalRequests,
+            SuccessCount:   ps.SuccessCount,
+            FailCount:      ps.FailCount,
+            TotalLatencyMs: float64(ps.totalLatency.Microseconds()) / 1000,
+        }
+    }
+    return s
+}
+
+// RestoreCounters 将上次保存的计数累加到当前计数上 (启动时调用)
+// 连续失败次数、最近错误与耗时分布不恢复，避免重启前的故障影响就绪判定
+func (m *Monitor) RestoreCounters(s CounterState) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    m.TotalRequests += s.TotalRequests
+    m.SuccessCount += s.SuccessCount
+    m.FailCount += s.FailCount
+    m.PanicCount += s.PanicCount
+    if !s.Since.IsZero() && s.Since.Before(m.countersSince) {
+        m.countersSince = s.Since
+    }
+    for name, pc := range s.Providers {
+        ps := m.provider(name)
+        ps.TotalRequests += pc.TotalRequests
+        ps.SuccessCount += pc.SuccessCount
+        ps.FailCount += pc.FailCount
+        ps.totalLatency += time.Duration(pc.TotalLatencyMs * float64(time.Millisecond))
+    }
+}
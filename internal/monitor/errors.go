@@ -0,0 +1,99 @@
+package monitor
+
+import (
+    "encoding/json"
+    "net/http"
+    "strconv"
+    "time"
+)
+
+// DefaultErrorHistorySize 默认保留的最近错误条数
+const DefaultErrorHistorySize = 100
+
+// ErrorEntry 单条上游失败或 Worker panic 记录
+type ErrorEntry struct {
+    Time     time.Time `json:"time"`
+    IP       string    `json:"ip,omitempty"`
+    Provider string    `json:"provider,omitempty"` // panic 时为空
+    Message  string    `json:"message"`
+}
+
+// errorRing 固定容量的环形缓冲，只保留最近的 N 条错误 (调用方持有 Monitor 的锁)
+type errorRing struct {
+    buf   []ErrorEntry
+    next  int
+    full  bool
+    total int64 // 自启动起记录的错误总数 (含已被覆盖的)
+}
+
+func newErrorRing(size int) errorRing {
+    return errorRing{buf: make([]ErrorEntry, size)}
+}
+
+func (r *errorRing) add(e ErrorEntry) {
+    r.total++
+    if len(r.buf) == 0 {
+        return
+    }
+    r.buf[r.next] = e
+    r.next = (r.next + 1) % len(r.buf)
+    if r.next == 0 {
+        r.full = true
+    }
+}
+
+// list 按时间倒序返回，最新的在前
+func (r *errorRing) list() []ErrorEntry {
+    n := r.next
+    if r.full {
+        n = len(r.buf)
+    }
+    out := make([]ErrorEntry, 0, n)
+    for i := 1; i <= n; i++ {
+        out = append(out, r.buf[(r.next-i+len(r.buf))%len(r.buf)])
+    }
+    return out
+}
+
+// SetErrorHistorySize 设置保留的最近错误条数 (清空已有记录)，0 为不保留
+func (m *Monitor) SetErrorHistorySize(n int) {
+    m.mu.Lock()
+    m.recentErrors = newErrorRing(n)
+    m.mu.Unlock()
+}
+
+// RecentErrors 返回最近的错误，最新的在前
+func (m *Monitor) RecentErrors() []ErrorEntry {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    return m.recentErrors.list()
+}
+
+// HandleErrors GET /errors: 最近的上游失败与 panic，支持 ?provider= 筛选与 ?limit= 限制条数
+func (m *Monitor) HandleErrors(w http.ResponseWriter, r *http.Request) {
+    m.mu.RLock()
+    entries := m.recentErrors.list()
+    total := m.recentErrors.total
+    capacity := len(m.recentErrors.buf)
+    m.mu.RUnlock()
+
+    if p := r.URL.Query().Get("provider"); p != "" {
+        filtered := entries[:0]
+        for _, e := range entries {
+            if e.Provider == p {
+                filtered = append(filtered, e)
+            }
+        }
+        entries = filtered
+    }
+    if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit >= 0 && limit < len(entries) {
+        entries = entries[:limit]
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(struct {
+        Total    int64        `json:"total"`    // 自启动起的错误总数
+        Capacity int          `json:"capacity"` // 保留条数上限
+        Errors   []ErrorEntry `json:"errors"`
+    }{total, capacity, entries})
+}
@@ -0,0 +1,84 @@
+package monitor
+
+import (
+    "math"
+    "sort"
+    "sync/atomic"
+    "time"
+)
+
+// 耗时分布的桶上界 (毫秒)，超出最后一个桶的记入溢出桶
+var latencyBucketsMs = [...]float64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000, 10000}
+
+// Histogram 固定分桶的耗时分布 (自启动起累计)，无锁，用于估算 p50 / p95 / p99
+type Histogram struct {
+    counts [len(latencyBucketsMs) + 1]atomic.Int64 // 最后一个为溢出桶
+    maxNs  atomic.Int64
+}
+
+// LatencySummary 耗时分布摘要，分位数按所在桶线性插值估算
+type LatencySummary struct {
+    Count int64   `json:"count"`
+    P50Ms float64 `json:"p50_ms"`
+    P95Ms float64 `json:"p95_ms"`
+    P99Ms float64 `json:"p99_ms"`
+    MaxMs float64 `json:"max_ms"`
+}
+
+func (h *Histogram) Observe(d time.Duration) {
+    ms := float64(d) / float64(time.Millisecond)
+    i := sort.SearchFloat64s(latencyBucketsMs[:], ms)
+    h.counts[i].Add(1)
+    for {
+        old := h.maxNs.Load()
+        if int64(d) <= old || h.maxNs.CompareAndSwap(old, int64(d)) {
+            return
+        }
+    }
+}
+
+func (h *Histogram) Summary() LatencySummary {
+    var counts [len(latencyBucketsMs) + 1]int64
+    var n int64
+    for i := range counts {
+        counts[i] = h.counts[i].Load()
+        n += counts[i]
+    }
+    s := LatencySummary{Count: n, MaxMs: float64(h.maxNs.Load()) / float64(time.Millisecond)}
+    if n == 0 {
+        return s
+    }
+    s.P50Ms = roundMs(quantile(counts[:], n, 0.50, s.MaxMs))
+    s.P95Ms = roundMs(quantile(counts[:], n, 0.95, s.MaxMs))
+    s.P99Ms = roundMs(quantile(counts[:], n, 0.99, s.MaxMs))
+    s.MaxMs = roundMs(s.MaxMs)
+    return s
+}
+
+// quantile 找到第 q 分位所在的桶，在桶内按计数线性插值；结果不超过观测到的最大值
+func quantile(counts []int64, n int64, q float64, maxMs float64) float64 {
+    rank := q * float64(n)
+    var seen int64
+    for i, c := range counts {
+        if c == 0 || float64(seen+c) < rank {
+            seen += c
+            continue
+        }
+        lower := 0.0
+        if i > 0 {
+            lower = latencyBucketsMs[i-1]
+        }
+        upper := maxMs
+        if i < len(latencyBucketsMs) {
+            upper = min(latencyBucketsMs[i], maxMs)
+        }
+        v := lower + (upper-lower)*(rank-float64(seen))/float64(c)
+        return min(max(v, lower), maxMs)
+    }
+    return maxMs
+}
+
+// roundMs 保留到微秒
+func roundMs(v float64) float64 {
+    return math.Round(v*1000) / 1000
+}
@@ -0,0 +1,138 @@
+package monitor
+
+import "sort"
+
+// Metric 扁平化的单个指标，供 StatsD 等推送式导出使用
+type Metric struct {
+    Name    string
+    Value   float64
+    Counter bool     // 自启动起的累计值，导出方按需换算为增量
+    Tags    []string // "key:value"，如 "provider:38599"
+}
+
+// Metrics 将 Snapshot 展开为指标列表，与 /status 的字段一一对应
+func (m *Monitor) Metrics() []Metric {
+    s := m.Snapshot()
+
+    out := []Metric{
+        {Name: "upstream.requests", Value: float64(s.TotalRequests), Counter: true},
+        {Name: "upstream.success", Value: float64(s.SuccessCount), Counter: true},
+        {Name: "upstream.failures", Value: float64(s.FailCount), Counter: true},
+        {Name: "upstream.consecutive_errors", Value: float64(s.ConsecutiveErr)},
+        {Name: "panics", Value: float64(s.PanicCount), Counter: true},
+        {Name: "cache.items", Value: float64(s.CacheItemCount)},
+        {Name: "cache.drift", Value: float64(s.CacheCountDrift)},
+    }
+    rt := s.Runtime
+    out = append(out,
+        Metric{Name: "runtime.goroutines", Value: float64(rt.Goroutines)},
+        Metric{Name: "runtime.heap_alloc_mb", Value: rt.HeapAllocMB},
+        Metric{Name: "runtime.heap_inuse_mb", Value: rt.HeapInuseMB},
+        Metric{Name: "runtime.sys_mb", Value: rt.SysMB},
+        Metric{Name: "runtime.gc", Value: float64(rt.NumGC), Counter: true},
+        Metric{Name: "runtime.gc_pause_ms", Value: rt.TotalPauseMs, Counter: true},
+        Metric{Name: "runtime.last_gc_pause_ms", Value: rt.LastPauseMs},
+    )
+    if rt.OpenFDs >= 0 {
+        out = append(out, Metric{Name: "runtime.open_fds", Value: float64(rt.OpenFDs)})
+    }
+    if s.RemainingRequestNum >= 0 {
+        out = append(out, Metric{Name: "quota.remaining", Value: float64(s.RemainingRequestNum)})
+    }
+
+    out = appendLatency(out, "latency.provider", s.Latency.Provider, nil)
+    out = appendLatency(out, "latency.handler", s.Latency.Handler, nil)
+
+    for _, code := range sortedKeys(s.StatusCodes) {
+        out = append(out, Metric{Name: "responses", Value: float64(s.StatusCodes[code]), Counter: true, Tags: []string{"code:" + code}})
+    }
+
+    for _, w := range sortedKeys(s.HitRatio) {
+        tags := []string{"window:" + w}
+        hr := s.HitRatio[w]
+        out = append(out,
+            Metric{Name: "hit_ratio", Value: hr.Ratio, Tags: tags},
+            Metric{Name: "hit_ratio.hits", Value: float64(hr.Hits), Tags: tags},
+            Metric{Name: "hit_ratio.misses", Value: float64(hr.Misses), Tags: tags},
+        )
+    }
+
+    for _, name := range sortedKeys(s.Providers) {
+        ps := s.Providers[name]
+        tags := []string{"provider:" + name}
+        out = append(out,
+            Metric{Name: "provider.requests", Value: float64(ps.TotalRequests), Counter: true, Tags: tags},
+            Metric{Name: "provider.success", Value: float64(ps.SuccessCount), Counter: true, Tags: tags},
+            Metric{Name: "provider.failures", Value: float64(ps.FailCount), Counter: true, Tags: tags},
+            Metric{Name: "provider.consecutive_errors", Value: float64(ps.ConsecutiveErr), Tags: tags},
+        )
+        out = appendLatency(out, "provider.latency", ps.Latency, tags)
+    }
+
+    if p := s.Persistence; p != nil {
+        out = append(out,
+            Metric{Name: "persistence.retried", Value: float64(p.RetriedUpdates), Counter: true},
+            Metric{Name: "persistence.dropped", Value: float64(p.DroppedUpdates), Counter: true},
+            Metric{Name: "persistence.drops_per_minute", Value: p.DropsPerMinute},
+        )
+    }
+
+    if s.SLO != nil {
+        out = appendSLO(out, "availability", s.SLO.Availability)
+        out = appendSLO(out, "latency", s.SLO.Latency)
+    }
+
+    if q := s.Queue; q != nil {
+        paused := 0.0
+        if q.QuotaPaused {
+            paused = 1
+        }
+        out = append(out,
+            Metric{Name: "queue.depth", Value: float64(q.QueueDepth)},
+            Metric{Name: "queue.refresh_depth", Value: float64(q.RefreshQueueDepth)},
+            Metric{Name: "queue.capacity", Value: float64(q.QueueCapacity)},
+            Metric{Name: "queue.inflight", Value: float64(q.Inflight)},
+            Metric{Name: "queue.workers", Value: float64(q.Workers)},
+            Metric{Name: "queue.busy_workers", Value: float64(q.BusyWorkers)},
+            Metric{Name: "queue.utilization", Value: q.QueueUtilization},
+            Metric{Name: "queue.worker_utilization", Value: q.WorkerUtilization},
+            Metric{Name: "queue.spilled", Value: float64(q.Spilled)},
+            Metric{Name: "queue.shed_refreshes", Value: float64(q.ShedRefreshes), Counter: true},
+            Metric{Name: "queue.inflight_expired", Value: float64(q.InflightExpired), Counter: true},
+            Metric{Name: "queue.quota_paused", Value: paused},
+        )
+    }
+    return out
+}
+
+func appendLatency(out []Metric, name string, l LatencySummary, tags []string) []Metric {
+    return append(out,
+        Metric{Name: name + ".p50_ms", Value: l.P50Ms, Tags: tags},
+        Metric{Name: name + ".p95_ms", Value: l.P95Ms, Tags: tags},
+        Metric{Name: name + ".p99_ms", Value: l.P99Ms, Tags: tags},
+        Metric{Name: name + ".max_ms", Value: l.MaxMs, Tags: tags},
+    )
+}
+
+func appendSLO(out []Metric, objective string, o *SLOObjective) []Metric {
+    if o == nil {
+        return out
+    }
+    for _, w := range o.Windows {
+        tags := []string{"objective:" + objective, "window:" + w.Window}
+        out = append(out,
+            Metric{Name: "slo.sli", Value: w.SLI, Tags: tags},
+            Metric{Name: "slo.burn_rate", Value: w.BurnRate, Tags: tags},
+        )
+    }
+    return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+    keys := make([]string, 0, len(m))
+    for k := range m {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+    return keys
+}
@@ -0,0 +1,80 @@
+package monitor
+
+import (
+    "net/http"
+    "strconv"
+    "sync/atomic"
+    "time"
+)
+
+// statusCounts 按状态码累计业务端口的响应数 (100-599)，无锁
+type statusCounts [600]atomic.Int64
+
+func (c *statusCounts) add(code int) {
+    if code < 100 || code >= len(c) {
+        return
+    }
+    c[code].Add(1)
+}
+
+// snapshot 只返回出现过的状态码，如 {"200": 10, "202": 3}
+func (c *statusCounts) snapshot() map[string]int64 {
+    out := make(map[string]int64)
+    for code := 100; code < len(c); code++ {
+        if n := c[code].Load(); n > 0 {
+            out[strconv.Itoa(code)] = n
+        }
+    }
+    return out
+}
+
+// StatusCodes 返回业务端口各状态码的累计响应数
+func (m *Monitor) StatusCodes() map[string]int64 {
+    return m.statusCodes.snapshot()
+}
+
+// Middleware 记录业务端口每个请求的端到端耗时与响应状态码
+func (m *Monitor) Middleware(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        start := time.Now()
+        sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+        next.ServeHTTP(sw, r)
+        latency := time.Since(start)
+        m.handlerLatency.Observe(latency)
+        m.statusCodes.add(sw.status)
+        if m.slo != nil {
+            m.slo.observe(sw.status, latency)
+        }
+    })
+}
+
+// statusWriter 记录实际写出的状态码 (未调用 WriteHeader 时为 200)
+type statusWriter struct {
+    http.ResponseWriter
+    status      int
+    wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+    if !w.wroteHeader {
+        w.status = code
+        w.wroteHeader = true
+    }
+    w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+    w.wroteHeader = true
+    return w.ResponseWriter.Write(p)
+}
+
+func (w *statusWriter) Flush() {
+    if f, ok := w.ResponseWriter.(http.Flusher); ok {
+        f.Flush()
+    }
+}
+
+// Unwrap 供 http.ResponseController 访问底层 ResponseWriter
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+    return w.ResponseWriter
+}
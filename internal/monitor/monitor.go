@@ -2,8 +2,10 @@ package monitor
 
 import (
     "encoding/json"
+    "ip-resolver/internal/version"
     "net/http"
     "sync"
+    "sync/atomic"
     "time"
 )
 
@@ -21,71 +23,309 @@ type Monitor struct {
     LastFailIP     string    `json:"last_fail_ip"`     // 导致出错的 IP
     RemainingRequestNum int64 `json:"remaining_request_num"` // 剩余配额
     CacheItemCount int64     `json:"cache_item_count"`
+    CacheCountDrift int64    `json:"cache_count_drift"` // 缓存计数累计校准偏差
+    PanicCount     int64     `json:"panic_count"`      // Worker 处理任务时 recover 的 panic 次数
 
-    quotaFetcher func() int64
+    countersSince time.Time // 累计计数的起始时间，恢复持久化的计数后早于 StartTime
+
+    quotaWarningThreshold int64 // 剩余配额低于该值时 quota_warning 为 true，0 为关闭
+
+    providers map[string]*providerStats // 按提供商名称分别统计
+
+    recentErrors errorRing // 最近的上游失败与 panic，避免只有 LastError 时被覆盖
+
+    providerLatency Histogram // 上游调用耗时 (所有提供商)
+    handlerLatency  Histogram // 业务端口请求的端到端耗时
+    statusCodes     statusCounts // 业务端口按状态码的响应数
+    slo             *sloTracker  // 可选，业务端口的 SLO 燃烧率
+    slowCalls       *slowCallStore // 可选，耗时超过阈值的上游调用
+
+    quotaUpdatedAt time.Time // 最近一次成功刷新剩余配额的时间
     cacheFetcher func() int64
+    driftFetcher func() int64
+    queueFetcher func() QueueStats
+    workerFetcher func() []WorkerStats
+    hitRatioFetcher func() map[string]HitRatio
+    persistenceFetcher func() PersistenceStats
+}
+
+// QueueStats 解析队列与处理中任务的状态
+type QueueStats struct {
+    QueueDepth        int   `json:"queue_depth"`         // 首次查询队列长度
+    RefreshQueueDepth int   `json:"refresh_queue_depth"` // 后台刷新队列长度
+    QueueCapacity     int   `json:"queue_capacity"`      // 单个队列容量
+    Inflight          int   `json:"inflight"`            // 排队或解析中的子网数
+    Workers           int   `json:"workers"`
+    BusyWorkers       int   `json:"busy_workers"`        // 正在处理任务的 Worker 数
+    QueueUtilization  float64 `json:"queue_utilization"`  // 首次查询队列使用率 (0-1)，接近 1 时新请求将被拒绝
+    WorkerUtilization float64 `json:"worker_utilization"` // busy_workers / workers (0-1)
+    ShedRefreshes     int64 `json:"shed_refreshes"`      // 因积压而跳过的预刷新次数
+    InflightExpired   int64 `json:"inflight_expired"`    // 超时被清除的处理中标记数 (非 0 说明存在泄漏)
+    ConcurrencyLimit  int   `json:"concurrency_limit,omitempty"` // 自适应并发当前上限 (未启用时省略)
+    Spilled           int   `json:"spilled"`             // 溢出日志中等待送回队列的任务数
+    QuotaPaused       bool  `json:"quota_paused"`        // 配额耗尽，上游请求已暂停
+}
+
+// PersistenceStats 缓存持久化 (SQLite 写入) 的压力指标
+type PersistenceStats struct {
+    RetriedUpdates int64   `json:"retried_updates"`  // 缓冲区满后重试写入的更新数
+    DroppedUpdates int64   `json:"dropped_updates"`  // 重试后仍丢弃的更新数 (重启后丢失)
+    DropsPerMinute float64 `json:"drops_per_minute"` // 最近 1 分钟的丢弃速率
+}
+
+// HitRatio 滚动窗口内的缓存命中率 (命中含预刷新)，未命中即消耗上游配额，可据此预估配额用量
+type HitRatio struct {
+    Hits   int64   `json:"hits"`
+    Misses int64   `json:"misses"`
+    Ratio  float64 `json:"ratio"`
+}
+
+// ProviderStats 单个提供商的调用统计，多提供商 (IPv6 / 指定提供商查询) 时可分别观察
+type ProviderStats struct {
+    TotalRequests  int64     `json:"total_requests"`
+    SuccessCount   int64     `json:"success_count"`
+    FailCount      int64     `json:"fail_count"`
+    ConsecutiveErr int64     `json:"consecutive_err"`
+    AvgLatencyMs   float64   `json:"avg_latency_ms"`
+    LastError      string    `json:"last_error,omitempty"`
+    LastErrorTime  time.Time `json:"last_error_time"`
+    LastFailIP     string    `json:"last_fail_ip,omitempty"`
+    Latency        LatencySummary `json:"latency"`
+}
+
+type providerStats struct {
+    ProviderStats
+    totalLatency time.Duration
+    latency      Histogram
+    reported     atomic.Bool // 本轮连续失败是否已上报，成功后重置
+}
+
+// LatencyStats 耗时分布，替代单一的平均值 / 健康与否判断
+type LatencyStats struct {
+    Provider LatencySummary `json:"provider"` // 上游调用
+    Handler  LatencySummary `json:"handler"`  // 业务端口端到端 (含缓存命中)
+}
+
+// WorkerStats 单个 Worker 的统计，用于发现卡住的 Worker 或负载倾斜
+type WorkerStats struct {
+    ID           int     `json:"id"`
+    Processed    int64   `json:"processed"`
+    Fetched      int64   `json:"fetched"`        // 调用上游次数
+    Errors       int64   `json:"errors"`
+    AvgLatencyMs float64 `json:"avg_latency_ms"` // 上游调用平均耗时
+    BusyMs       int64   `json:"busy_ms"`        // 当前任务已持续的时间，0 为空闲
+    CurrentIP    string  `json:"current_ip,omitempty"`
 }
 
 func New() *Monitor {
+    now := time.Now()
     return &Monitor{
-        StartTime:           time.Now(),
+        StartTime:           now,
+        countersSince:       now,
         RemainingRequestNum: -1,
         CacheItemCount:      0,
+        providers:           make(map[string]*providerStats),
+        recentErrors:        newErrorRing(DefaultErrorHistorySize),
     }
 }
 
+// provider 返回提供商的统计项，不存在时创建 (调用方需持有写锁)
+func (m *Monitor) provider(name string) *providerStats {
+    ps, ok := m.providers[name]
+    if !ok {
+        ps = &providerStats{}
+        m.providers[name] = ps
+    }
+    return ps
+}
+
 func (m *Monitor) SetCacheFetcher(f func() int64) {
     m.mu.Lock()
     m.cacheFetcher = f
     m.mu.Unlock()
 }
 
-func (m *Monitor) SetQuotaFetcher(f func() int64) {
+func (m *Monitor) SetDriftFetcher(f func() int64) {
     m.mu.Lock()
-    m.quotaFetcher = f
+    m.driftFetcher = f
     m.mu.Unlock()
 }
 
-// RecordSuccess 记录一次成功
-func (m *Monitor) RecordSuccess() {
+// SetQuotaWarningThreshold 设置剩余配额预警阈值
+func (m *Monitor) SetQuotaWarningThreshold(n int64) {
+    m.mu.Lock()
+    m.quotaWarningThreshold = n
+    m.mu.Unlock()
+}
+
+func (m *Monitor) SetQueueFetcher(f func() QueueStats) {
+    m.mu.Lock()
+    m.queueFetcher = f
+    m.mu.Unlock()
+}
+
+func (m *Monitor) SetWorkerFetcher(f func() []WorkerStats) {
+    m.mu.Lock()
+    m.workerFetcher = f
+    m.mu.Unlock()
+}
+
+func (m *Monitor) SetHitRatioFetcher(f func() map[string]HitRatio) {
+    m.mu.Lock()
+    m.hitRatioFetcher = f
+    m.mu.Unlock()
+}
+
+func (m *Monitor) SetPersistenceFetcher(f func() PersistenceStats) {
+    m.mu.Lock()
+    m.persistenceFetcher = f
+    m.mu.Unlock()
+}
+
+// RecordSuccess 记录提供商的一次成功调用
+func (m *Monitor) RecordSuccess(provider string, latency time.Duration) {
     m.mu.Lock()
     defer m.mu.Unlock()
     m.TotalRequests++
     m.SuccessCount++
     m.ConsecutiveErr = 0 // 重置连续失败计数
+
+    ps := m.provider(provider)
+    ps.TotalRequests++
+    ps.SuccessCount++
+    ps.ConsecutiveErr = 0
+    ps.reported.Store(false)
+    ps.totalLatency += latency
+    ps.latency.Observe(latency)
+    m.providerLatency.Observe(latency)
 }
 
-// RecordFailure 记录一次失败
-func (m *Monitor) RecordFailure(ip string, errMsg string) {
+// RecordFailure 记录提供商的一次失败调用
+func (m *Monitor) RecordFailure(provider, ip, errMsg string, latency time.Duration) {
     m.mu.Lock()
     defer m.mu.Unlock()
+    now := time.Now()
     m.TotalRequests++
     m.FailCount++
     m.ConsecutiveErr++
     
     m.LastError = errMsg
     m.LastFailIP = ip
+    m.LastErrorTime = now
+
+    ps := m.provider(provider)
+    ps.TotalRequests++
+    ps.FailCount++
+    ps.ConsecutiveErr++
+    ps.totalLatency += latency
+    ps.latency.Observe(latency)
+    m.providerLatency.Observe(latency)
+    ps.LastError = errMsg
+    ps.LastFailIP = ip
+    ps.LastErrorTime = now
+
+    m.recentErrors.add(ErrorEntry{Time: now, IP: ip, Provider: provider, Message: errMsg})
+}
+
+// ConsecutiveErrors 返回上游连续失败次数与最近一次错误
+func (m *Monitor) ConsecutiveErrors() (int64, string) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    return m.ConsecutiveErr, m.LastError
+}
+
+// ProviderConsecutiveErrors 返回指定提供商的连续失败次数
+func (m *Monitor) ProviderConsecutiveErrors(provider string) int64 {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    if ps, ok := m.providers[provider]; ok {
+        return ps.ConsecutiveErr
+    }
+    return 0
+}
+
+// ClaimFailureReport 提供商连续失败达到 threshold 且本轮尚未上报时返回 true 与当前次数，
+// 并发调用中只有一方成功，直到下一次成功调用后才可再次上报
+func (m *Monitor) ClaimFailureReport(provider string, threshold int64) (int64, bool) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    ps, ok := m.providers[provider]
+    if !ok || ps.ConsecutiveErr < threshold {
+        return 0, false
+    }
+    return ps.ConsecutiveErr, ps.reported.CompareAndSwap(false, true)
+}
+
+// ProviderStats 返回各提供商统计的快照
+func (m *Monitor) ProviderStats() map[string]ProviderStats {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    out := make(map[string]ProviderStats, len(m.providers))
+    for name, ps := range m.providers {
+        s := ps.ProviderStats
+        if s.TotalRequests > 0 {
+            s.AvgLatencyMs = float64(ps.totalLatency.Microseconds()) / 1000 / float64(s.TotalRequests)
+        }
+        s.Latency = ps.latency.Summary()
+        out[name] = s
+    }
+    return out
+}
+
+// RecordPanic 记录一次 Worker panic (已 recover)
+func (m *Monitor) RecordPanic(ip string, msg string) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.PanicCount++
+
+    m.LastError = "panic: " + msg
+    m.LastFailIP = ip
     m.LastErrorTime = time.Now()
+
+    m.recentErrors.add(ErrorEntry{Time: m.LastErrorTime, IP: ip, Message: m.LastError})
 }
 
 // HandleStatus HTTP 接口处理函数
-func (m *Monitor) HandleStatus(w http.ResponseWriter, r *http.Request) {
+// Snapshot /status 中 data 字段的内容，也供 StatsD 等推送式导出使用
+type Snapshot struct {
+    StartTime      time.Time `json:"start_time"`
+    CountersSince  time.Time `json:"counters_since"` // 累计计数的起始时间 (持久化计数时跨越重启)
+    TotalRequests  int64     `json:"total_requests"`
+    SuccessCount   int64     `json:"success_count"`
+    FailCount      int64     `json:"fail_count"`
+    ConsecutiveErr int64     `json:"consecutive_err"`
+    LastError      string    `json:"last_error"`
+    LastErrorTime  time.Time `json:"last_error_time"`
+    LastFailIP     string    `json:"last_fail_ip"`
+    RemainingRequestNum int64 `json:"remaining_request_num"`
+    QuotaUpdatedAt time.Time `json:"quota_updated_at"`
+    QuotaWarning   bool      `json:"quota_warning"` // 剩余配额低于预警阈值
+    CacheItemCount int64     `json:"cache_item_count"`
+    CacheCountDrift int64    `json:"cache_count_drift"`
+    PanicCount     int64     `json:"panic_count"`
+    HitRatio       map[string]HitRatio `json:"hit_ratio,omitempty"`
+    Latency        LatencyStats `json:"latency"`
+    Runtime        RuntimeStats `json:"runtime"`
+    StatusCodes    map[string]int64 `json:"status_codes"`
+    Providers      map[string]ProviderStats `json:"providers,omitempty"`
+    Queue          *QueueStats `json:"queue,omitempty"`
+    Persistence    *PersistenceStats `json:"persistence,omitempty"`
+    SLO            *SLOStatus `json:"slo,omitempty"`
+    Workers        []WorkerStats `json:"workers,omitempty"`
+}
+
+// Snapshot 调用各 fetcher 并返回当前统计
+func (m *Monitor) Snapshot() Snapshot {
     // 1. 安全读取并调用 fetchers
     m.mu.RLock()
-    quotaFetcher := m.quotaFetcher
     cacheFetcher := m.cacheFetcher
+    driftFetcher := m.driftFetcher
+    queueFetcher := m.queueFetcher
+    workerFetcher := m.workerFetcher
+    hitRatioFetcher := m.hitRatioFetcher
+    persistenceFetcher := m.persistenceFetcher
     m.mu.RUnlock()
 
-    // 更新配额 (Quota)
-    if quotaFetcher != nil {
-        newQuota := quotaFetcher()
-        if newQuota >= 0 {
-            m.mu.Lock()
-            m.RemainingRequestNum = newQuota
-            m.mu.Unlock()
-        }
-    }
-
     if cacheFetcher != nil {
         count := cacheFetcher()
         m.mu.Lock()
@@ -93,23 +333,42 @@ func (m *Monitor) HandleStatus(w http.ResponseWriter, r *http.Request) {
         m.mu.Unlock()
     }
 
-    type monitorSnapshot struct {
-        StartTime      time.Time `json:"start_time"`
-        TotalRequests  int64     `json:"total_requests"`
-        SuccessCount   int64     `json:"success_count"`
-        FailCount      int64     `json:"fail_count"`
-        ConsecutiveErr int64     `json:"consecutive_err"`
-        LastError      string    `json:"last_error"`
-        LastErrorTime  time.Time `json:"last_error_time"`
-        LastFailIP     string    `json:"last_fail_ip"`
-        RemainingRequestNum int64 `json:"remaining_request_num"`
-        CacheItemCount int64     `json:"cache_item_count"`
+    if driftFetcher != nil {
+        drift := driftFetcher()
+        m.mu.Lock()
+        m.CacheCountDrift = drift
+        m.mu.Unlock()
     }
 
-    var snap monitorSnapshot
+    var snap Snapshot
+    if queueFetcher != nil {
+        qs := queueFetcher()
+        snap.Queue = &qs
+    }
+    if workerFetcher != nil {
+        snap.Workers = workerFetcher()
+    }
+    if hitRatioFetcher != nil {
+        snap.HitRatio = hitRatioFetcher()
+    }
+    if persistenceFetcher != nil {
+        ps := persistenceFetcher()
+        snap.Persistence = &ps
+    }
+    snap.Providers = m.ProviderStats()
+    snap.StatusCodes = m.StatusCodes()
+    if m.slo != nil {
+        snap.SLO = m.slo.status()
+    }
+    snap.Runtime = readRuntimeStats()
+    snap.Latency = LatencyStats{
+        Provider: m.providerLatency.Summary(),
+        Handler:  m.handlerLatency.Summary(),
+    }
 
     m.mu.RLock()
     snap.StartTime = m.StartTime
+    snap.CountersSince = m.countersSince
     snap.TotalRequests = m.TotalRequests
     snap.SuccessCount = m.SuccessCount
     snap.FailCount = m.FailCount
@@ -118,17 +377,31 @@ func (m *Monitor) HandleStatus(w http.ResponseWriter, r *http.Request) {
     snap.LastErrorTime = m.LastErrorTime
     snap.LastFailIP = m.LastFailIP
     snap.RemainingRequestNum = m.RemainingRequestNum
+    snap.QuotaUpdatedAt = m.quotaUpdatedAt
+    snap.QuotaWarning = m.quotaWarningThreshold > 0 && m.RemainingRequestNum >= 0 && m.RemainingRequestNum < m.quotaWarningThreshold
     snap.CacheItemCount = m.CacheItemCount
+    snap.CacheCountDrift = m.CacheCountDrift
+    snap.PanicCount = m.PanicCount
     m.mu.RUnlock()
+    return snap
+}
 
-    status := struct {
-        Healthy     bool             `json:"healthy"`
-        Uptime      string           `json:"uptime"`
-        MonitorData *monitorSnapshot `json:"data"`
-    }{
-        Healthy:     snap.ConsecutiveErr < 3,
-        Uptime:      time.Since(snap.StartTime).String(),
-        MonitorData: &snap,
+// Status /status 的返回，pkg/client 直接复用
+type Status struct {
+    Healthy bool         `json:"healthy"`
+    Uptime  string       `json:"uptime"`
+    Build   version.Info `json:"build"`
+    Data    Snapshot     `json:"data"`
+}
+
+func (m *Monitor) HandleStatus(w http.ResponseWriter, r *http.Request) {
+    snap := m.Snapshot()
+
+    status := Status{
+        Healthy: snap.ConsecutiveErr < 3,
+        Uptime:  time.Since(snap.StartTime).String(),
+        Build:   version.Get(),
+        Data:    snap,
     }
 
     w.Header().Set("Content-Type", "application/json")
@@ -139,4 +412,4 @@ func (m *Monitor) HandleStatus(w http.ResponseWriter, r *http.Request) {
     }
 
     json.NewEncoder(w).Encode(status)
-}
\ No newline at end of file
+}
@@ -0,0 +1,81 @@
+package monitor
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClaimFailureReport(t *testing.T) {
+	m := New()
+	for i := 0; i < 2; i++ {
+		m.RecordFailure("p", "1.1.1.1", "timeout", 0)
+	}
+	if _, ok := m.ClaimFailureReport("p", 3); ok {
+		t.Fatal("claimed below threshold")
+	}
+
+	// 并发失败越过阈值时只有一方上报
+	var wg sync.WaitGroup
+	var claimed atomic.Int32
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.RecordFailure("p", "1.1.1.1", "timeout", 0)
+			if _, ok := m.ClaimFailureReport("p", 3); ok {
+				claimed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := claimed.Load(); n != 1 {
+		t.Fatalf("claimed %d times, want 1", n)
+	}
+
+	// 成功后重置，下一轮连续失败可再次上报
+	m.RecordSuccess("p", 0)
+	for i := 0; i < 3; i++ {
+		m.RecordFailure("p", "1.1.1.1", "timeout", 0)
+	}
+	if n, ok := m.ClaimFailureReport("p", 3); !ok || n != 3 {
+		t.Fatalf("claim after recovery = %d, %v", n, ok)
+	}
+	if _, ok := m.ClaimFailureReport("other", 1); ok {
+		t.Fatal("claimed for unknown provider")
+	}
+}
+
+func TestSLOObserve(t *testing.T) {
+	m := New()
+	m.SetSLO(SLOConfig{AvailabilityTarget: 0.99, LatencyTarget: 0.9, LatencyThreshold: 100 * time.Millisecond})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 25; n++ {
+				status, latency := 200, 10*time.Millisecond
+				if n == 0 {
+					status = 503
+				}
+				if n < 5 {
+					latency = time.Second
+				}
+				m.slo.observe(status, latency)
+			}
+		}()
+	}
+	wg.Wait()
+
+	st := m.slo.status()
+	avail, lat := st.Availability.Windows[0], st.Latency.Windows[0]
+	if avail.Requests != 100 || avail.Bad != 4 || lat.Bad != 20 {
+		t.Fatalf("availability = %+v, latency = %+v", avail, lat)
+	}
+	if avail.BurnRate != 4 || lat.BurnRate != 2 {
+		t.Fatalf("burn rates = %v / %v, want 4 / 2", avail.BurnRate, lat.BurnRate)
+	}
+}
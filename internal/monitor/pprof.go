@@ -0,0 +1,23 @@
+package monitor
+
+import (
+    "net/http"
+    "net/http/pprof"
+    "time"
+)
+
+// RegisterPprof 在 mux 上挂载 /debug/pprof/，wrap 负责鉴权
+// 采样类接口 (profile / trace) 会持续 seconds 秒，因此取消监控端口的写超时
+func RegisterPprof(mux *http.ServeMux, wrap func(http.HandlerFunc) http.HandlerFunc) {
+    handle := func(pattern string, h http.HandlerFunc) {
+        mux.HandleFunc(pattern, wrap(func(w http.ResponseWriter, r *http.Request) {
+            _ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+            h(w, r)
+        }))
+    }
+    handle("/debug/pprof/", pprof.Index)
+    handle("/debug/pprof/cmdline", pprof.Cmdline)
+    handle("/debug/pprof/profile", pprof.Profile)
+    handle("/debug/pprof/symbol", pprof.Symbol)
+    handle("/debug/pprof/trace", pprof.Trace)
+}
@@ -0,0 +1,99 @@
+package monitor
+
+import (
+    "encoding/json"
+    "net/http"
+    "sync"
+    "sync/atomic"
+)
+
+// Probes Kubernetes 风格的探针，与 /status 分开:
+//   - /livez    进程存活 (能响应即 200)，失败应重启进程
+//   - /startupz 启动完成 (缓存已加载、后台任务与监听已就绪)
+//   - /readyz   可接收流量 (启动完成、未在关闭、各就绪检查通过)，失败只应摘除流量
+type Probes struct {
+    started  atomic.Bool
+    draining atomic.Bool
+
+    mu     sync.RWMutex
+    checks []readyCheck
+}
+
+type readyCheck struct {
+    name  string
+    check func() error
+}
+
+type probeResult struct {
+    Status string            `json:"status"` // ok / fail
+    Checks map[string]string `json:"checks,omitempty"`
+}
+
+func NewProbes() *Probes {
+    return &Probes{}
+}
+
+// AddReadyCheck 注册就绪检查，返回非 nil 错误表示未就绪
+func (p *Probes) AddReadyCheck(name string, check func() error) {
+    p.mu.Lock()
+    p.checks = append(p.checks, readyCheck{name: name, check: check})
+    p.mu.Unlock()
+}
+
+// SetStarted 标记启动完成
+func (p *Probes) SetStarted() {
+    p.started.Store(true)
+}
+
+// SetDraining 标记进入关闭流程，readyz 随即失败以便摘除流量
+func (p *Probes) SetDraining() {
+    p.draining.Store(true)
+}
+
+func (p *Probes) HandleLive(w http.ResponseWriter, r *http.Request) {
+    writeProbe(w, probeResult{Status: "ok"})
+}
+
+func (p *Probes) HandleStartup(w http.ResponseWriter, r *http.Request) {
+    if !p.started.Load() {
+        writeProbe(w, probeResult{Status: "fail", Checks: map[string]string{"startup": "starting"}})
+        return
+    }
+    writeProbe(w, probeResult{Status: "ok"})
+}
+
+func (p *Probes) HandleReady(w http.ResponseWriter, r *http.Request) {
+    res := probeResult{Status: "ok", Checks: make(map[string]string)}
+    fail := func(name, msg string) {
+        res.Status = "fail"
+        res.Checks[name] = msg
+    }
+
+    if !p.started.Load() {
+        fail("startup", "starting")
+    }
+    if p.draining.Load() {
+        fail("shutdown", "draining")
+    }
+
+    p.mu.RLock()
+    checks := p.checks
+    p.mu.RUnlock()
+    for _, c := range checks {
+        if err := c.check(); err != nil {
+            fail(c.name, err.Error())
+        } else {
+            res.Checks[c.name] = "ok"
+        }
+    }
+    writeProbe(w, res)
+}
+
+func writeProbe(w http.ResponseWriter, res probeResult) {
+    w.Header().Set("Content-Type", "application/json")
+    w.Header().Set("Cache-Control", "no-store")
+    if res.Status != "ok" {
+        w.WriteHeader(http.StatusServiceUnavailable)
+    }
+    json.NewEncoder(w).Encode(res)
+}
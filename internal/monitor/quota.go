@@ -0,0 +1,12 @@
+package monitor
+
+import "time"
+
+// SetRemainingQuota 记录后台配额检查查询到的剩余配额 (由 Worker 的配额检查调用)
+// /status 只读取该值，配额接口变慢时不会拖慢状态查询与健康检查
+func (m *Monitor) SetRemainingQuota(remaining int64) {
+    m.mu.Lock()
+    m.RemainingRequestNum = remaining
+    m.quotaUpdatedAt = time.Now()
+    m.mu.Unlock()
+}
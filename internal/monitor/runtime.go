@@ -0,0 +1,66 @@
+package monitor
+
+import (
+    "math"
+    "os"
+    "runtime"
+    "syscall"
+    "time"
+)
+
+// RuntimeStats 进程运行时状态，用于在不挂载 pprof 的情况下发现协程 / 内存 / FD 泄漏
+type RuntimeStats struct {
+    Goroutines       int       `json:"goroutines"`
+    HeapAllocMB      float64   `json:"heap_alloc_mb"` // 堆上存活对象
+    HeapInuseMB      float64   `json:"heap_inuse_mb"`
+    HeapObjects      uint64    `json:"heap_objects"`
+    SysMB            float64   `json:"sys_mb"` // 向操作系统申请的内存总量
+    NumGC            uint32    `json:"num_gc"`
+    LastGC           time.Time `json:"last_gc"`
+    LastPauseMs      float64   `json:"last_gc_pause_ms"`
+    MaxRecentPauseMs float64   `json:"max_recent_gc_pause_ms"` // 最近 256 次 GC 中最长的暂停
+    TotalPauseMs     float64   `json:"total_gc_pause_ms"`
+    GCCPUFraction    float64   `json:"gc_cpu_fraction"`
+    OpenFDs          int       `json:"open_fds"`          // -1 为无法获取 (非 Linux)
+    MaxFDs           uint64    `json:"max_fds,omitempty"` // RLIMIT_NOFILE 软限制
+}
+
+// readRuntimeStats ReadMemStats 会短暂 STW，只在请求 /status 或推送指标时调用
+func readRuntimeStats() RuntimeStats {
+    var ms runtime.MemStats
+    runtime.ReadMemStats(&ms)
+
+    s := RuntimeStats{
+        Goroutines:    runtime.NumGoroutine(),
+        HeapAllocMB:   toMB(ms.HeapAlloc),
+        HeapInuseMB:   toMB(ms.HeapInuse),
+        HeapObjects:   ms.HeapObjects,
+        SysMB:         toMB(ms.Sys),
+        NumGC:         ms.NumGC,
+        TotalPauseMs:  roundMs(float64(ms.PauseTotalNs) / 1e6),
+        GCCPUFraction: ms.GCCPUFraction,
+        OpenFDs:       -1,
+    }
+    if ms.NumGC > 0 {
+        s.LastGC = time.Unix(0, int64(ms.LastGC))
+        s.LastPauseMs = roundMs(float64(ms.PauseNs[(ms.NumGC+255)%256]) / 1e6)
+        var longest uint64
+        for _, p := range ms.PauseNs[:min(int(ms.NumGC), len(ms.PauseNs))] {
+            longest = max(longest, p)
+        }
+        s.MaxRecentPauseMs = roundMs(float64(longest) / 1e6)
+    }
+
+    if entries, err := os.ReadDir("/proc/self/fd"); err == nil {
+        s.OpenFDs = len(entries)
+    }
+    var rl syscall.Rlimit
+    if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rl); err == nil {
+        s.MaxFDs = rl.Cur
+    }
+    return s
+}
+
+func toMB(b uint64) float64 {
+    return math.Round(float64(b)/(1<<20)*100) / 100
+}
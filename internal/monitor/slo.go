@@ -0,0 +1,150 @@
+package monitor
+
+import (
+    "math"
+    "sync/atomic"
+    "time"
+)
+
+// ======== 硬编码参数 =========
+// sloWindows 多窗口燃烧率告警常用的窗口 (如 1h + 5m 超过 14.4、6h + 30m 超过 6)
+var sloWindows = []struct {
+    name string
+    d    time.Duration
+}{
+    {"5m", 5 * time.Minute},
+    {"30m", 30 * time.Minute},
+    {"1h", time.Hour},
+    {"2h", 2 * time.Hour},
+    {"6h", 6 * time.Hour},
+    {"1d", 24 * time.Hour},
+    {"3d", 72 * time.Hour},
+}
+
+const sloSlots = 3 * 24 * 60 // 按分钟分槽，覆盖最长的 3d 窗口
+
+// SLOConfig 业务端口的服务等级目标，目标为 0 时不计算对应指标
+type SLOConfig struct {
+    AvailabilityTarget float64       // 非 5xx 响应占比目标，如 0.999
+    LatencyTarget      float64       // 耗时不超过 LatencyThreshold 的请求占比目标，如 0.99
+    LatencyThreshold   time.Duration
+}
+
+// SLOWindow 单个窗口内的达成情况
+// BurnRate 为错误预算消耗速度: 1 表示恰好在目标周期内耗尽预算，14.4 表示 1h 消耗 30 天预算的 2%
+type SLOWindow struct {
+    Window   string  `json:"window"`
+    Requests int64   `json:"requests"`
+    Bad      int64   `json:"bad"`
+    SLI      float64 `json:"sli"` // 达标请求占比，窗口内无请求时为 1
+    BurnRate float64 `json:"burn_rate"`
+}
+
+// SLOObjective 单个目标在各窗口的燃烧率
+type SLOObjective struct {
+    Target      float64     `json:"target"`
+    ThresholdMs float64     `json:"threshold_ms,omitempty"` // 仅延迟目标
+    Windows     []SLOWindow `json:"windows"`
+}
+
+// SLOStatus /status 中的 SLO 部分
+type SLOStatus struct {
+    Availability *SLOObjective `json:"availability,omitempty"`
+    Latency      *SLOObjective `json:"latency,omitempty"`
+}
+
+// sloSlot 一分钟内的请求数、5xx 数与超过延迟阈值的请求数
+type sloSlot struct {
+    min   atomic.Int64
+    total atomic.Int64
+    errs  atomic.Int64
+    slow  atomic.Int64
+}
+
+// sloTracker 每个业务请求都会调用 observe，槽位无锁更新 (与 Histogram 相同)
+type sloTracker struct {
+    cfg SLOConfig
+
+    slots []sloSlot
+}
+
+// SetSLO 启用 SLO 计算，需在业务端口开始服务前调用
+func (m *Monitor) SetSLO(cfg SLOConfig) {
+    if cfg.AvailabilityTarget <= 0 && cfg.LatencyTarget <= 0 {
+        return
+    }
+    // 配置为百分比，换算后去掉浮点误差 (99.9 / 100 = 0.9990000000000001)
+    cfg.AvailabilityTarget = math.Round(cfg.AvailabilityTarget*1e6) / 1e6
+    cfg.LatencyTarget = math.Round(cfg.LatencyTarget*1e6) / 1e6
+    m.slo = &sloTracker{cfg: cfg, slots: make([]sloSlot, sloSlots)}
+}
+
+func (t *sloTracker) observe(status int, latency time.Duration) {
+    minute := time.Now().Unix() / 60
+    s := &t.slots[minute%int64(len(t.slots))]
+    if old := s.min.Load(); old != minute && s.min.CompareAndSwap(old, minute) {
+        // 槽位上一次使用已是 3 天前，由换分钟的一方清零
+        s.total.Store(0)
+        s.errs.Store(0)
+        s.slow.Store(0)
+    }
+    s.total.Add(1)
+    if status >= 500 {
+        s.errs.Add(1)
+    }
+    if t.cfg.LatencyThreshold > 0 && latency > t.cfg.LatencyThreshold {
+        s.slow.Add(1)
+    }
+}
+
+func (t *sloTracker) status() *SLOStatus {
+    now := time.Now().Unix() / 60
+    type sums struct{ total, errs, slow int64 }
+    acc := make([]sums, len(sloWindows))
+
+    for i := range t.slots {
+        s := &t.slots[i]
+        total := s.total.Load()
+        if total == 0 {
+            continue
+        }
+        age := time.Duration(now-s.min.Load()) * time.Minute
+        errs, slow := s.errs.Load(), s.slow.Load()
+        for i, w := range sloWindows {
+            if age < w.d {
+                acc[i].total += total
+                acc[i].errs += errs
+                acc[i].slow += slow
+            }
+        }
+    }
+
+    st := &SLOStatus{}
+    if target := t.cfg.AvailabilityTarget; target > 0 {
+        st.Availability = &SLOObjective{Target: target}
+        for i, w := range sloWindows {
+            st.Availability.Windows = append(st.Availability.Windows, sloWindow(w.name, acc[i].total, acc[i].errs, target))
+        }
+    }
+    if target := t.cfg.LatencyTarget; target > 0 {
+        st.Latency = &SLOObjective{Target: target, ThresholdMs: float64(t.cfg.LatencyThreshold.Milliseconds())}
+        for i, w := range sloWindows {
+            st.Latency.Windows = append(st.Latency.Windows, sloWindow(w.name, acc[i].total, acc[i].slow, target))
+        }
+    }
+    return st
+}
+
+func sloWindow(name string, total, bad int64, target float64) SLOWindow {
+    w := SLOWindow{Window: name, Requests: total, Bad: bad, SLI: 1}
+    if total > 0 {
+        errRatio := float64(bad) / float64(total)
+        w.SLI = roundRatio(1 - errRatio)
+        w.BurnRate = roundRatio(errRatio / (1 - target))
+    }
+    return w
+}
+
+func roundRatio(v float64) float64 {
+    return math.Round(v*1e4) / 1e4
+}
@@ -0,0 +1,158 @@
+package monitor
+
+import (
+    "encoding/json"
+    "net/http"
+    "strconv"
+    "sync"
+    "time"
+)
+
+// ======== 硬编码参数 =========
+const maxSlowCallBody = 16 << 10 // 单条记录保存的响应体上限
+
+// SlowCall 一次耗时超过阈值的上游调用，用于排查偶发的上游变慢
+type SlowCall struct {
+    Time          time.Time         `json:"time"`
+    Provider      string            `json:"provider"`
+    Method        string            `json:"method"`
+    URL           string            `json:"url"`
+    Query         map[string]string `json:"query,omitempty"`
+    Form          map[string]string `json:"form,omitempty"`           // 表单参数 (POST)
+    Headers       map[string]string `json:"headers,omitempty"`        // 不含鉴权头
+    RequestID     string            `json:"request_id,omitempty"`
+    JobID         string            `json:"job_id,omitempty"`
+    LatencyMs     float64           `json:"latency_ms"`
+    StatusCode    int               `json:"status_code,omitempty"`    // 0 为未收到响应
+    Error         string            `json:"error,omitempty"`
+    Body          string            `json:"body,omitempty"`           // 仅 capture_body 开启时
+    BodyTruncated bool              `json:"body_truncated,omitempty"`
+}
+
+// slowCallStore 固定容量的慢调用记录，写满后覆盖最早的记录
+type slowCallStore struct {
+    threshold   time.Duration
+    captureBody bool
+
+    mu    sync.Mutex
+    buf   []SlowCall
+    next  int
+    full  bool
+    total int64
+}
+
+// SetSlowCallCapture 启用慢调用记录: 上游调用耗时超过 threshold 时保存请求参数 (及响应体)，最多保留 size 条
+func (m *Monitor) SetSlowCallCapture(threshold time.Duration, size int, captureBody bool) {
+    if threshold <= 0 || size <= 0 {
+        return
+    }
+    m.slowCalls = &slowCallStore{threshold: threshold, captureBody: captureBody, buf: make([]SlowCall, size)}
+}
+
+// SlowCallThreshold 返回慢调用阈值，未启用时为 0
+func (m *Monitor) SlowCallThreshold() time.Duration {
+    if m == nil || m.slowCalls == nil {
+        return 0
+    }
+    return m.slowCalls.threshold
+}
+
+// CaptureSlowCallBody 是否需要保存响应体
+func (m *Monitor) CaptureSlowCallBody() bool {
+    return m != nil && m.slowCalls != nil && m.slowCalls.captureBody
+}
+
+// RecordSlowCall 保存一条慢调用，响应体超过上限时截断
+func (m *Monitor) RecordSlowCall(c SlowCall) {
+    if m == nil || m.slowCalls == nil {
+        return
+    }
+    s := m.slowCalls
+    if !s.captureBody {
+        c.Body = ""
+    } else if len(c.Body) > maxSlowCallBody {
+        c.Body = c.Body[:maxSlowCallBody]
+        c.BodyTruncated = true
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.total++
+    s.buf[s.next] = c
+    s.next = (s.next + 1) % len(s.buf)
+    if s.next == 0 {
+        s.full = true
+    }
+}
+
+// list 最新的在前
+func (s *slowCallStore) list() []SlowCall {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    n := s.next
+    if s.full {
+        n = len(s.buf)
+    }
+    out := make([]SlowCall, 0, n)
+    for i := 1; i <= n; i++ {
+        out = append(out, s.buf[(s.next-i+len(s.buf))%len(s.buf)])
+    }
+    return out
+}
+
+func (s *slowCallStore) clear() int {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    n := s.next
+    if s.full {
+        n = len(s.buf)
+    }
+    clear(s.buf)
+    s.next, s.full = 0, false
+    return n
+}
+
+// HandleSlowCalls /admin/slowcalls
+// GET 返回最近的慢调用 (最新的在前)，支持 ?provider= 筛选与 ?limit= 限制条数；DELETE 清空记录
+func (m *Monitor) HandleSlowCalls(w http.ResponseWriter, r *http.Request) {
+    s := m.slowCalls
+    if s == nil {
+        http.Error(w, "slow call capture is disabled (slow_call.threshold_ms)", http.StatusNotFound)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    switch r.Method {
+    case http.MethodGet:
+        calls := s.list()
+        if p := r.URL.Query().Get("provider"); p != "" {
+            filtered := calls[:0]
+            for _, c := range calls {
+                if c.Provider == p {
+                    filtered = append(filtered, c)
+                }
+            }
+            calls = filtered
+        }
+        if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit >= 0 && limit < len(calls) {
+            calls = calls[:limit]
+        }
+
+        s.mu.Lock()
+        total := s.total
+        s.mu.Unlock()
+        json.NewEncoder(w).Encode(struct {
+            ThresholdMs float64    `json:"threshold_ms"`
+            Total       int64      `json:"total"`    // 自启动起的慢调用总数 (含已被覆盖的)
+            Capacity    int        `json:"capacity"` // 保留条数上限
+            Calls       []SlowCall `json:"calls"`
+        }{float64(s.threshold.Milliseconds()), total, len(s.buf), calls})
+
+    case http.MethodDelete:
+        json.NewEncoder(w).Encode(map[string]int{"removed": s.clear()})
+
+    default:
+        w.Header().Set("Allow", "GET, DELETE")
+        w.WriteHeader(http.StatusMethodNotAllowed)
+    }
+}
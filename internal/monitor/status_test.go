@@ -0,0 +1,83 @@
+package monitor
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// /status 的返回类型 (pkg/client 直接复用) 与 OpenAPI 中的 Status 逐层字段一致
+func TestStatusMatchesOpenAPI(t *testing.T) {
+	raw, err := os.ReadFile("../../api/openapi/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Components struct {
+			Schemas map[string]schema `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatal(err)
+	}
+	compareSchema(t, "Status", reflect.TypeFor[Status](), doc.Components.Schemas["Status"], doc.Components.Schemas)
+}
+
+type schema struct {
+	Ref                  string            `json:"$ref"`
+	Properties           map[string]schema `json:"properties"`
+	Items                *schema           `json:"items"`
+	AdditionalProperties json.RawMessage   `json:"additionalProperties"`
+}
+
+func compareSchema(t *testing.T, path string, typ reflect.Type, s schema, defs map[string]schema) {
+	t.Helper()
+	if s.Ref != "" {
+		s = defs[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch {
+	case typ == reflect.TypeFor[time.Time]():
+	case typ.Kind() == reflect.Slice:
+		if s.Items == nil {
+			t.Errorf("%s: schema is not an array", path)
+			return
+		}
+		compareSchema(t, path+"[]", typ.Elem(), *s.Items, defs)
+	case typ.Kind() == reflect.Map:
+		var elem schema
+		if err := json.Unmarshal(s.AdditionalProperties, &elem); err != nil {
+			t.Errorf("%s: schema has no additionalProperties", path)
+			return
+		}
+		compareSchema(t, path+"{}", typ.Elem(), elem, defs)
+	case typ.Kind() == reflect.Struct:
+		var fields []string
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			fields = append(fields, name)
+			sub, ok := s.Properties[name]
+			if !ok {
+				t.Errorf("%s.%s: missing from OpenAPI", path, name)
+				continue
+			}
+			compareSchema(t, path+"."+name, f.Type, sub, defs)
+		}
+		for name := range s.Properties {
+			if !slices.Contains(fields, name) {
+				t.Errorf("%s.%s: documented but not returned", path, name)
+			}
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package promexport
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"ip-resolver/internal/logging"
+	"ip-resolver/internal/monitor"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var log = logging.For("PromPush")
+
+// ======== 硬编码参数 =========
+const (
+	sendTimeout = 10 * time.Second
+	namePrefix  = "ip_resolver_"
+)
+
+// 推送方式
+const (
+	ModePushgateway = "pushgateway"
+	ModeRemoteWrite = "remote_write"
+)
+
+// Options 推送配置
+type Options struct {
+	Mode        string // pushgateway / remote_write
+	URL         string // Pushgateway 根地址，或 remote-write 接收地址 (如 http://prometheus:9090/api/v1/write)
+	Job         string
+	Instance    string
+	Labels      map[string]string // 附加到所有指标的标签
+	BearerToken string
+	Username    string // Basic 认证
+	Password    string
+	Interval    time.Duration
+}
+
+// Exporter 定期将监控指标推送到 Pushgateway 或 Prometheus remote-write 接口，适用于无法被抓取的部署
+// 指标名为 ip_resolver_ 前缀加 Metric 名称 (. 替换为 _)，累计值 (Counter) 追加 _total 后缀
+type Exporter struct {
+	opts    Options
+	target  string
+	client  *http.Client
+	collect func() []monitor.Metric
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func New(opts Options, collect func() []monitor.Metric) (*Exporter, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid url: %q", opts.URL)
+	}
+
+	target := opts.URL
+	switch opts.Mode {
+	case ModePushgateway:
+		// PUT 整组替换，已消失的指标 (如被移除的提供商) 不会残留
+		target = strings.TrimRight(opts.URL, "/") + "/metrics/job/" + url.PathEscape(opts.Job)
+		if opts.Instance != "" {
+			target += "/instance/" + url.PathEscape(opts.Instance)
+		}
+	case ModeRemoteWrite:
+	default:
+		return nil, fmt.Errorf("unknown mode: %q", opts.Mode)
+	}
+
+	return &Exporter{
+		opts:    opts,
+		target:  target,
+		client:  &http.Client{Timeout: sendTimeout},
+		collect: collect,
+		stop:    make(chan struct{}),
+	}, nil
+}
+
+func (e *Exporter) Start() {
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		ticker := time.NewTicker(e.opts.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				e.push()
+			case <-e.stop:
+				e.push() // 退出前推送最后一次
+				return
+			}
+		}
+	}()
+}
+
+func (e *Exporter) Stop() {
+	close(e.stop)
+	e.wg.Wait()
+}
+
+func (e *Exporter) push() {
+	series := e.series(e.collect())
+
+	var (
+		method = http.MethodPut
+		body   []byte
+		header = http.Header{}
+	)
+	if e.opts.Mode == ModeRemoteWrite {
+		method = http.MethodPost
+		body = snappyEncode(encodeWriteRequest(series, time.Now().UnixMilli()))
+		header.Set("Content-Type", "application/x-protobuf")
+		header.Set("Content-Encoding", "snappy")
+		header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	} else {
+		body = encodeText(series)
+		header.Set("Content-Type", "text/plain; version=0.0.4")
+	}
+
+	if err := e.send(method, body, header); err != nil {
+		log.Warn("推送指标失败", "mode", e.opts.Mode, "url", e.target, "err", err)
+	}
+}
+
+func (e *Exporter) send(method string, body []byte, header http.Header) error {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, e.target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header = header
+	switch {
+	case e.opts.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+e.opts.BearerToken)
+	case e.opts.Username != "":
+		req.SetBasicAuth(e.opts.Username, e.opts.Password)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// ======== 指标转换 =========
+
+type label struct{ name, value string }
+
+// series 一条时间序列，labels 按名称排序 (不含 __name__)
+type series struct {
+	name    string
+	counter bool
+	labels  []label
+	value   float64
+}
+
+// series 将 Metric 转换为 Prometheus 时间序列并按名称排序 (文本格式要求同名指标相邻)
+// Pushgateway 会为分组附加 job / instance 标签，remote-write 需由推送方携带
+func (e *Exporter) series(metrics []monitor.Metric) []series {
+	out := make([]series, 0, len(metrics))
+	for _, m := range metrics {
+		s := series{name: metricName(m.Name, m.Counter), counter: m.Counter, value: m.Value}
+		for k, v := range e.opts.Labels {
+			s.labels = append(s.labels, label{sanitizeName(k), v})
+		}
+		for _, t := range m.Tags {
+			k, v, _ := strings.Cut(t, ":")
+			s.labels = append(s.labels, label{sanitizeName(k), v})
+		}
+		if e.opts.Mode == ModeRemoteWrite {
+			s.labels = append(s.labels, label{"job", e.opts.Job})
+			if e.opts.Instance != "" {
+				s.labels = append(s.labels, label{"instance", e.opts.Instance})
+			}
+		}
+		sort.Slice(s.labels, func(i, j int) bool { return s.labels[i].name < s.labels[j].name })
+		out = append(out, s)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].name < out[j].name })
+	return out
+}
+
+func metricName(name string, counter bool) string {
+	n := namePrefix + sanitizeName(name)
+	if counter {
+		n += "_total"
+	}
+	return n
+}
+
+// sanitizeName 指标名与标签名只允许字母、数字与下划线
+func sanitizeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// encodeText Prometheus 文本格式 (0.0.4)
+func encodeText(all []series) []byte {
+	var buf bytes.Buffer
+	for i, s := range all {
+		if i == 0 || all[i-1].name != s.name {
+			typ := "gauge"
+			if s.counter {
+				typ = "counter"
+			}
+			fmt.Fprintf(&buf, "# TYPE %s %s\n", s.name, typ)
+		}
+		buf.WriteString(s.name)
+		if len(s.labels) > 0 {
+			buf.WriteByte('{')
+			for j, l := range s.labels {
+				if j > 0 {
+					buf.WriteByte(',')
+				}
+				buf.WriteString(l.name + `="` + escapeLabel(l.value) + `"`)
+			}
+			buf.WriteByte('}')
+		}
+		buf.WriteByte(' ')
+		buf.WriteString(strconv.FormatFloat(s.value, 'g', -1, 64))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+// encodeWriteRequest 按 remote-write 协议 (prometheus.WriteRequest) 手工编码 protobuf，避免引入 prometheus/prompb
+//
+//	WriteRequest { repeated TimeSeries timeseries = 1; }
+//	TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	Label        { string name = 1; string value = 2; }
+//	Sample       { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(all []series, tsMillis int64) []byte {
+	var req []byte
+	for _, s := range all {
+		labels := append([]label{{"__name__", s.name}}, s.labels...)
+
+		var ts []byte
+		for _, l := range labels {
+			var lb []byte
+			lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.name)
+			lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.value)
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, lb)
+		}
+
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(s.value))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(tsMillis))
+		ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, sample)
+
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, ts)
+	}
+	return req
+}
+
+// snappyEncode 生成仅包含字面量块的 Snappy (block 格式) 数据
+// 不做压缩，但任何 Snappy 解码器都能解析，指标数据量很小，无需为此引入压缩库
+func snappyEncode(src []byte) []byte {
+	dst := binary.AppendUvarint(nil, uint64(len(src)))
+	const maxLiteral = 1 << 16
+	for len(src) > 0 {
+		n := min(len(src), maxLiteral)
+		// 字面量标记: 长度 - 1 以 2 字节小端存放 (tag 高 6 位为 61)
+		dst = append(dst, 61<<2, byte(n-1), byte((n-1)>>8))
+		dst = append(dst, src[:n]...)
+		src = src[n:]
+	}
+	return dst
+}
@@ -0,0 +1,216 @@
+package promexport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"ip-resolver/internal/monitor"
+	"math"
+	"slices"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// decodedSeries 按 prometheus.TimeSeries 解码出的一条时间序列
+type decodedSeries struct {
+	labels  []label
+	samples []decodedSample
+}
+
+type decodedSample struct {
+	value float64
+	ts    int64
+}
+
+// decodeWriteRequest 用 protowire 解码 WriteRequest，遇到非预期的字段或类型时报错
+func decodeWriteRequest(t *testing.T, b []byte) []decodedSeries {
+	t.Helper()
+	var out []decodedSeries
+	forEachField(t, b, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) {
+		if num != 1 || typ != protowire.BytesType {
+			t.Fatalf("WriteRequest: unexpected field %d type %d", num, typ)
+		}
+		var s decodedSeries
+		forEachField(t, v, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) {
+			switch {
+			case num == 1 && typ == protowire.BytesType:
+				var l label
+				forEachField(t, v, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) {
+					switch {
+					case num == 1 && typ == protowire.BytesType:
+						l.name = string(v)
+					case num == 2 && typ == protowire.BytesType:
+						l.value = string(v)
+					default:
+						t.Fatalf("Label: unexpected field %d type %d", num, typ)
+					}
+				})
+				s.labels = append(s.labels, l)
+			case num == 2 && typ == protowire.BytesType:
+				var smp decodedSample
+				forEachField(t, v, func(num protowire.Number, typ protowire.Type, _ []byte, n uint64) {
+					switch {
+					case num == 1 && typ == protowire.Fixed64Type:
+						smp.value = math.Float64frombits(n)
+					case num == 2 && typ == protowire.VarintType:
+						smp.ts = int64(n)
+					default:
+						t.Fatalf("Sample: unexpected field %d type %d", num, typ)
+					}
+				})
+				s.samples = append(s.samples, smp)
+			default:
+				t.Fatalf("TimeSeries: unexpected field %d type %d", num, typ)
+			}
+		})
+		out = append(out, s)
+	})
+	return out
+}
+
+// forEachField 依次读取消息中的字段，bytes 字段的内容放在 v，varint / fixed64 的取值放在 n
+func forEachField(t *testing.T, b []byte, fn func(num protowire.Number, typ protowire.Type, v []byte, n uint64)) {
+	t.Helper()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatalf("bad tag: %v", protowire.ParseError(n))
+		}
+		b = b[n:]
+		switch typ {
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				t.Fatalf("bad bytes: %v", protowire.ParseError(n))
+			}
+			fn(num, typ, v, 0)
+			b = b[n:]
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				t.Fatalf("bad varint: %v", protowire.ParseError(n))
+			}
+			fn(num, typ, nil, v)
+			b = b[n:]
+		case protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(b)
+			if n < 0 {
+				t.Fatalf("bad fixed64: %v", protowire.ParseError(n))
+			}
+			fn(num, typ, nil, v)
+			b = b[n:]
+		default:
+			t.Fatalf("unexpected wire type %d", typ)
+		}
+	}
+}
+
+// remote-write 请求体可被标准 protobuf 解码: 每条序列的 __name__ 在前，其余标签按名称排序，携带 job / instance
+func TestEncodeWriteRequest(t *testing.T) {
+	e := &Exporter{opts: Options{
+		Mode:     ModeRemoteWrite,
+		Job:      "ip-resolver",
+		Instance: "host-1",
+		Labels:   map[string]string{"env": "prod"},
+	}}
+	all := e.series([]monitor.Metric{
+		{Name: "requests", Value: 42, Counter: true},
+		{Name: "hit_ratio", Value: 0.875, Tags: []string{"window:5m"}},
+	})
+	const ts = 1760000000123
+
+	got := decodeWriteRequest(t, encodeWriteRequest(all, ts))
+	want := []decodedSeries{
+		{
+			labels:  []label{{"__name__", "ip_resolver_hit_ratio"}, {"env", "prod"}, {"instance", "host-1"}, {"job", "ip-resolver"}, {"window", "5m"}},
+			samples: []decodedSample{{0.875, ts}},
+		},
+		{
+			labels:  []label{{"__name__", "ip_resolver_requests_total"}, {"env", "prod"}, {"instance", "host-1"}, {"job", "ip-resolver"}},
+			samples: []decodedSample{{42, ts}},
+		},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("decoded %d series, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !slices.Equal(got[i].labels, want[i].labels) || !slices.Equal(got[i].samples, want[i].samples) {
+			t.Fatalf("series %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// snappyDecode 按 Snappy block 格式解码，仅支持字面量块 (snappyEncode 不产生 copy 块)
+func snappyDecode(src []byte) ([]byte, error) {
+	size, n := binary.Uvarint(src)
+	if n <= 0 {
+		return nil, errors.New("bad length")
+	}
+	src = src[n:]
+	var dst []byte
+	for len(src) > 0 {
+		tag := src[0]
+		src = src[1:]
+		if tag&3 != 0 {
+			return nil, errors.New("unexpected copy element")
+		}
+		l := int(tag >> 2)
+		if l >= 60 {
+			k := l - 59 // 长度字段的字节数
+			if len(src) < k {
+				return nil, errors.New("truncated literal length")
+			}
+			l = 0
+			for i := k - 1; i >= 0; i-- {
+				l = l<<8 | int(src[i])
+			}
+			src = src[k:]
+		}
+		l++
+		if len(src) < l {
+			return nil, errors.New("truncated literal")
+		}
+		dst = append(dst, src[:l]...)
+		src = src[l:]
+	}
+	if uint64(len(dst)) != size {
+		return nil, errors.New("length mismatch")
+	}
+	return dst, nil
+}
+
+func TestSnappyEncode(t *testing.T) {
+	// 字面量块: 标记 0xf4 (61<<2，长度 - 1 以 2 字节小端存放)
+	exact := []struct {
+		src  []byte
+		want []byte
+	}{
+		{nil, []byte{0x00}},
+		{[]byte("abc"), []byte{0x03, 0xf4, 0x02, 0x00, 'a', 'b', 'c'}},
+	}
+	for _, tt := range exact {
+		if got := snappyEncode(tt.src); !bytes.Equal(got, tt.want) {
+			t.Fatalf("snappyEncode(%q) = % x, want % x", tt.src, got, tt.want)
+		}
+	}
+
+	// 超过 64KiB 时拆成多个字面量块
+	big := make([]byte, 1<<16+10)
+	for i := range big {
+		big[i] = byte(i * 7)
+	}
+	for _, src := range [][]byte{[]byte("x"), big[:1<<16], big} {
+		enc := snappyEncode(src)
+		dec, err := snappyDecode(enc)
+		if err != nil {
+			t.Fatalf("len %d: %v", len(src), err)
+		}
+		if !bytes.Equal(dec, src) {
+			t.Fatalf("len %d: round trip mismatch", len(src))
+		}
+	}
+	if enc := snappyEncode(big); !bytes.Equal(enc[3:6], []byte{0xf4, 0xff, 0xff}) || enc[6+1<<16] != 0xf4 {
+		t.Fatalf("unexpected chunk headers: % x", enc[:6])
+	}
+}
@@ -3,9 +3,11 @@ package provider
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"ip-resolver/internal/model"
 	"ip-resolver/internal/monitor"
+	"time"
 )
 
 type TencentIPQueryProvider struct {
@@ -13,30 +15,37 @@ type TencentIPQueryProvider struct {
 	mon  *monitor.Monitor
 }
 
-func New30498Provider(secretID, secretKey string, mon *monitor.Monitor) *TencentIPQueryProvider {
+func New30498Provider(secretID, secretKey string, timeout time.Duration, mon *monitor.Monitor) *TencentIPQueryProvider {
 	config := &TencentCloudConfig{
 		SecretID:  secretID,
 		SecretKey: secretKey,
 		BaseURL:   "https://ap-guangzhou.cloudmarket-apigw.com/service-hnhpr5tw/ip/query",
 		Method:    "POST",
+		Timeout:   timeout,
 	}
 
-	return &TencentIPQueryProvider{
-		base: NewTencentCloudBase(config),
-		mon:  mon,
-	}
+	p := &TencentIPQueryProvider{mon: mon}
+	config.Name = p.Name()
+	p.base = NewTencentCloudBase(config, mon)
+	return p
 }
 
 func (p *TencentIPQueryProvider) Name() string {
 	return "https://market.cloud.tencent.com/products/30498"
 }
 
+// SetCredentials 更换凭证 (配置热加载)
+func (p *TencentIPQueryProvider) SetCredentials(secretID, secretKey string) {
+	p.base.SetCredentials(secretID, secretKey)
+}
+
 func (p *TencentIPQueryProvider) Fetch(ctx context.Context, ip string) (*model.IPInfo, error) {
 	bodyParams := map[string]string{"ip": ip}
 	
+	start := time.Now()
 	bodyBytes, err := p.base.DoRequest(ctx, nil, bodyParams)
 	if err != nil {
-		p.mon.RecordFailure(ip, fmt.Sprintf("请求失败: %v", err))
+		p.mon.RecordFailure(p.Name(), ip, withJobID(ctx, fmt.Sprintf("请求失败: %v", err)), time.Since(start))
 		return nil, err
 	}
 
@@ -50,17 +59,17 @@ func (p *TencentIPQueryProvider) Fetch(ctx context.Context, ip string) (*model.I
 	}
 
 	if err := json.Unmarshal(bodyBytes, &apiResp); err != nil {
-		p.mon.RecordFailure(ip, fmt.Sprintf("JSON解析失败: %v", err))
+		p.mon.RecordFailure(p.Name(), ip, withJobID(ctx, fmt.Sprintf("JSON解析失败: %v", err)), time.Since(start))
 		return nil, fmt.Errorf("JSON解析失败: %w", err)
 	}
 
 	if apiResp.Code != 200 {
 		errMsg := fmt.Sprintf("API 错误 | 代码: %d | 信息: %s", apiResp.Code, apiResp.Msg)
-		p.mon.RecordFailure(ip, errMsg)
-		return nil, fmt.Errorf(errMsg)
+		p.mon.RecordFailure(p.Name(), ip, withJobID(ctx, errMsg), time.Since(start))
+		return nil, errors.New(errMsg)
 	}
 
-	p.mon.RecordSuccess()
+	p.mon.RecordSuccess(p.Name(), time.Since(start))
 
 	return &model.IPInfo{
 		Province: apiResp.Data.Region,
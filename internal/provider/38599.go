@@ -3,9 +3,11 @@ package provider
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"ip-resolver/internal/model"
 	"ip-resolver/internal/monitor"
+	"time"
 )
 
 type ShuMaiProvider struct {
@@ -13,24 +15,30 @@ type ShuMaiProvider struct {
 	mon  *monitor.Monitor
 }
 
-func New38599Provider(secretID, secretKey string, mon *monitor.Monitor) *ShuMaiProvider {
+func New38599Provider(secretID, secretKey string, timeout time.Duration, mon *monitor.Monitor) *ShuMaiProvider {
 	config := &TencentCloudConfig{
 		SecretID:  secretID,
 		SecretKey: secretKey,
 		BaseURL:   "https://ap-guangzhou.cloudmarket-apigw.com/service-5ezbz0ek/v4/ip/district/query",
 		Method:    "GET",
+		Timeout:   timeout,
 	}
 
-	return &ShuMaiProvider{
-		base: NewTencentCloudBase(config),
-		mon:  mon,
-	}
+	p := &ShuMaiProvider{mon: mon}
+	config.Name = p.Name()
+	p.base = NewTencentCloudBase(config, mon)
+	return p
 }
 
 func (p *ShuMaiProvider) Name() string {
 	return "https://market.cloud.tencent.com/products/38599"
 }
 
+// SetCredentials 更换凭证 (配置热加载)
+func (p *ShuMaiProvider) SetCredentials(secretID, secretKey string) {
+	p.base.SetCredentials(secretID, secretKey)
+}
+
 func (p *ShuMaiProvider) Fetch(ctx context.Context, ip string) (*model.IPInfo, error) {
 	// 构建请求参数
 	queryParams := map[string]string{
@@ -38,9 +46,10 @@ func (p *ShuMaiProvider) Fetch(ctx context.Context, ip string) (*model.IPInfo, e
 	}
 
 	// 发起请求
+	start := time.Now()
 	bodyBytes, err := p.base.DoRequest(ctx, queryParams, nil)
 	if err != nil {
-		p.mon.RecordFailure(ip, fmt.Sprintf("请求失败: %v", err))
+		p.mon.RecordFailure(p.Name(), ip, withJobID(ctx, fmt.Sprintf("请求失败: %v", err)), time.Since(start))
 		return nil, err
 	}
 
@@ -58,17 +67,17 @@ func (p *ShuMaiProvider) Fetch(ctx context.Context, ip string) (*model.IPInfo, e
 	}
 
 	if err := json.Unmarshal(bodyBytes, &apiResp); err != nil {
-		p.mon.RecordFailure(ip, fmt.Sprintf("JSON解析失败: %v | body: %s", err, string(bodyBytes)))
+		p.mon.RecordFailure(p.Name(), ip, withJobID(ctx, fmt.Sprintf("JSON解析失败: %v | body: %s", err, string(bodyBytes))), time.Since(start))
 		return nil, fmt.Errorf("JSON解析失败: %w", err)
 	}
 
 	if apiResp.Code != 200 {
 		errMsg := fmt.Sprintf("API 错误 | 代码: %d | 信息: %s", apiResp.Code, apiResp.Message)
-		p.mon.RecordFailure(ip, errMsg)
-		return nil, fmt.Errorf(errMsg)
+		p.mon.RecordFailure(p.Name(), ip, withJobID(ctx, errMsg), time.Since(start))
+		return nil, errors.New(errMsg)
 	}
 
-	p.mon.RecordSuccess()
+	p.mon.RecordSuccess(p.Name(), time.Since(start))
 
 	return &model.IPInfo{
 		Province: apiResp.Data.Result.Prov,
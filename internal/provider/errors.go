@@ -0,0 +1,40 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// StatusError 上游返回 5xx 等异常 HTTP 状态码
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("上游返回异常状态码: %d", e.StatusCode)
+}
+
+// IsTransient 判断错误是否为短暂故障 (超时、连接失败、5xx)，稍后重试通常可以恢复
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.StatusCode >= http.StatusInternalServerError
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+	var oe *net.OpError
+	return errors.As(err, &oe)
+}
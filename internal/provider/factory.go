@@ -3,15 +3,35 @@ package provider
 import (
     "fmt"
     "ip-resolver/internal/monitor"
+    "sort"
+    "time"
 )
 
-func NewProviderByName(name, secretID, secretKey string, mon *monitor.Monitor) (IPProvider, error) {
-	switch name {
-	case "38599":
-		return New38599Provider(secretID, secretKey, mon), nil
-	case "30498":
-		return New30498Provider(secretID, secretKey, mon), nil
-	default:
-		return nil, fmt.Errorf("未知供应商: %s", name)
-	}
-}
\ No newline at end of file
+// constructors 按配置名称登记的提供商
+var constructors = map[string]func(secretID, secretKey string, timeout time.Duration, mon *monitor.Monitor) IPProvider{
+    "38599": func(secretID, secretKey string, timeout time.Duration, mon *monitor.Monitor) IPProvider {
+        return New38599Provider(secretID, secretKey, timeout, mon)
+    },
+    "30498": func(secretID, secretKey string, timeout time.Duration, mon *monitor.Monitor) IPProvider {
+        return New30498Provider(secretID, secretKey, timeout, mon)
+    },
+}
+
+// Names 返回支持的提供商名称 (用于配置校验)
+func Names() []string {
+    names := make([]string, 0, len(constructors))
+    for name := range constructors {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// NewProviderByName 按名称创建提供商，timeout 为单次 HTTP 请求超时 (0 使用默认值)
+func NewProviderByName(name, secretID, secretKey string, timeout time.Duration, mon *monitor.Monitor) (IPProvider, error) {
+    newFn, ok := constructors[name]
+    if !ok {
+        return nil, fmt.Errorf("未知供应商: %s", name)
+    }
+    return newFn(secretID, secretKey, timeout, mon), nil
+}
@@ -9,3 +9,15 @@ type IPProvider interface {
 	Fetch(ctx context.Context, ip string) (*model.IPInfo, error)
 	Name() string
 }
+
+// BatchProvider 支持单次请求查询多个 IP 的提供商 (可选实现)
+// 返回结果按 IP 索引，缺失的 IP 视为该 IP 查询失败
+type BatchProvider interface {
+	IPProvider
+	FetchBatch(ctx context.Context, ips []string) (map[string]*model.IPInfo, error)
+}
+
+// CredentialSetter 支持运行时更换凭证的提供商 (可选实现，配置热加载使用)
+type CredentialSetter interface {
+	SetCredentials(secretID, secretKey string)
+}
@@ -8,14 +8,21 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io"
+	"ip-resolver/internal/logging"
+	"ip-resolver/internal/monitor"
+	"ip-resolver/internal/requestid"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
+var slowLog = logging.For("SlowCall")
+
 // TencentCloudConfig 腾讯云市场通用配置
 type TencentCloudConfig struct {
+	Name      string // 提供商名称，用于慢调用记录
 	SecretID  string
 	SecretKey string
 	BaseURL   string
@@ -26,11 +33,13 @@ type TencentCloudConfig struct {
 // TencentCloudBase 腾讯云市场基础客户端
 type TencentCloudBase struct {
 	config *TencentCloudConfig
+	credMu sync.RWMutex // 保护 config.SecretID / SecretKey (配置热加载)
 	client *http.Client
+	mon    *monitor.Monitor // 可选，记录慢调用
 }
 
 // NewTencentCloudBase 创建腾讯云基础客户端
-func NewTencentCloudBase(config *TencentCloudConfig) *TencentCloudBase {
+func NewTencentCloudBase(config *TencentCloudConfig, mon *monitor.Monitor) *TencentCloudBase {
 	if config.Timeout == 0 {
 		config.Timeout = 5 * time.Second
 	}
@@ -40,14 +49,29 @@ func NewTencentCloudBase(config *TencentCloudConfig) *TencentCloudBase {
 		client: &http.Client{
 			Timeout: config.Timeout,
 		},
+		mon: mon,
 	}
 }
 
+// SetCredentials 更换 SecretId / SecretKey，之后发起的请求使用新凭证
+func (b *TencentCloudBase) SetCredentials(secretID, secretKey string) {
+	b.credMu.Lock()
+	defer b.credMu.Unlock()
+	b.config.SecretID = secretID
+	b.config.SecretKey = secretKey
+}
+
+func (b *TencentCloudBase) credentials() (string, string) {
+	b.credMu.RLock()
+	defer b.credMu.RUnlock()
+	return b.config.SecretID, b.config.SecretKey
+}
+
 // DoRequest 执行腾讯云市场请求
 func (b *TencentCloudBase) DoRequest(ctx context.Context, queryParams, bodyParams map[string]string) ([]byte, error) {
 
 	// 检查配置是否为空
-	if b.config.SecretID == "" || b.config.SecretKey == "" {
+	if secretID, secretKey := b.credentials(); secretID == "" || secretKey == "" {
 		return nil, fmt.Errorf("凭证缺失: SecretId 或 SecretKey 为空")
 	}
 	
@@ -79,7 +103,14 @@ func (b *TencentCloudBase) DoRequest(ctx context.Context, queryParams, bodyParam
 		return nil, fmt.Errorf("计算签名失败: %w", err)
 	}
 	
-	reqID := generateRequestID()
+	// 优先沿用调用方的请求 ID，便于端到端追踪
+	reqID := requestid.FromContext(ctx)
+	if reqID == "" {
+		reqID = requestid.JobFromContext(ctx)
+	}
+	if reqID == "" {
+		reqID = generateRequestID()
+	}
 	headers["Authorization"] = auth
 	headers["request-id"] = reqID
 
@@ -88,14 +119,21 @@ func (b *TencentCloudBase) DoRequest(ctx context.Context, queryParams, bodyParam
 	}
 
 	// 5. 发起请求
+	start := time.Now()
 	resp, err := b.client.Do(req)
 	if err != nil {
+		b.captureSlow(ctx, req, queryParams, bodyParams, start, 0, nil, err)
 		return nil, fmt.Errorf("请求发送失败: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// 6. 读取响应
 	bodyBytes, err := io.ReadAll(resp.Body)
+	b.captureSlow(ctx, req, queryParams, bodyParams, start, resp.StatusCode, bodyBytes, err)
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, &StatusError{StatusCode: resp.StatusCode}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("读取响应失败: %w", err)
 	}
@@ -103,6 +141,45 @@ func (b *TencentCloudBase) DoRequest(ctx context.Context, queryParams, bodyParam
 	return bodyBytes, nil
 }
 
+// captureSlow 耗时超过 slow_call.threshold_ms 时记录完整请求参数 (不含鉴权头) 与响应
+func (b *TencentCloudBase) captureSlow(ctx context.Context, req *http.Request, queryParams, bodyParams map[string]string, start time.Time, status int, body []byte, err error) {
+	threshold := b.mon.SlowCallThreshold()
+	latency := time.Since(start)
+	if threshold <= 0 || latency < threshold {
+		return
+	}
+
+	headers := make(map[string]string, len(req.Header))
+	for k := range req.Header {
+		if k != "Authorization" {
+			headers[k] = req.Header.Get(k)
+		}
+	}
+	call := monitor.SlowCall{
+		Time:       start,
+		Provider:   b.config.Name,
+		Method:     req.Method,
+		URL:        b.config.BaseURL,
+		Query:      queryParams,
+		Form:       bodyParams,
+		Headers:    headers,
+		RequestID:  requestid.FromContext(ctx),
+		JobID:      requestid.JobFromContext(ctx),
+		LatencyMs:  float64(latency.Microseconds()) / 1000,
+		StatusCode: status,
+	}
+	if err != nil {
+		call.Error = err.Error()
+	}
+	if b.mon.CaptureSlowCallBody() {
+		call.Body = string(body)
+	}
+
+	slowLog.Warn("上游调用缓慢", "provider", call.Provider, "method", call.Method, "url", call.URL, "query", queryParams, "form", bodyParams,
+		"request_id", call.RequestID, "job_id", call.JobID, "latency", latency, "status", status, "err", err)
+	b.mon.RecordSlowCall(call)
+}
+
 // calcAuthorization 计算腾讯云市场鉴权签名
 func (b *TencentCloudBase) calcAuthorization() (string, error) {
 	timeLocation, err := time.LoadLocation("Etc/GMT")
@@ -114,7 +191,8 @@ func (b *TencentCloudBase) calcAuthorization() (string, error) {
 	signStr := fmt.Sprintf("x-date: %s", datetime)
 
 	// HMAC-SHA1 签名
-	mac := hmac.New(sha1.New, []byte(b.config.SecretKey))
+	secretID, secretKey := b.credentials()
+	mac := hmac.New(sha1.New, []byte(secretKey))
 	_, err = mac.Write([]byte(signStr))
 	if err != nil {
 		return "", err
@@ -122,7 +200,7 @@ func (b *TencentCloudBase) calcAuthorization() (string, error) {
 	sign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
     
 	auth := fmt.Sprintf(`{"id":"%s", "x-date":"%s", "signature":"%s"}`,
-		b.config.SecretID, datetime, sign)
+		secretID, datetime, sign)
 
 	return auth, nil
 }
@@ -135,6 +213,14 @@ func urlencode(params map[string]string) string {
 	return values.Encode()
 }
 
+// withJobID 在错误信息后附加解析任务 ID，便于从 /status 的 last_error 追溯到具体任务
+func withJobID(ctx context.Context, msg string) string {
+	if id := requestid.JobFromContext(ctx); id != "" {
+		return msg + " | JobID=" + id
+	}
+	return msg
+}
+
 func generateRequestID() string {
 	b := make([]byte, 16)
 	_, err := rand.Read(b)
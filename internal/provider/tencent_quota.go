@@ -1,13 +1,16 @@
 package provider
 
 import (
-	"log"
+	"context"
+	"ip-resolver/internal/logging"
 
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 	market "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/market/v20191010"
 )
 
+var quotaLog = logging.For("Quota")
+
 // TencentQuotaChecker
 type TencentQuotaChecker struct {
 	InstanceID string
@@ -30,7 +33,7 @@ func NewTencentQuotaChecker(secretID, secretKey, instanceID string) *TencentQuot
 	client, err := market.NewClient(credential, "", cpf)
 	if err != nil {
 		// 如果初始化失败，打印日志，但不要 panic，防止程序崩掉
-		log.Printf("[Quota] Init Client Error: %v", err)
+		quotaLog.Error("Init Client Error", "err", err)
 		return &TencentQuotaChecker{
 			InstanceID: instanceID,
 			Client:     nil, // 标记为不可用
@@ -45,6 +48,11 @@ func NewTencentQuotaChecker(secretID, secretKey, instanceID string) *TencentQuot
 
 // GetRemainingRequests 查询时直接使用现成的 Client
 func (c *TencentQuotaChecker) GetRemainingRequests() int64 {
+	return c.GetRemainingRequestsContext(context.Background())
+}
+
+// GetRemainingRequestsContext 同 GetRemainingRequests，ctx 取消或超时时放弃查询并返回 -1
+func (c *TencentQuotaChecker) GetRemainingRequestsContext(ctx context.Context) int64 {
 	// 如果初始化时失败了，或者没有 InstanceID，直接返回
 	if c.Client == nil || c.InstanceID == "" {
 		return -1
@@ -55,9 +63,9 @@ func (c *TencentQuotaChecker) GetRemainingRequests() int64 {
 	request.InstanceId = common.StringPtr(c.InstanceID)
 
 	// 5. 发起调用 (复用 Client)
-	response, err := c.Client.GetUsagePlanUsageAmount(request)
+	response, err := c.Client.GetUsagePlanUsageAmountWithContext(ctx, request)
 	if err != nil {
-		log.Printf("[Quota] Fetch Error: %v", err)
+		quotaLog.Warn("Fetch Error", "instance_id", c.InstanceID, "err", err)
 		return -1
 	}
 
@@ -0,0 +1,421 @@
+// Package remoteconfig 从配置中心 (Consul / etcd / Nacos) 读取 YAML 配置并监听变化，
+// 均通过各自的 HTTP API 实现，不引入客户端依赖
+package remoteconfig
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"ip-resolver/internal/logging"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var log = logging.For("RemoteConfig")
+
+// ======== 硬编码参数 =========
+const (
+	requestTimeout   = 10 * time.Second
+	longPollWait     = 55 * time.Second // Consul 阻塞查询等待时长
+	nacosPollTimeout = 30 * time.Second // Nacos 长轮询超时
+	retryBackoff     = 5 * time.Second  // 监听失败后的重试间隔
+	nacosDefaultGrp  = "DEFAULT_GROUP"
+)
+
+// 支持的配置中心
+const (
+	Consul = "consul"
+	Etcd   = "etcd"
+	Nacos  = "nacos"
+)
+
+// Providers 支持的配置中心名称
+var Providers = []string{Consul, Etcd, Nacos}
+
+// Options 配置中心连接参数
+type Options struct {
+	Provider  string
+	Endpoint  string // 如 http://127.0.0.1:8500
+	Key       string // Consul / etcd 的 key，Nacos 的 dataId
+	Group     string // Nacos group
+	Namespace string // Nacos 命名空间 ID
+	Token     string // Consul ACL Token
+	Username  string // etcd / Nacos 认证
+	Password  string
+}
+
+// Client 配置中心客户端
+type Client struct {
+	opts Options
+	http *http.Client
+	// 长轮询使用单独的 Client，超时需长于服务端等待时长
+	poll *http.Client
+}
+
+// New 创建配置中心客户端
+func New(opts Options) (*Client, error) {
+	switch opts.Provider {
+	case Consul, Etcd, Nacos:
+	default:
+		return nil, fmt.Errorf("不支持的配置中心: %q", opts.Provider)
+	}
+	opts.Endpoint = strings.TrimRight(opts.Endpoint, "/")
+	if opts.Provider == Nacos && opts.Group == "" {
+		opts.Group = nacosDefaultGrp
+	}
+	return &Client{
+		opts: opts,
+		http: &http.Client{Timeout: requestTimeout},
+		poll: &http.Client{},
+	}, nil
+}
+
+// Fetch 读取配置内容
+func (c *Client) Fetch(ctx context.Context) ([]byte, error) {
+	var data []byte
+	var err error
+	switch c.opts.Provider {
+	case Consul:
+		data, _, err = c.consulGet(ctx, c.http, 0)
+	case Etcd:
+		data, _, err = c.etcdGet(ctx)
+	case Nacos:
+		data, err = c.nacosGet(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 配置 %s 失败: %w", c.opts.Provider, c.opts.Key, err)
+	}
+	return data, nil
+}
+
+// Watch 监听配置变化，每次变化向返回的 channel 发送通知 (不携带内容，由调用方重新加载)；
+// ctx 取消后停止监听并关闭 channel
+func (c *Client) Watch(ctx context.Context) <-chan struct{} {
+	ch := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case ch <- struct{}{}:
+		default: // 已有未处理的通知，重新加载时会读取最新内容
+		}
+	}
+	go func() {
+		defer close(ch)
+		for ctx.Err() == nil {
+			var err error
+			switch c.opts.Provider {
+			case Consul:
+				err = c.consulWatch(ctx, notify)
+			case Etcd:
+				err = c.etcdWatch(ctx, notify)
+			case Nacos:
+				err = c.nacosWatch(ctx, notify)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			log.Warn("监听远程配置失败，稍后重试", "provider", c.opts.Provider, "key", c.opts.Key, "retry_in", retryBackoff, "err", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryBackoff):
+			}
+		}
+	}()
+	return ch
+}
+
+// do 发送请求并返回响应体，非 2xx 视为错误
+func do(hc *http.Client, req *http.Request) ([]byte, http.Header, error) {
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, resp.Header, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, resp.Header, nil
+}
+
+// ======== Consul =========
+
+// consulGet 读取 KV，index 非 0 时为阻塞查询，返回内容与 X-Consul-Index
+func (c *Client) consulGet(ctx context.Context, hc *http.Client, index uint64) ([]byte, uint64, error) {
+	q := url.Values{"raw": {""}}
+	if index > 0 {
+		q.Set("index", strconv.FormatUint(index, 10))
+		q.Set("wait", fmt.Sprintf("%ds", int(longPollWait/time.Second)))
+	}
+	u := c.opts.Endpoint + "/v1/kv/" + strings.TrimLeft(c.opts.Key, "/") + "?" + q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if c.opts.Token != "" {
+		req.Header.Set("X-Consul-Token", c.opts.Token)
+	}
+	body, header, err := do(hc, req)
+	if err != nil {
+		return nil, 0, err
+	}
+	idx, _ := strconv.ParseUint(header.Get("X-Consul-Index"), 10, 64)
+	return body, idx, nil
+}
+
+// consulWatch 基于阻塞查询监听 KV，Index 变化且内容不同时通知
+func (c *Client) consulWatch(ctx context.Context, notify func()) error {
+	data, index, err := c.consulGet(ctx, c.http, 0)
+	if err != nil {
+		return err
+	}
+	for {
+		next, idx, err := c.consulGet(ctx, c.poll, index)
+		if err != nil {
+			return err
+		}
+		if idx == 0 {
+			return errors.New("响应缺少 X-Consul-Index")
+		}
+		// Index 回退 (如 Consul 重建) 时按官方建议从头开始
+		if idx < index {
+			idx = 0
+		}
+		index = idx
+		if string(next) != string(data) {
+			data = next
+			notify()
+		}
+	}
+}
+
+// ======== etcd (v3 gRPC gateway) =========
+
+type etcdHeader struct {
+	Revision string `json:"revision"`
+}
+
+// etcdAuth 配置了用户名时获取认证 Token
+func (c *Client) etcdAuth(ctx context.Context) (string, error) {
+	if c.opts.Username == "" {
+		return "", nil
+	}
+	var resp struct {
+		Token string `json:"token"`
+	}
+	body, _ := json.Marshal(map[string]string{"name": c.opts.Username, "password": c.opts.Password})
+	if err := c.etcdPost(ctx, c.http, "/v3/auth/authenticate", "", body, &resp); err != nil {
+		return "", fmt.Errorf("etcd 认证失败: %w", err)
+	}
+	return resp.Token, nil
+}
+
+func (c *Client) etcdPost(ctx context.Context, hc *http.Client, path, token string, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.Endpoint+path, strings.NewReader(string(body)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	data, _, err := do(hc, req)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// etcdGet 读取 key，返回内容与当前 revision
+func (c *Client) etcdGet(ctx context.Context) ([]byte, int64, error) {
+	token, err := c.etcdAuth(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	var resp struct {
+		Header etcdHeader `json:"header"`
+		Kvs    []struct {
+			Value string `json:"value"`
+		} `json:"kvs"`
+	}
+	body, _ := json.Marshal(map[string]string{"key": base64.StdEncoding.EncodeToString([]byte(c.opts.Key))})
+	if err := c.etcdPost(ctx, c.http, "/v3/kv/range", token, body, &resp); err != nil {
+		return nil, 0, err
+	}
+	rev, _ := strconv.ParseInt(resp.Header.Revision, 10, 64)
+	if len(resp.Kvs) == 0 {
+		return nil, rev, errors.New("key 不存在")
+	}
+	value, err := base64.StdEncoding.DecodeString(resp.Kvs[0].Value)
+	if err != nil {
+		return nil, rev, fmt.Errorf("解码 value 失败: %w", err)
+	}
+	return value, rev, nil
+}
+
+// etcdWatch 使用 /v3/watch 流式接口监听 key，收到事件即通知
+func (c *Client) etcdWatch(ctx context.Context, notify func()) error {
+	_, rev, err := c.etcdGet(ctx)
+	if err != nil {
+		return err
+	}
+	token, err := c.etcdAuth(ctx)
+	if err != nil {
+		return err
+	}
+	create := map[string]any{"create_request": map[string]any{
+		"key":            base64.StdEncoding.EncodeToString([]byte(c.opts.Key)),
+		"start_revision": strconv.FormatInt(rev+1, 10),
+	}}
+	body, _ := json.Marshal(create)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.Endpoint+"/v3/watch", strings.NewReader(string(body)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	resp, err := c.poll.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var msg struct {
+			Result struct {
+				Canceled     bool              `json:"canceled"`
+				CancelReason string            `json:"cancel_reason"`
+				Events       []json.RawMessage `json:"events"`
+			} `json:"result"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := dec.Decode(&msg); err != nil {
+			return fmt.Errorf("watch 连接中断: %w", err)
+		}
+		if msg.Error != nil {
+			return errors.New(msg.Error.Message)
+		}
+		if msg.Result.Canceled {
+			return fmt.Errorf("watch 被取消: %s", msg.Result.CancelReason)
+		}
+		if len(msg.Result.Events) > 0 {
+			notify()
+		}
+	}
+}
+
+// ======== Nacos (Open API v1) =========
+
+// nacosLogin 配置了用户名时获取 accessToken
+func (c *Client) nacosLogin(ctx context.Context) (string, error) {
+	if c.opts.Username == "" {
+		return "", nil
+	}
+	form := url.Values{"username": {c.opts.Username}, "password": {c.opts.Password}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.Endpoint+"/nacos/v1/auth/login", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	body, _, err := do(c.http, req)
+	if err != nil {
+		return "", fmt.Errorf("Nacos 登录失败: %w", err)
+	}
+	var resp struct {
+		AccessToken string `json:"accessToken"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("Nacos 登录失败: %w", err)
+	}
+	return resp.AccessToken, nil
+}
+
+func (c *Client) nacosQuery(token string) url.Values {
+	q := url.Values{"dataId": {c.opts.Key}, "group": {c.opts.Group}}
+	if c.opts.Namespace != "" {
+		q.Set("tenant", c.opts.Namespace)
+	}
+	if token != "" {
+		q.Set("accessToken", token)
+	}
+	return q
+}
+
+func (c *Client) nacosGet(ctx context.Context) ([]byte, error) {
+	token, err := c.nacosLogin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	u := c.opts.Endpoint + "/nacos/v1/cs/configs?" + c.nacosQuery(token).Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	body, _, err := do(c.http, req)
+	return body, err
+}
+
+// nacosWatch 长轮询 /nacos/v1/cs/configs/listener，服务端在 MD5 不一致时立即返回变化的配置
+func (c *Client) nacosWatch(ctx context.Context, notify func()) error {
+	data, err := c.nacosGet(ctx)
+	if err != nil {
+		return err
+	}
+	sum := md5.Sum(data)
+	md5hex := hex.EncodeToString(sum[:])
+	for {
+		token, err := c.nacosLogin(ctx)
+		if err != nil {
+			return err
+		}
+		// Listening-Configs: dataId^2group^2md5[^2tenant]^1
+		listening := c.opts.Key + "\x02" + c.opts.Group + "\x02" + md5hex
+		if c.opts.Namespace != "" {
+			listening += "\x02" + c.opts.Namespace
+		}
+		form := url.Values{"Listening-Configs": {listening + "\x01"}}
+		u := c.opts.Endpoint + "/nacos/v1/cs/configs/listener"
+		if token != "" {
+			u += "?" + url.Values{"accessToken": {token}}.Encode()
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, strings.NewReader(form.Encode()))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Long-Pulling-Timeout", strconv.Itoa(int(nacosPollTimeout/time.Millisecond)))
+		body, _, err := do(c.poll, req)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(string(body)) == "" {
+			continue
+		}
+		next, err := c.nacosGet(ctx)
+		if err != nil {
+			return err
+		}
+		sum = md5.Sum(next)
+		md5hex = hex.EncodeToString(sum[:])
+		notify()
+	}
+}
@@ -0,0 +1,361 @@
+package remoteconfig
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// store 模拟配置中心中的单个 key，每次写入递增版本号并唤醒长轮询
+type store struct {
+	mu       sync.Mutex
+	value    string
+	rev      int64
+	changed  chan struct{} // 写入时关闭并替换
+	watching chan struct{} // 长轮询 / watch 请求到达时通知测试
+}
+
+func newStore(value string) *store {
+	return &store{value: value, rev: 1, changed: make(chan struct{}), watching: make(chan struct{}, 16)}
+}
+
+func (s *store) get() (string, int64, chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.value, s.rev, s.changed
+}
+
+func (s *store) set(v string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.value = v
+	s.rev++
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *store) markWatching() {
+	select {
+	case s.watching <- struct{}{}:
+	default:
+	}
+}
+
+// consulHandler 实现 /v1/kv/<key>?raw 与 index 阻塞查询
+func consulHandler(t *testing.T, s *store, token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/app/config" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("X-Consul-Token") != token {
+			http.Error(w, "ACL not found", http.StatusForbidden)
+			return
+		}
+		value, rev, changed := s.get()
+		if idx := r.URL.Query().Get("index"); idx != "" {
+			if r.URL.Query().Get("wait") == "" {
+				t.Errorf("blocking query without wait: %s", r.URL.RawQuery)
+			}
+			if n, _ := strconv.ParseInt(idx, 10, 64); n >= rev {
+				s.markWatching()
+				select {
+				case <-changed:
+				case <-r.Context().Done():
+					return
+				}
+				value, rev, _ = s.get()
+			}
+		}
+		w.Header().Set("X-Consul-Index", strconv.FormatInt(rev, 10))
+		fmt.Fprint(w, value)
+	}
+}
+
+// etcdHandler 实现 gRPC 网关的 /v3/kv/range、/v3/watch 与 /v3/auth/authenticate
+func etcdHandler(t *testing.T, s *store, user, pass string) http.HandlerFunc {
+	const token = "etcd-token"
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.URL.Path == "/v3/auth/authenticate" {
+			if req["name"] != user || req["password"] != pass {
+				http.Error(w, `{"error":"authentication failed"}`, http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"token": token})
+			return
+		}
+		if user != "" && r.Header.Get("Authorization") != token {
+			http.Error(w, `{"error":"invalid auth token"}`, http.StatusUnauthorized)
+			return
+		}
+		key := base64.StdEncoding.EncodeToString([]byte("app/config"))
+		switch r.URL.Path {
+		case "/v3/kv/range":
+			if req["key"] != key {
+				json.NewEncoder(w).Encode(map[string]any{"header": map[string]string{"revision": "1"}})
+				return
+			}
+			value, rev, _ := s.get()
+			json.NewEncoder(w).Encode(map[string]any{
+				"header": map[string]string{"revision": strconv.FormatInt(rev, 10)},
+				"kvs":    []map[string]string{{"value": base64.StdEncoding.EncodeToString([]byte(value))}},
+			})
+		case "/v3/watch":
+			create, _ := req["create_request"].(map[string]any)
+			if create["key"] != key {
+				t.Errorf("watch key = %v", create["key"])
+			}
+			start, _ := strconv.ParseInt(fmt.Sprint(create["start_revision"]), 10, 64)
+			enc := json.NewEncoder(w)
+			enc.Encode(map[string]any{"result": map[string]any{"created": true}})
+			w.(http.Flusher).Flush()
+			for {
+				_, rev, changed := s.get()
+				if rev >= start {
+					enc.Encode(map[string]any{"result": map[string]any{"events": []map[string]string{{"type": "PUT"}}}})
+					w.(http.Flusher).Flush()
+					start = rev + 1
+				}
+				s.markWatching()
+				select {
+				case <-changed:
+				case <-r.Context().Done():
+					return
+				}
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+// nacosHandler 实现 Open API v1 的 configs、configs/listener 与 auth/login
+func nacosHandler(t *testing.T, s *store, user, pass string) http.HandlerFunc {
+	const token = "nacos-token"
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.URL.Path == "/nacos/v1/auth/login" {
+			if r.PostForm.Get("username") != user || r.PostForm.Get("password") != pass {
+				http.Error(w, "unknown user!", http.StatusForbidden)
+				return
+			}
+			fmt.Fprintf(w, `{"accessToken":%q,"tokenTtl":18000}`, token)
+			return
+		}
+		if user != "" && r.URL.Query().Get("accessToken") != token {
+			http.Error(w, "token invalid!", http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/nacos/v1/cs/configs":
+			q := r.URL.Query()
+			if q.Get("dataId") != "app.yaml" || q.Get("group") != "DEFAULT_GROUP" || q.Get("tenant") != "prod" {
+				http.Error(w, "config data not exist", http.StatusNotFound)
+				return
+			}
+			value, _, _ := s.get()
+			fmt.Fprint(w, value)
+		case "/nacos/v1/cs/configs/listener":
+			if r.Header.Get("Long-Pulling-Timeout") == "" {
+				t.Error("listener request without Long-Pulling-Timeout")
+			}
+			parts := strings.Split(strings.TrimSuffix(r.PostForm.Get("Listening-Configs"), "\x01"), "\x02")
+			if len(parts) != 4 || parts[0] != "app.yaml" || parts[1] != "DEFAULT_GROUP" || parts[3] != "prod" {
+				t.Errorf("Listening-Configs = %q", parts)
+				return
+			}
+			for {
+				value, _, changed := s.get()
+				sum := md5.Sum([]byte(value))
+				if hex.EncodeToString(sum[:]) != parts[2] {
+					fmt.Fprint(w, "app.yaml%02DEFAULT_GROUP%02prod%01\n")
+					return
+				}
+				s.markWatching()
+				select {
+				case <-changed:
+				case <-r.Context().Done():
+					return
+				}
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+type backend struct {
+	name    string
+	opts    Options
+	handler func(*testing.T, *store) http.HandlerFunc
+}
+
+func backends() []backend {
+	return []backend{
+		{"consul", Options{Provider: Consul, Key: "/app/config", Token: "acl"},
+			func(t *testing.T, s *store) http.HandlerFunc { return consulHandler(t, s, "acl") }},
+		{"etcd", Options{Provider: Etcd, Key: "app/config"},
+			func(t *testing.T, s *store) http.HandlerFunc { return etcdHandler(t, s, "", "") }},
+		{"etcd auth", Options{Provider: Etcd, Key: "app/config", Username: "root", Password: "pw"},
+			func(t *testing.T, s *store) http.HandlerFunc { return etcdHandler(t, s, "root", "pw") }},
+		{"nacos", Options{Provider: Nacos, Key: "app.yaml", Namespace: "prod"},
+			func(t *testing.T, s *store) http.HandlerFunc { return nacosHandler(t, s, "", "") }},
+		{"nacos auth", Options{Provider: Nacos, Key: "app.yaml", Namespace: "prod", Username: "nacos", Password: "pw"},
+			func(t *testing.T, s *store) http.HandlerFunc { return nacosHandler(t, s, "nacos", "pw") }},
+	}
+}
+
+func newClient(t *testing.T, b backend, s *store) *Client {
+	t.Helper()
+	srv := httptest.NewServer(b.handler(t, s))
+	t.Cleanup(srv.Close)
+	opts := b.opts
+	opts.Endpoint = srv.URL + "/"
+	c, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestFetch(t *testing.T) {
+	for _, b := range backends() {
+		t.Run(b.name, func(t *testing.T) {
+			c := newClient(t, b, newStore("log_level: debug\n"))
+			data, err := c.Fetch(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != "log_level: debug\n" {
+				t.Fatalf("Fetch = %q", data)
+			}
+		})
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		b    backend
+	}{
+		{"consul bad token", backend{opts: Options{Provider: Consul, Key: "app/config", Token: "wrong"},
+			handler: func(t *testing.T, s *store) http.HandlerFunc { return consulHandler(t, s, "acl") }}},
+		{"consul missing key", backend{opts: Options{Provider: Consul, Key: "other"},
+			handler: func(t *testing.T, s *store) http.HandlerFunc { return consulHandler(t, s, "") }}},
+		{"etcd missing key", backend{opts: Options{Provider: Etcd, Key: "other"},
+			handler: func(t *testing.T, s *store) http.HandlerFunc { return etcdHandler(t, s, "", "") }}},
+		{"etcd bad password", backend{opts: Options{Provider: Etcd, Key: "app/config", Username: "root", Password: "wrong"},
+			handler: func(t *testing.T, s *store) http.HandlerFunc { return etcdHandler(t, s, "root", "pw") }}},
+		{"nacos missing data id", backend{opts: Options{Provider: Nacos, Key: "other.yaml", Namespace: "prod"},
+			handler: func(t *testing.T, s *store) http.HandlerFunc { return nacosHandler(t, s, "", "") }}},
+		{"nacos bad password", backend{opts: Options{Provider: Nacos, Key: "app.yaml", Namespace: "prod", Username: "nacos", Password: "wrong"},
+			handler: func(t *testing.T, s *store) http.HandlerFunc { return nacosHandler(t, s, "nacos", "pw") }}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newClient(t, tt.b, newStore("log_level: debug\n"))
+			if _, err := c.Fetch(context.Background()); err == nil {
+				t.Fatal("Fetch succeeded")
+			}
+		})
+	}
+}
+
+// 超时或取消的 ctx 立即中止读取，不等待 requestTimeout
+func TestFetchContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+	c, err := New(Options{Provider: Consul, Endpoint: srv.URL, Key: "app/config"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.Fetch(ctx); err == nil {
+		t.Fatal("Fetch succeeded")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Fatalf("Fetch returned after %v", d)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	for _, b := range backends() {
+		t.Run(b.name, func(t *testing.T) {
+			s := newStore("log_level: info\n")
+			c := newClient(t, b, s)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ch := c.Watch(ctx)
+
+			// 每次变化通知一次；长轮询建立后再写入，避免变化落在首次读取之前
+			for i, v := range []string{"log_level: debug\n", "log_level: warn\n"} {
+				waitFor(t, s.watching, "watch request")
+				s.set(v)
+				waitFor(t, ch, fmt.Sprintf("notification %d", i+1))
+			}
+
+			cancel()
+			deadline := time.After(5 * time.Second)
+			for {
+				select {
+				case _, ok := <-ch:
+					if !ok {
+						return
+					}
+				case <-deadline:
+					t.Fatal("channel not closed after cancel")
+				}
+			}
+		})
+	}
+}
+
+// 内容未变化 (仅 Consul Index 前进) 时不通知
+func TestConsulWatchSameContent(t *testing.T) {
+	s := newStore("log_level: info\n")
+	c := newClient(t, backends()[0], s)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := c.Watch(ctx)
+
+	waitFor(t, s.watching, "watch request")
+	s.set("log_level: info\n")
+	waitFor(t, s.watching, "next watch request")
+	select {
+	case <-ch:
+		t.Fatal("notified for unchanged content")
+	default:
+	}
+}
+
+func waitFor[T any](t *testing.T, ch <-chan T, what string) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+	}
+}
@@ -0,0 +1,91 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// Header 请求 ID 的 HTTP 头
+const Header = "X-Request-ID"
+
+// JobHeader 未命中时返回的解析任务 ID，用于追踪异步解析过程
+const JobHeader = "X-Job-ID"
+
+const maxLen = 128
+
+type ctxKey struct{}
+
+type jobKey struct{}
+
+// New 生成随机请求 ID
+func New() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// NewJobID 生成解析任务 ID (比请求 ID 短，便于在日志中检索)
+func NewJobID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// WithJob 把解析任务 ID 写入 context
+func WithJob(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, jobKey{}, id)
+}
+
+// JobFromContext 读取解析任务 ID，不存在时返回空串
+func JobFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(jobKey{}).(string)
+	return id
+}
+
+// NewContext 把请求 ID 写入 context
+func NewContext(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext 读取请求 ID，不存在时返回空串
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// Middleware 采用客户端传入的 X-Request-ID (不合法时重新生成)，写入 context 并回显到响应头
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if !valid(id) {
+			id = New()
+		}
+		w.Header().Set(Header, id)
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), id)))
+	})
+}
+
+// valid 仅接受长度受限的可见 ASCII，避免日志注入
+func valid(id string) bool {
+	if id == "" || len(id) > maxLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,165 @@
+package statsd
+
+import (
+	"bytes"
+	"ip-resolver/internal/logging"
+	"ip-resolver/internal/monitor"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var log = logging.For("StatsD")
+
+// ======== 硬编码参数 =========
+const maxPacketSize = 1432 // 避免 UDP 分片 (以太网 MTU 1500 减去 IP / UDP 头)
+
+// Exporter 定期将监控指标以 StatsD 协议 (UDP) 推送到 statsd / Datadog Agent
+// 累计值 (Counter) 换算为两次推送间的增量以 |c 发送，其余以 |g 发送
+// tags 为 true 时使用 DogStatsD 标签 (|#k:v)，否则将标签值拼接进指标名
+type Exporter struct {
+	addr     string
+	prefix   string
+	tags     bool
+	interval time.Duration
+	collect  func() []monitor.Metric
+
+	conn net.Conn
+	last map[string]float64 // 上次推送时各 Counter 的累计值
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func New(addr, prefix string, tags bool, interval time.Duration, collect func() []monitor.Metric) (*Exporter, error) {
+	// UDP 无需建立连接，Agent 未启动时也不会失败
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Exporter{
+		addr:     addr,
+		prefix:   prefix,
+		tags:     tags,
+		interval: interval,
+		collect:  collect,
+		conn:     conn,
+		last:     make(map[string]float64),
+		stop:     make(chan struct{}),
+	}, nil
+}
+
+func (e *Exporter) Start() {
+	// 以当前累计值为基准，启动时恢复的历史计数不作为增量推送
+	for _, m := range e.collect() {
+		if m.Counter {
+			e.last[counterKey(m)] = m.Value
+		}
+	}
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				e.push()
+			case <-e.stop:
+				e.push() // 退出前推送最后一次
+				return
+			}
+		}
+	}()
+}
+
+func (e *Exporter) Stop() {
+	close(e.stop)
+	e.wg.Wait()
+	e.conn.Close()
+}
+
+func (e *Exporter) push() {
+	var buf bytes.Buffer
+	failed := 0
+	flush := func() {
+		if buf.Len() == 0 {
+			return
+		}
+		if _, err := e.conn.Write(buf.Bytes()); err != nil {
+			failed++
+		}
+		buf.Reset()
+	}
+
+	for _, m := range e.collect() {
+		line := e.format(m)
+		if line == "" {
+			continue
+		}
+		if buf.Len() > 0 && buf.Len()+1+len(line) > maxPacketSize {
+			flush()
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(line)
+	}
+	flush()
+
+	if failed > 0 {
+		log.Warn("推送指标失败", "addr", e.addr, "packets", failed)
+	}
+}
+
+// format 返回一行 StatsD 数据，Counter 无增量时返回空串
+func (e *Exporter) format(m monitor.Metric) string {
+	name := e.prefix + m.Name
+	if !e.tags {
+		for _, t := range m.Tags {
+			_, v, _ := strings.Cut(t, ":")
+			name += "." + sanitize(v)
+		}
+	}
+
+	value, typ := m.Value, "g"
+	if m.Counter {
+		key := counterKey(m)
+		value -= e.last[key]
+		e.last[key] = m.Value
+		if value <= 0 {
+			return ""
+		}
+		typ = "c"
+	}
+
+	line := name + ":" + strconv.FormatFloat(value, 'f', -1, 64) + "|" + typ
+	if e.tags && len(m.Tags) > 0 {
+		tags := make([]string, len(m.Tags))
+		for i, t := range m.Tags {
+			k, v, _ := strings.Cut(t, ":")
+			tags[i] = k + ":" + sanitize(v)
+		}
+		line += "|#" + strings.Join(tags, ",")
+	}
+	return line
+}
+
+func counterKey(m monitor.Metric) string {
+	return m.Name + "|" + strings.Join(m.Tags, ",")
+}
+
+// sanitize 将提供商名称等中的 URL 字符替换为下划线，避免破坏 StatsD 行格式
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,78 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"ip-resolver/internal/config"
+	"os"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Enabled 判断是否配置了证书或 ACME 自动签发
+func Enabled(cfg config.TLSConfig) bool {
+	return (cfg.CertFile != "" && cfg.KeyFile != "") || len(cfg.ACMEDomains) > 0
+}
+
+// ServerConfig 根据配置构建服务端 TLS 配置，配置了 client_ca_file 时启用客户端证书校验 (mTLS)
+// 配置 acme_domains 时通过 ACME (TLS-ALPN-01) 自动签发证书，监听端口需为 443
+func ServerConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	var tlsCfg *tls.Config
+
+	if len(cfg.ACMEDomains) > 0 {
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.ACMEDomains...),
+			Cache:      autocert.DirCache(cfg.ACMECacheDir),
+			Email:      cfg.ACMEEmail,
+		}
+		tlsCfg = &tls.Config{
+			GetCertificate: m.GetCertificate,
+			NextProtos:     []string{"http/1.1", acme.ALPNProto},
+			MinVersion:     tls.VersionTLS12,
+		}
+	} else {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载证书失败: %w", err)
+		}
+		tlsCfg = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+	}
+
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取客户端 CA 失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("客户端 CA 文件中没有有效证书: %s", cfg.ClientCAFile)
+		}
+		tlsCfg.ClientCAs = pool
+
+		switch cfg.ClientAuth {
+		case "", "require":
+			tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+		case "optional":
+			tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+		default:
+			return nil, fmt.Errorf("未知 client_auth: %s", cfg.ClientAuth)
+		}
+	}
+
+	return tlsCfg, nil
+}
+
+// EnableHTTP2 在 ALPN 中优先协商 h2 (需配合 http.Server.Protocols 启用 HTTP/2)
+func EnableHTTP2(tlsCfg *tls.Config) {
+	protos := []string{"h2"}
+	if len(tlsCfg.NextProtos) == 0 {
+		protos = append(protos, "http/1.1")
+	}
+	tlsCfg.NextProtos = append(protos, tlsCfg.NextProtos...)
+}
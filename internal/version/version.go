@@ -0,0 +1,79 @@
+package version
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// 以下变量在编译时通过 ldflags 注入，例如:
+// go build -ldflags "-X ip-resolver/internal/version.Version=v26.01.12 -X ip-resolver/internal/version.Commit=$(git rev-parse HEAD)"
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info 当前运行的构建信息
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"` // 编译时工作区有未提交的修改
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+var (
+	once sync.Once
+	info Info
+)
+
+// Get 返回构建信息；未通过 ldflags 注入 commit / 编译时间时，尝试从 Go 内嵌的 VCS 信息中读取 (此时为提交时间)
+func Get() Info {
+	once.Do(func() {
+		info = Info{
+			Version:   Version,
+			Commit:    Commit,
+			BuildTime: BuildTime,
+			GoVersion: runtime.Version(),
+			Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		}
+		bi, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.BuildTime == "" {
+					info.BuildTime = s.Value
+				}
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	})
+	return info
+}
+
+// Short 用于日志与命令行输出，如 "v26.01.12 (1a2b3c4d)"
+func Short() string {
+	i := Get()
+	if i.Commit == "" {
+		return i.Version
+	}
+	return i.Version + " (" + i.Commit[:min(len(i.Commit), 8)] + ")"
+}
+
+// Handler GET /version
+func Handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(Get())
+}
@@ -0,0 +1,133 @@
+package worker
+
+import (
+	"ip-resolver/internal/logging"
+	"sync"
+	"time"
+)
+
+var adaptiveLog = logging.For("Adaptive")
+
+// minAdaptiveWindow 每次调整并发前至少收集的样本数
+const minAdaptiveWindow = 10
+
+// adaptiveLimiter 按上游耗时与错误率调整实际并发 (AIMD)：
+// 每个窗口结束时平均耗时或错误率超过阈值则并发减半，否则加一，范围 [minLimit, maxLimit]
+type adaptiveLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	minLimit  int
+	maxLimit  int
+	inUse     int
+	wake      chan struct{} // 有空闲名额时关闭并替换，唤醒等待方
+	latency   time.Duration // 平均耗时阈值
+	errorRate float64       // 错误率阈值
+
+	// 当前窗口
+	samples int
+	errors  int
+	total   time.Duration
+}
+
+// newAdaptiveLimiter 初始并发为 maxLimit
+func newAdaptiveLimiter(minLimit, maxLimit int, latency time.Duration, errorRate float64) *adaptiveLimiter {
+	minLimit = min(max(minLimit, 1), maxLimit)
+	return &adaptiveLimiter{
+		limit:     maxLimit,
+		minLimit:  minLimit,
+		maxLimit:  maxLimit,
+		wake:      make(chan struct{}),
+		latency:   latency,
+		errorRate: errorRate,
+	}
+}
+
+// acquire 阻塞到获得并发名额，done 关闭时放弃并返回 false
+func (l *adaptiveLimiter) acquire(done <-chan struct{}) bool {
+	if l == nil {
+		return true
+	}
+
+	for {
+		l.mu.Lock()
+		if l.inUse < l.limit {
+			l.inUse++
+			l.mu.Unlock()
+			return true
+		}
+		wake := l.wake
+		l.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-done:
+			return false
+		}
+	}
+}
+
+// release 归还 acquire 获得的名额
+func (l *adaptiveLimiter) release() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	l.inUse--
+	l.broadcast()
+	l.mu.Unlock()
+}
+
+// observe 记录一次上游请求的结果，窗口满时调整并发
+func (l *adaptiveLimiter) observe(latency time.Duration, failed bool) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.samples++
+	l.total += latency
+	if failed {
+		l.errors++
+	}
+	if l.samples < max(l.limit, minAdaptiveWindow) {
+		return
+	}
+
+	avg := l.total / time.Duration(l.samples)
+	rate := float64(l.errors) / float64(l.samples)
+	l.samples, l.errors, l.total = 0, 0, 0
+
+	prev := l.limit
+	if avg > l.latency || rate > l.errorRate {
+		l.limit = max(l.limit/2, l.minLimit)
+	} else if l.limit < l.maxLimit {
+		l.limit++
+		l.broadcast()
+	}
+
+	if l.limit < prev {
+		adaptiveLog.Warn("上游变慢或出错, 降低并发", "latency", avg, "error_rate", rate, "from", prev, "to", l.limit)
+	} else if l.limit == l.maxLimit && prev < l.maxLimit {
+		adaptiveLog.Info("上游已恢复, 并发回到上限", "limit", l.limit)
+	}
+}
+
+// broadcast 唤醒所有等待方 (需持有 mu)
+func (l *adaptiveLimiter) broadcast() {
+	close(l.wake)
+	l.wake = make(chan struct{})
+}
+
+// current 返回当前并发上限，未启用时返回 0
+func (l *adaptiveLimiter) current() int {
+	if l == nil {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
@@ -0,0 +1,171 @@
+package worker
+
+import (
+	"encoding/json"
+	"io"
+	"ip-resolver/internal/logging"
+	"ip-resolver/internal/model"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+var adminLog = logging.For("Admin")
+
+// MaxRequeueKeys 单次强制重新解析的最大 key 数
+const MaxRequeueKeys = 10000
+
+// ================= 管理接口 ===================
+
+// cacheEntryInfo 单个缓存条目的详细信息
+type cacheEntryInfo struct {
+	Key          string        `json:"key"`
+	Found        bool          `json:"found"`
+	Tag          string        `json:"tag,omitempty"`
+	Detail       *model.IPInfo `json:"detail,omitempty"`
+	TTL          int64         `json:"ttl,omitempty"`
+	NeedsRefresh bool          `json:"needs_refresh,omitempty"`
+	Inflight     bool          `json:"inflight"`
+}
+
+// HandleAdminCache 查看 (GET) 或删除 (DELETE) 单个缓存条目，通过 ?key= 或 ?ip= 指定
+func (m *Manager) HandleAdminCache(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if ip := r.URL.Query().Get("ip"); ip != "" {
+		k, err := m.KeyForIP(ip)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		key = k
+	}
+	if key == "" {
+		http.Error(w, "missing key or ip", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		tag, found, needsRefresh, remaining := m.cache.Get(key)
+		info := cacheEntryInfo{
+			Key:      key,
+			Found:    found,
+			Inflight: m.inflight.Done(key) != nil,
+		}
+		if found {
+			info.Tag = tag
+			info.Detail = model.ParseDetail(m.cache.GetDetail(key))
+			info.TTL = int64(remaining / time.Second)
+			info.NeedsRefresh = needsRefresh
+		}
+		writeJSONBody(w, http.StatusOK, info)
+
+	case http.MethodDelete:
+		removed := m.Invalidate(key)
+		writeJSONBody(w, http.StatusOK, map[string]any{"key": key, "removed": removed})
+
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleAdminDebug 输出队列、并发与运行时状态
+func (m *Manager) HandleAdminDebug(w http.ResponseWriter, r *http.Request) {
+	writeJSONBody(w, http.StatusOK, map[string]any{
+		"queue_length":           m.queue.len(),
+		"queue_capacity":         m.queue.cap(),
+		"queue_shards":           len(m.queue.shards),
+		"refresh_queue_length":   m.refreshQueue.len(),
+		"refresh_queue_capacity": m.refreshQueue.cap(),
+		"inflight":               m.inflight.Len(),
+		"inflight_expired":       m.inflight.Expired(),
+		"workers":                m.workerCount(),
+		"concurrency_limit":      m.adaptive.current(),
+		"goroutines":             runtime.NumGoroutine(),
+		"cache_items":            m.cache.Count(),
+		"dead_letters":           m.deadLetters.len(),
+		"pending_retries":        m.retryWheel.len(),
+		"spilled":                m.spill.len(),
+	})
+}
+
+func writeJSONBody(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// RequeueResult 强制重新解析与死信重新入队的结果
+type RequeueResult struct {
+	Requeued int      `json:"requeued"`
+	Inflight int      `json:"inflight"`          // 已在处理中而跳过
+	Rejected int      `json:"rejected"`          // 队列已满
+	Invalid  []string `json:"invalid,omitempty"` // 无法识别的 key / IP
+}
+
+// Requeue 忽略预刷新窗口，强制重新解析指定的缓存 key 或 IP (如上游修正数据后)
+// 任务进入后台刷新队列，不挤占首次查询；解析成功后覆盖原有结果 (包括人工标记)
+func (m *Manager) Requeue(items []string) RequeueResult {
+	var res RequeueResult
+	for _, item := range items {
+		ip := item
+		if subnet := keyToSubnet(item); subnet != nil {
+			ip = subnet.IP.String()
+		}
+		key, err := m.KeyForIP(ip)
+		if err != nil {
+			res.Invalid = append(res.Invalid, item)
+			continue
+		}
+
+		if !m.inflight.TryAdd(key) {
+			res.Inflight++
+			continue
+		}
+		if !m.refreshQueue.tryPush(job{IP: ip, Background: true, Force: true}) {
+			m.inflight.Delete(key)
+			res.Rejected++
+			continue
+		}
+		res.Requeued++
+	}
+
+	if res.Requeued > 0 {
+		adminLog.Info("强制重新解析", "count", res.Requeued)
+	}
+	return res
+}
+
+// HandleAdminRequeue POST: 强制重新解析，?key= / ?ip= 指定单个，或 body 为 key / IP 列表 (JSON 数组或按行分隔)
+func (m *Manager) HandleAdminRequeue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var items []string
+	for _, name := range []string{"key", "ip"} {
+		if v := r.URL.Query().Get(name); v != "" {
+			items = append(items, v)
+		}
+	}
+	if len(items) == 0 {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBatchBodySize))
+		if err != nil {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if items, err = parseBatchBody(body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if len(items) > MaxRequeueKeys {
+		http.Error(w, "too many keys", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	writeJSONBody(w, http.StatusOK, m.Requeue(items))
+}
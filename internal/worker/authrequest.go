@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// HandleAuthRequest 兼容 nginx auth_request / HAProxy 的查询接口
+// 从配置的请求头读取 IP (仅在直连方为受信任代理时采信)，始终返回 200 且不带 body，
+// Tag 通过 X-IP-Tag 响应头返回 (未命中时为空)，便于网关按省份 / 运营商路由
+func (m *Manager) HandleAuthRequest(w http.ResponseWriter, r *http.Request) {
+	ip := m.authRequestIP(r)
+	if ip == "" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if normalized, errMsg := m.normalizeIP(ip); errMsg == "" {
+		res := m.applyFallback(m.lookup(r.Context(), normalized))
+		setCacheHeaders(w, res)
+		w.Header().Set("X-IP-Tag", res.Tag)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *Manager) authRequestIP(r *http.Request) string {
+	remote := remoteIP(r.RemoteAddr)
+	if remote == nil || m.isTrustedProxy(remote) {
+		if v := strings.TrimSpace(r.Header.Get(m.authRequestHeader)); v != "" {
+			// 兼容 X-Forwarded-For 形式，取第一个地址
+			if i := strings.IndexByte(v, ','); i >= 0 {
+				v = strings.TrimSpace(v[:i])
+			}
+			if ip := net.ParseIP(v); ip != nil {
+				return ip.String()
+			}
+		}
+	}
+	if remote != nil {
+		return remote.String()
+	}
+	return ""
+}
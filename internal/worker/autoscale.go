@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"ip-resolver/internal/logging"
+	"time"
+)
+
+var autoscaleLog = logging.For("Autoscale")
+
+// ======== 自动伸缩参数 =========
+const (
+	autoscaleInterval    = 5 * time.Second
+	autoscaleTargetDrain = 2 * time.Second // 期望在该时间内清空当前积压
+)
+
+// workerCount 返回当前运行中的 Worker 数量
+func (m *Manager) workerCount() int {
+	return int(m.workers.Load())
+}
+
+// spawnWorkers 启动 n 个 Worker
+func (m *Manager) spawnWorkers(n int) {
+	for i := 0; i < n; i++ {
+		m.workers.Add(1)
+		m.wg.Add(1)
+		go m.worker(int(m.nextWorkerID.Add(1)) - 1)
+	}
+}
+
+// desiredWorkers 按队列积压与上游平均耗时估算所需 Worker 数
+// 扩容一步到位，缩容每个周期只减少一个，避免抖动
+func (m *Manager) desiredWorkers(cur int) int {
+	// 配额耗尽暂停期间积压不代表处理能力不足
+	if m.quotaGate.isPaused() {
+		return cur
+	}
+
+	depth := m.queue.len() + m.refreshQueue.len()
+
+	latency := m.fetchLatency.value()
+	if latency <= 0 {
+		latency = m.fetchTimeout
+	}
+
+	want := int((time.Duration(depth)*latency + autoscaleTargetDrain - 1) / autoscaleTargetDrain)
+	switch {
+	case want > cur:
+		return min(want, m.maxWorkers)
+	case want < cur:
+		return max(cur-1, m.minWorkers)
+	}
+	return cur
+}
+
+// runAutoscale 在 [minWorkers, maxWorkers] 之间定期调整 Worker 数量
+func (m *Manager) runAutoscale() {
+	if m.maxWorkers <= 0 {
+		return
+	}
+
+	m.scaleWg.Add(1)
+	go func() {
+		defer m.scaleWg.Done()
+
+		ticker := time.NewTicker(autoscaleInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-m.scaleQuit:
+				return
+			}
+
+			// 上一次缩容尚未被 Worker 领取时不再调整
+			if len(m.retire) > 0 {
+				continue
+			}
+
+			cur := m.workerCount()
+			want := m.desiredWorkers(cur)
+			switch {
+			case want > cur:
+				m.spawnWorkers(want - cur)
+				autoscaleLog.Info("Worker 扩容", "from", cur, "to", want, "queue", m.queue.len()+m.refreshQueue.len(), "latency", m.fetchLatency.value())
+			case want < cur:
+				m.retire <- struct{}{}
+				autoscaleLog.Debug("Worker 缩容", "from", cur, "to", want)
+			}
+		}
+	}()
+}
+
+// stopAutoscale 停止伸缩 (需在关闭解析队列之前调用)
+func (m *Manager) stopAutoscale() {
+	close(m.scaleQuit)
+	m.scaleWg.Wait()
+}
@@ -0,0 +1,100 @@
+package worker
+
+import (
+	"ip-resolver/internal/monitor"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// ======== 背压参数 =========
+const (
+	minRetryAfter = 1 * time.Second
+	maxRetryAfter = 60 * time.Second
+)
+
+// latencyEWMA 上游耗时的指数加权平均 (alpha = 1/8)
+type latencyEWMA struct {
+	nanos atomic.Int64
+}
+
+func (e *latencyEWMA) observe(d time.Duration) {
+	for {
+		old := e.nanos.Load()
+		next := int64(d)
+		if old != 0 {
+			next = old + (int64(d)-old)/8
+		}
+		if e.nanos.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+func (e *latencyEWMA) value() time.Duration {
+	return time.Duration(e.nanos.Load())
+}
+
+// retryAfter 根据队列深度、并发数与上游平均耗时估算队列排空所需时间
+func (m *Manager) retryAfter() time.Duration {
+	workers := m.workerCount()
+	if workers < 1 {
+		workers = 1
+	}
+
+	latency := m.fetchLatency.value()
+	if latency <= 0 {
+		latency = m.fetchTimeout
+	}
+
+	d := time.Duration(m.queue.len()) * latency / time.Duration(workers)
+	return min(max(d, minRetryAfter), maxRetryAfter)
+}
+
+// setRetryAfter 写出 Retry-After (秒，向上取整)
+func (m *Manager) setRetryAfter(w http.ResponseWriter) {
+	secs := int64(math.Ceil(m.retryAfter().Seconds()))
+	w.Header().Set("Retry-After", strconv.FormatInt(secs, 10))
+}
+
+// shouldShedRefresh 队列积压或处理中任务超过阈值时跳过预刷新 (仍返回旧值)，把容量留给首次查询
+func (m *Manager) shouldShedRefresh() bool {
+	if m.shedQueueDepth > 0 && m.queue.len()+m.refreshQueue.len() >= m.shedQueueDepth {
+		return true
+	}
+	if m.shedInflight > 0 && m.inflight.Len() >= m.shedInflight {
+		return true
+	}
+	return false
+}
+
+// QueueStats 返回队列深度、处理中数量等指标，供 /status 展示
+func (m *Manager) QueueStats() monitor.QueueStats {
+	qs := monitor.QueueStats{
+		QueueDepth:        m.queue.len(),
+		RefreshQueueDepth: m.refreshQueue.len(),
+		QueueCapacity:     m.queue.cap(),
+		Inflight:          m.inflight.Len(),
+		Workers:           m.workerCount(),
+		BusyWorkers:       m.workerStats.busy(),
+		ShedRefreshes:     m.shedRefreshes.Load(),
+		InflightExpired:   m.inflight.Expired(),
+		ConcurrencyLimit:  m.adaptive.current(),
+		Spilled:           m.spill.len(),
+		QuotaPaused:       m.quotaGate.isPaused(),
+	}
+	if qs.QueueCapacity > 0 {
+		qs.QueueUtilization = ratio(qs.QueueDepth, qs.QueueCapacity)
+	}
+	if qs.Workers > 0 {
+		qs.WorkerUtilization = ratio(qs.BusyWorkers, qs.Workers)
+	}
+	return qs
+}
+
+// ratio 保留三位小数，缩容过程中 busy 可能短暂大于 workers，上限为 1
+func ratio(n, total int) float64 {
+	return min(math.Round(float64(n)/float64(total)*1000)/1000, 1)
+}
@@ -0,0 +1,155 @@
+package worker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ======== 批量查询参数 =========
+const (
+	MaxBatchSize     = 1000
+	MaxPathIPs       = 100 // GET /ip1,ip2 路径中的 IP 上限
+	maxBatchBodySize = 1 << 20 // 1MB
+)
+
+// batchItem 批量查询中单个 IP 的结果
+type batchItem struct {
+	Key   string `json:"key,omitempty"`
+	Tag   string `json:"tag,omitempty"`
+	Cache string `json:"cache,omitempty"`
+	TTL   int64  `json:"ttl,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// HandleBatch 批量查询: POST JSON 数组或按行分隔的 IP 列表，返回 IP -> 结果
+// 未命中的 IP 会进入异步解析队列，与单个查询行为一致
+func (m *Manager) HandleBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBatchBodySize))
+	if err != nil {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	ips, err := parseBatchBody(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(ips) > MaxBatchSize {
+		http.Error(w, fmt.Sprintf("too many ips (max %d)", MaxBatchSize), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	results := make(map[string]batchItem, len(ips))
+	for _, rawIP := range ips {
+		results[rawIP] = m.lookupBatchItem(r.Context(), rawIP)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(results)
+}
+
+// serveMultiLookup 处理 GET /1.2.3.4,5.6.7.8: 默认按输入顺序每行输出一个 Tag
+// (未命中或非法时为空行，fallback_response 定制了 body 时输出 body)，JSON 格式与 /batch 一致
+func (m *Manager) serveMultiLookup(w http.ResponseWriter, r *http.Request, ips []string) {
+	if len(ips) > MaxPathIPs {
+		w.WriteHeader(http.StatusRequestURITooLong)
+		_, _ = fmt.Fprintf(w, "too many ips (max %d)", MaxPathIPs)
+		return
+	}
+
+	if wantsJSON(r) {
+		results := make(map[string]batchItem, len(ips))
+		for _, rawIP := range dedupIPs(ips) {
+			results[rawIP] = m.lookupBatchItem(r.Context(), rawIP)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(results)
+		return
+	}
+
+	var buf strings.Builder
+	for _, rawIP := range ips {
+		ip, errMsg := m.normalizeIP(strings.TrimSpace(rawIP))
+		if errMsg == "" {
+			buf.WriteString(m.applyFallback(m.lookup(r.Context(), ip)).text())
+		}
+		buf.WriteByte('\n')
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(buf.String()))
+}
+
+func (m *Manager) lookupBatchItem(ctx context.Context, rawIP string) batchItem {
+	ip, errMsg := m.normalizeIP(rawIP)
+	if errMsg != "" {
+		return batchItem{Error: errMsg}
+	}
+
+	res := m.applyFallback(m.lookup(ctx, ip))
+	return batchItem{
+		Key:   res.Key,
+		Tag:   res.Tag,
+		Cache: res.Status,
+		TTL:   int64(res.Remaining.Seconds()),
+	}
+}
+
+// parseBatchBody 支持 JSON 字符串数组或按行 (逗号/空白) 分隔的纯文本
+func parseBatchBody(body []byte) ([]string, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil, fmt.Errorf("empty body")
+	}
+
+	if trimmed[0] == '[' {
+		var ips []string
+		if err := json.Unmarshal(trimmed, &ips); err != nil {
+			return nil, fmt.Errorf("invalid json array: %v", err)
+		}
+		return dedupIPs(ips), nil
+	}
+
+	var ips []string
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	for scanner.Scan() {
+		for _, field := range strings.FieldsFunc(scanner.Text(), func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\r'
+		}) {
+			ips = append(ips, field)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return dedupIPs(ips), nil
+}
+
+func dedupIPs(ips []string) []string {
+	seen := make(map[string]struct{}, len(ips))
+	res := ips[:0]
+	for _, ip := range ips {
+		ip = strings.TrimSpace(ip)
+		if ip == "" {
+			continue
+		}
+		if _, ok := seen[ip]; ok {
+			continue
+		}
+		seen[ip] = struct{}{}
+		res = append(res, ip)
+	}
+	return res
+}
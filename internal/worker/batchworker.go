@@ -0,0 +1,138 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"ip-resolver/internal/model"
+	"ip-resolver/internal/provider"
+	"ip-resolver/internal/requestid"
+	"time"
+)
+
+// ======== 批量解析参数 =========
+const MaxWorkerBatchSize = 100
+
+var errMissingBatchResult = errors.New("批量查询结果中缺少该 IP")
+
+// handle 提供商支持批量查询时，从队列中再取出最多 batchSize-1 个任务合并为一次上游请求
+func (m *Manager) handle(id int, st *workerStat, j job) {
+	p := m.providerFor(j.IP)
+	bp, ok := p.(provider.BatchProvider)
+	if !ok || m.batchSize <= 1 {
+		m.process(id, st, j)
+		return
+	}
+
+	jobs := []job{j}
+	var others []job // 使用其他提供商的任务 (如 IPv6 专用提供商)，逐个处理
+
+collect:
+	for len(jobs) < m.batchSize {
+		// 同样优先取首次查询任务
+		next, ok := m.queue.tryPop(id)
+		if !ok {
+			if next, ok = m.refreshQueue.tryPop(id); !ok {
+				break collect
+			}
+		}
+
+		if m.providerFor(next.IP) == p {
+			jobs = append(jobs, next)
+		} else {
+			others = append(others, next)
+		}
+	}
+
+	if len(jobs) == 1 {
+		m.process(id, st, j)
+	} else {
+		m.processBatch(id, st, bp, jobs)
+	}
+	for _, o := range others {
+		m.process(id, st, o)
+	}
+}
+
+// processBatch 以一次 FetchBatch 解析多个不同子网的任务，结果逐个写入缓存
+func (m *Manager) processBatch(id int, st *workerStat, bp provider.BatchProvider, jobs []job) {
+	st.begin(jobs[0].IP)
+	defer st.end()
+	for i := range jobs {
+		jobs[i].assignID()
+	}
+	defer m.recoverPanic(id, st, jobs[0])
+
+	var tasks []task
+	retrying := make(map[string]bool)
+	defer func() {
+		for _, t := range tasks {
+			if !retrying[t.key] {
+				m.inflight.Delete(t.key)
+			}
+		}
+	}()
+
+	for _, j := range jobs {
+		t, ok := m.prepare(j)
+		if !ok {
+			m.inflight.Delete(t.key)
+			continue
+		}
+		tasks = append(tasks, t)
+	}
+	if len(tasks) == 0 {
+		return
+	}
+
+	// 一次批量请求只占用一个并发名额与速率许可
+	if !m.quotaGate.wait(m.halt) || !m.adaptive.acquire(m.halt) {
+		for _, t := range tasks {
+			m.abandon(t.job)
+		}
+		return
+	}
+	defer m.adaptive.release()
+
+	if !m.limiter.wait(m.halt) {
+		for _, t := range tasks {
+			m.abandon(t.job)
+		}
+		return
+	}
+
+	ips := make([]string, len(tasks))
+	for i, t := range tasks {
+		ips[i] = t.IP
+	}
+
+	ctx, cancel := context.WithTimeout(requestid.WithJob(requestid.NewContext(m.runCtx, tasks[0].RequestID), tasks[0].ID), m.timeoutFor(tasks[0].IP))
+	defer cancel()
+
+	start := time.Now()
+	results, err := bp.FetchBatch(ctx, ips)
+	latency := time.Since(start)
+
+	if err != nil && m.runCtx.Err() != nil {
+		for _, t := range tasks {
+			m.abandon(t.job)
+		}
+		return
+	}
+
+	m.fetchLatency.observe(latency)
+	m.adaptive.observe(latency, err != nil)
+	st.observe(latency, err != nil)
+
+	workerLog.Debug("批量查询完成", "worker", id, "provider", bp.Name(), "count", len(ips), "latency", latency, "job_id", tasks[0].ID, "err", err)
+
+	for _, t := range tasks {
+		var info *model.IPInfo
+		itemErr := err
+		if itemErr == nil {
+			if info = results[t.IP]; info == nil {
+				itemErr = errMissingBatchResult
+			}
+		}
+		retrying[t.key] = m.complete(id, bp, t, info, itemErr, latency)
+	}
+}
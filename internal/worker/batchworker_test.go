@@ -0,0 +1,131 @@
+package worker
+
+import (
+	"context"
+	"ip-resolver/internal/config"
+	"ip-resolver/internal/model"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// fakeBatchProvider 记录每次 FetchBatch 的 IP，missing 中的 IP 不返回结果
+type fakeBatchProvider struct {
+	mu      sync.Mutex
+	batches [][]string
+	single  int
+	missing map[string]bool
+}
+
+func (p *fakeBatchProvider) Name() string { return "fake" }
+
+func (p *fakeBatchProvider) Fetch(ctx context.Context, ip string) (*model.IPInfo, error) {
+	p.mu.Lock()
+	p.single++
+	p.mu.Unlock()
+	return &model.IPInfo{Province: "北京", ISP: "移动"}, nil
+}
+
+func (p *fakeBatchProvider) FetchBatch(ctx context.Context, ips []string) (map[string]*model.IPInfo, error) {
+	p.mu.Lock()
+	p.batches = append(p.batches, slices.Clone(ips))
+	p.mu.Unlock()
+	res := make(map[string]*model.IPInfo)
+	for _, ip := range ips {
+		if !p.missing[ip] {
+			res[ip] = &model.IPInfo{Province: "北京", ISP: "移动"}
+		}
+	}
+	return res, nil
+}
+
+func newBatchManager(t *testing.T, p *fakeBatchProvider, batchSize int) *Manager {
+	t.Helper()
+	m := NewManager(p, &config.Config{
+		CacheTTLSeconds:   3600,
+		CacheRefreshRatio: 10,
+		WorkerConcurrency: 1,
+		QueueSize:         16,
+		QueueShards:       1,
+		IPv6PrefixLen:     48,
+		WorkerBatchSize:   batchSize,
+		Providers:         []config.ProviderConfig{{Name: "fake", Role: config.RolePrimary}},
+	})
+	t.Cleanup(m.cache.Close)
+	return m
+}
+
+// 队列中的 N 个子网合并为一次 FetchBatch，结果逐个写入缓存
+func TestHandleBatch(t *testing.T) {
+	ips := []string{"1.1.1.1", "2.2.2.2", "3.3.3.3", "4.4.4.4", "5.5.5.5"}
+	tests := []struct {
+		name      string
+		batchSize int
+		queued    int
+		batches   []int // 每次 FetchBatch 的 IP 数
+		single    int   // 逐个 Fetch 的次数
+	}{
+		{"batch disabled", 1, 3, nil, 3},
+		{"whole queue in one call", 4, 3, []int{3}, 0},
+		{"split by batch size", 4, 5, []int{4}, 1},
+		{"single job falls back to Fetch", 4, 1, nil, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &fakeBatchProvider{}
+			m := newBatchManager(t, p, tt.batchSize)
+			for _, ip := range ips[:tt.queued] {
+				if !m.inflight.TryAdd(m.cacheKey(ip)) || !m.queue.tryPush(job{IP: ip}) {
+					t.Fatal("enqueue failed")
+				}
+			}
+
+			st := m.workerStats.register(0)
+			for {
+				j, ok := m.queue.tryPop(0)
+				if !ok {
+					break
+				}
+				m.handle(0, st, j)
+			}
+
+			var sizes []int
+			for _, b := range p.batches {
+				sizes = append(sizes, len(b))
+			}
+			if !slices.Equal(sizes, tt.batches) || p.single != tt.single {
+				t.Fatalf("FetchBatch sizes = %v, Fetch calls = %d; want %v, %d", sizes, p.single, tt.batches, tt.single)
+			}
+			for _, ip := range ips[:tt.queued] {
+				key := m.cacheKey(ip)
+				if tag, found, _, _ := m.cache.Get(key); !found || tag == "" {
+					t.Fatalf("%s not cached", ip)
+				}
+				if !m.inflight.TryAdd(key) {
+					t.Fatalf("%s still marked in flight", ip)
+				}
+			}
+		})
+	}
+}
+
+// 批量结果中缺少的 IP 按单个失败处理 (重试耗尽后进入死信)，其余正常写入
+func TestHandleBatchMissingResult(t *testing.T) {
+	p := &fakeBatchProvider{missing: map[string]bool{"2.2.2.2": true}}
+	m := newBatchManager(t, p, 4)
+	for _, ip := range []string{"1.1.1.1", "2.2.2.2"} {
+		m.inflight.TryAdd(m.cacheKey(ip))
+		m.queue.tryPush(job{IP: ip})
+	}
+
+	j, _ := m.queue.tryPop(0)
+	m.handle(0, m.workerStats.register(0), j)
+
+	if _, found, _, _ := m.cache.Get(m.cacheKey("1.1.1.1")); !found {
+		t.Fatal("1.1.1.1 not cached")
+	}
+	items := m.deadLetters.list()
+	if len(items) != 1 || items[0].IP != "2.2.2.2" || items[0].Error != errMissingBatchResult.Error() {
+		t.Fatalf("dead letters = %+v", items)
+	}
+}
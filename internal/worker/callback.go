@@ -0,0 +1,149 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ip-resolver/internal/logging"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var callbackLog = logging.For("Callback")
+
+// ======== 完成回调参数 =========
+const (
+	maxPendingCallbacks = 1024
+	callbackPostTimeout = 5 * time.Second
+)
+
+// parseCallbackURL 校验 ?callback= 参数，仅允许 http / https 且主机在白名单内
+// 白名单项为主机名或 *.example.com (匹配所有子域名)，不区分大小写
+func parseCallbackURL(raw string, allowed []string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", errors.New("invalid callback url")
+	}
+	if !callbackHostAllowed(u.Hostname(), allowed) {
+		return "", errors.New("callback host not allowed")
+	}
+	return u.String(), nil
+}
+
+func callbackHostAllowed(host string, allowed []string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, a := range allowed {
+		a = strings.ToLower(a)
+		if suffix, ok := strings.CutPrefix(a, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == a {
+			return true
+		}
+	}
+	return false
+}
+
+// blockedCallbackAddr 回环、私有、链路本地 (含 169.254.169.254 元数据地址)、CGNAT 等内部地址
+func blockedCallbackAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() ||
+		cgnatPrefix.Contains(ip)
+}
+
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// newCallbackClient 回调使用的 HTTP 客户端: 在建立连接时 (DNS 解析之后) 检查目标地址，
+// 白名单主机被解析 (或重绑定) 到内部地址时拒绝连接；不跟随重定向、不使用代理
+func newCallbackClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: callbackPostTimeout}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+			if blockedCallbackAddr(ip) {
+				return fmt.Errorf("回调目标为内部地址: %s", ip)
+			}
+			return nil
+		}
+	}
+	return &http.Client{
+		Timeout: callbackPostTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: callbackPostTimeout,
+			MaxIdleConns:        16,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// registerCallback 在后台等待 key 解析结束后把结果 POST 到回调地址
+// 等待中的回调数量超过上限时返回 false
+func (m *Manager) registerCallback(res lookupResult, callbackURL string) bool {
+	select {
+	case m.callbackSem <- struct{}{}:
+	default:
+		return false
+	}
+
+	m.hookWg.Add(1)
+	go func() {
+		defer m.hookWg.Done()
+		defer func() { <-m.callbackSem }()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-m.stopCh:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		final := m.waitKey(ctx, res, m.callbackTimeout)
+		if err := m.postCallback(callbackURL, m.toResponse(final)); err != nil {
+			callbackLog.Warn("回调失败", "key", res.Key, "url", callbackURL, "err", err)
+			return
+		}
+		callbackLog.Debug("回调完成", "key", res.Key, "url", callbackURL, "status", final.Status)
+	}()
+	return true
+}
+
+func (m *Manager) postCallback(callbackURL string, resp LookupResponse) error {
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	httpResp, err := m.callbackClient.Post(callbackURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode >= 300 {
+		return fmt.Errorf("状态码: %d", httpResp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestParseCallbackURL(t *testing.T) {
+	allowed := []string{"hooks.example.com", "*.internal.example.org"}
+	tests := []struct {
+		raw string
+		ok  bool
+	}{
+		{"https://hooks.example.com/cb", true},
+		{"http://HOOKS.example.com:8080/cb", true},
+		{"https://a.internal.example.org/cb", true},
+		{"https://internal.example.org/cb", false}, // *. 只匹配子域名
+		{"https://evil.com/cb", false},
+		{"https://hooks.example.com.evil.com/cb", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"ftp://hooks.example.com/cb", false},
+		{"not a url", false},
+	}
+	for _, tt := range tests {
+		_, err := parseCallbackURL(tt.raw, allowed)
+		if (err == nil) != tt.ok {
+			t.Errorf("parseCallbackURL(%q) err = %v, want ok = %v", tt.raw, err, tt.ok)
+		}
+	}
+}
+
+func TestBlockedCallbackAddr(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1":        true,
+		"::1":              true,
+		"10.1.2.3":         true,
+		"172.16.0.1":       true,
+		"192.168.1.1":      true,
+		"169.254.169.254":  true,
+		"100.64.0.1":       true,
+		"0.0.0.0":          true,
+		"fe80::1":          true,
+		"fd00::1":          true,
+		"::ffff:127.0.0.1": true,
+		"8.8.8.8":          false,
+		"2001:4860::8888":  false,
+	}
+	for addr, want := range tests {
+		if got := blockedCallbackAddr(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("blockedCallbackAddr(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+// 白名单主机解析到回环地址 (如 DNS 重绑定) 时在建立连接阶段被拒绝
+func TestCallbackClientRejectsInternalDial(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	_, err := newCallbackClient(false).Post(srv.URL, "application/json", strings.NewReader("{}"))
+	if err == nil || !strings.Contains(err.Error(), "内部地址") {
+		t.Fatalf("expected dial to loopback to be rejected, got %v", err)
+	}
+
+	resp, err := newCallbackClient(true).Post(srv.URL, "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("allow_private: %v", err)
+	}
+	resp.Body.Close()
+}
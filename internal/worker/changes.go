@@ -0,0 +1,135 @@
+package worker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"ip-resolver/internal/cache"
+	"ip-resolver/internal/logging"
+	"net/http"
+	"time"
+)
+
+var webhookLog = logging.For("Webhook")
+
+// ======== 变更推送参数 =========
+const (
+	changeWebhookBatchSize = 100
+	changeWebhookInterval  = time.Second
+	changeWebhookTimeout   = 5 * time.Second
+)
+
+// HandleChanges 以 SSE 推送缓存变更事件
+func (m *Manager) HandleChanges(w http.ResponseWriter, r *http.Request) {
+	events, cancel := m.cache.Subscribe(0)
+	defer cancel()
+
+	streamSSE(w, r, events, m.streamsQuit, func(ev cache.ChangeEvent) string { return ev.Op })
+}
+
+// streamSSE 把通道中的事件以 SSE 写出，直到通道关闭、quit 关闭或客户端断开
+func streamSSE[T any](w http.ResponseWriter, r *http.Request, events <-chan T, quit <-chan struct{}, name func(T) string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// 长连接不受 Server 的 WriteTimeout 限制
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(15 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name(ev), data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		case <-quit:
+			return
+		}
+	}
+}
+
+// runChangeWebhook 订阅缓存变更并批量 POST 到 webhook 地址，缓存关闭时退出
+func (m *Manager) runChangeWebhook(url string) {
+	events, cancel := m.cache.Subscribe(4096)
+	client := &http.Client{Timeout: changeWebhookTimeout}
+
+	m.hookWg.Add(1)
+	go func() {
+		defer m.hookWg.Done()
+		defer cancel()
+
+		batch := make([]cache.ChangeEvent, 0, changeWebhookBatchSize)
+		ticker := time.NewTicker(changeWebhookInterval)
+		defer ticker.Stop()
+
+		flush := func() {
+			if len(batch) == 0 {
+				return
+			}
+			if err := postChanges(client, url, batch); err != nil {
+				webhookLog.Warn("推送变更失败", "count", len(batch), "err", err)
+			}
+			batch = batch[:0]
+		}
+
+		for {
+			select {
+			case ev, ok := <-events:
+				if !ok {
+					flush()
+					return
+				}
+				batch = append(batch, ev)
+				if len(batch) >= changeWebhookBatchSize {
+					flush()
+				}
+			case <-ticker.C:
+				flush()
+			}
+		}
+	}()
+}
+
+func postChanges(client *http.Client, url string, batch []cache.ChangeEvent) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("状态码: %d", resp.StatusCode)
+	}
+	return nil
+}
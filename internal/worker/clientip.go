@@ -0,0 +1,94 @@
+package worker
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// HandleSelf 查询调用方自身的出口 IP
+func (m *Manager) HandleSelf(w http.ResponseWriter, r *http.Request) {
+	ip := m.clientIP(r)
+	if ip == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("unable to determine client ip"))
+		return
+	}
+	m.serveLookup(w, r, ip)
+}
+
+// clientIP 获取客户端真实 IP
+// 仅当直连方为受信任代理 (或 Unix Socket) 时才采信 X-Forwarded-For / X-Real-IP
+func (m *Manager) clientIP(r *http.Request) string {
+	remote := remoteIP(r.RemoteAddr)
+	if remote != nil && !m.isTrustedProxy(remote) {
+		return remote.String()
+	}
+
+	// X-Forwarded-For 从右往左跳过受信任代理，第一个非代理地址即为客户端
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(hops[i]))
+			if ip == nil {
+				break
+			}
+			if !m.isTrustedProxy(ip) || i == 0 {
+				return ip.String()
+			}
+		}
+	}
+
+	if xrip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); xrip != nil {
+		return xrip.String()
+	}
+
+	if remote != nil {
+		return remote.String()
+	}
+	return ""
+}
+
+func (m *Manager) isTrustedProxy(ip net.IP) bool {
+	for _, n := range m.trustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteIP 解析 RemoteAddr，Unix Socket 连接返回 nil
+func remoteIP(addr string) net.IP {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return net.ParseIP(host)
+}
+
+// parseCIDRs 解析 CIDR 列表，单个 IP 视为 /32 或 /128
+func parseCIDRs(list []string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, s := range list {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.Contains(s, "/") {
+			if ip := net.ParseIP(s); ip != nil && ip.To4() != nil {
+				s += "/32"
+			} else {
+				s += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			slog.Warn("忽略无效的 CIDR", "cidr", s, "err", err)
+			continue
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
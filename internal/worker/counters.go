@@ -0,0 +1,67 @@
+package worker
+
+import (
+	"encoding/json"
+	"ip-resolver/internal/monitor"
+	"time"
+)
+
+// monitorCountersKey 监控累计计数在 SQLite app_state 表中的 key
+const monitorCountersKey = "monitor_counters"
+
+// restoreCounters 启动时恢复上次保存的监控计数，需在 Worker 启动前调用
+func (m *Manager) restoreCounters() {
+	if m.mon == nil || m.counterPersistInterval <= 0 || !m.cache.HasStore() {
+		return
+	}
+	data, ok, err := m.cache.LoadState(monitorCountersKey)
+	if err != nil {
+		cacheLog.Warn("读取监控计数失败", "err", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	var s monitor.CounterState
+	if err := json.Unmarshal([]byte(data), &s); err != nil {
+		cacheLog.Warn("监控计数格式无效, 重新计数", "err", err)
+		return
+	}
+	m.mon.RestoreCounters(s)
+	cacheLog.Info("已恢复监控计数", "total_requests", s.TotalRequests, "since", s.Since, "saved_at", s.SavedAt)
+}
+
+// runCounterPersistence 定期保存监控计数，关闭时由 Stop 保存最后一次
+func (m *Manager) runCounterPersistence() {
+	if m.mon == nil || m.counterPersistInterval <= 0 || !m.cache.HasStore() {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(m.counterPersistInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				m.saveCounters()
+			case <-m.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+func (m *Manager) saveCounters() {
+	if m.mon == nil || m.counterPersistInterval <= 0 || !m.cache.HasStore() {
+		return
+	}
+	s := m.mon.Counters()
+	data, err := json.Marshal(s)
+	if err != nil {
+		return
+	}
+	if err := m.cache.SaveState(monitorCountersKey, string(data), s.SavedAt.Unix()); err != nil {
+		cacheLog.Warn("保存监控计数失败", "err", err)
+	}
+}
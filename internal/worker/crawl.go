@@ -0,0 +1,87 @@
+package worker
+
+import (
+	"ip-resolver/internal/logging"
+	"time"
+)
+
+var crawlLog = logging.For("Crawl")
+
+// ======== 过期扫描参数 =========
+const (
+	maxCrawlBatch    = 10000 // 单轮最多处理的 key 数
+	defaultCrawlRate = 5
+)
+
+// runCrawl 定期扫描进入预刷新窗口的缓存条目并按速率送入后台刷新队列
+// 使长时间未被请求的子网也能在过期前完成刷新
+func (m *Manager) runCrawl() {
+	if m.crawlInterval <= 0 {
+		return
+	}
+
+	m.crawlWg.Add(1)
+	go func() {
+		defer m.crawlWg.Done()
+
+		ticker := time.NewTicker(m.crawlInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				m.crawlOnce()
+			case <-m.crawlQuit:
+				return
+			}
+		}
+	}()
+}
+
+// crawlOnce 执行一轮扫描，队列积压触发准入控制时提前结束本轮
+func (m *Manager) crawlOnce() {
+	keys := m.cache.RefreshDue(maxCrawlBatch)
+	if len(keys) == 0 {
+		return
+	}
+
+	rate := m.crawlRate
+	if rate <= 0 {
+		rate = defaultCrawlRate
+	}
+	pace := time.NewTicker(time.Second / time.Duration(rate))
+	defer pace.Stop()
+
+	enqueued, shed := 0, false
+	for _, key := range keys {
+		select {
+		case <-pace.C:
+		case <-m.crawlQuit:
+			return
+		}
+
+		if m.shouldShedRefresh() {
+			shed = true
+			break
+		}
+
+		subnet := keyToSubnet(key)
+		if subnet == nil || !m.inflight.TryAdd(key) {
+			continue
+		}
+
+		if m.refreshQueue.tryPush(job{IP: subnet.IP.String(), Background: true}) {
+			enqueued++
+		} else {
+			m.inflight.Delete(key)
+		}
+	}
+
+	crawlLog.Info("刷新扫描完成", "due", len(keys), "enqueued", enqueued, "shed", shed)
+}
+
+// stopCrawl 停止扫描 (需在关闭解析队列之前调用)
+func (m *Manager) stopCrawl() {
+	close(m.crawlQuit)
+	m.crawlWg.Wait()
+}
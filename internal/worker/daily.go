@@ -0,0 +1,338 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"ip-resolver/internal/monitor"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ======== 硬编码参数 =========
+const (
+	dailyCheckInterval = time.Minute     // 检查日期切换的间隔
+	dailyFlushInterval = 5 * time.Minute // 当天汇总写入 SQLite 的间隔 (重启后继续累计)
+	dailyRetentionDays = 400             // SQLite 中保留的天数
+	dailyMemoryDays    = 31              // 未配置 SQLite 时内存中保留的天数
+	dailyTopTags       = 10
+	dailyMaxTags       = 10000 // 单日统计的不同 Tag 数上限，避免异常数据撑爆内存
+	dailyDefaultDays   = 7
+	dailyMaxDays       = 366
+	dateLayout         = "2006-01-02"
+)
+
+// DailyReport 一天 (本地时区) 的汇总
+type DailyReport struct {
+	Date                string                        `json:"date"`
+	Complete            bool                          `json:"complete"` // false 为当天，仍在累计
+	UpdatedAt           time.Time                     `json:"updated_at"`
+	Lookups             int64                         `json:"lookups"`
+	Hits                int64                         `json:"hits"`
+	Misses              int64                         `json:"misses"`
+	HitRatio            float64                       `json:"hit_ratio"`
+	ProviderCalls       int64                         `json:"provider_calls"`
+	ProviderFailures    int64                         `json:"provider_failures"`
+	EstimatedQuotaSpend int64                         `json:"estimated_quota_spend"`     // 成功的上游调用数 (按次计费时即消耗的配额)
+	QuotaRemaining      *int64                        `json:"quota_remaining,omitempty"` // 最近一次查询到的剩余配额
+	Providers           map[string]DailyProviderCalls `json:"providers,omitempty"`
+	TopTags             []DailyTagCount               `json:"top_tags"` // 命中缓存的查询中出现最多的 Tag
+}
+
+// DailyProviderCalls 单个提供商当天的调用数
+type DailyProviderCalls struct {
+	Calls    int64 `json:"calls"`
+	Failures int64 `json:"failures"`
+}
+
+// DailyTagCount 当天返回某个 Tag 的查询数
+type DailyTagCount struct {
+	Tag     string `json:"tag"`
+	Lookups int64  `json:"lookups"`
+}
+
+// dailyTracker 累计当天的查询与上游调用
+// 提供商调用数取自 Monitor 的累计计数，与 provBase (当天开始或进程启动时的值) 相减得到
+// 每次查询都会调用 record，计数无锁累加；mu 只保护日期切换与汇总
+type dailyTracker struct {
+	mu       sync.Mutex
+	day      string
+	carried  DailyReport // 重启前已持久化的当天汇总，在此基础上继续累计
+	counts   atomic.Pointer[dailyCounts]
+	provBase map[string]monitor.ProviderStats
+	history  []DailyReport // 未配置 SQLite 时已结束的汇总 (最新的在后)
+}
+
+// dailyCounts 本进程当天的查询计数，日期切换时整体替换
+type dailyCounts struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+	tags   sync.Map     // tag -> *atomic.Int64
+	ntags  atomic.Int64 // 不同 Tag 数
+}
+
+func (t *dailyTracker) record(hit bool, tag string) {
+	c := t.counts.Load()
+	if c == nil {
+		return
+	}
+	if !hit {
+		c.misses.Add(1)
+		return
+	}
+	c.hits.Add(1)
+	if tag == "" {
+		return
+	}
+	if v, ok := c.tags.Load(tag); ok {
+		v.(*atomic.Int64).Add(1)
+		return
+	}
+	// 并发插入时可能略超过上限，只影响内存占用
+	if c.ntags.Load() >= dailyMaxTags {
+		return
+	}
+	v, loaded := c.tags.LoadOrStore(tag, new(atomic.Int64))
+	if !loaded {
+		c.ntags.Add(1)
+	}
+	v.(*atomic.Int64).Add(1)
+}
+
+// reset 开始新的一天
+func (t *dailyTracker) reset(day string, carried DailyReport, provBase map[string]monitor.ProviderStats) {
+	t.day = day
+	t.carried = carried
+	t.counts.Store(&dailyCounts{})
+	t.provBase = provBase
+}
+
+// build 合并重启前的汇总与本进程的计数，调用方持有 t.mu
+func (t *dailyTracker) build(prov map[string]monitor.ProviderStats, quota int64) DailyReport {
+	c := t.carried
+	cur := t.counts.Load()
+	if cur == nil {
+		cur = &dailyCounts{}
+	}
+	r := DailyReport{
+		Date:                t.day,
+		UpdatedAt:           time.Now(),
+		Hits:                c.Hits + cur.hits.Load(),
+		Misses:              c.Misses + cur.misses.Load(),
+		ProviderCalls:       c.ProviderCalls,
+		ProviderFailures:    c.ProviderFailures,
+		EstimatedQuotaSpend: c.EstimatedQuotaSpend,
+		Providers:           make(map[string]DailyProviderCalls, len(prov)),
+	}
+	r.Lookups = r.Hits + r.Misses
+	if r.Lookups > 0 {
+		r.HitRatio = float64(r.Hits) / float64(r.Lookups)
+	}
+	if quota >= 0 {
+		r.QuotaRemaining = &quota
+	}
+
+	for name, pc := range c.Providers {
+		r.Providers[name] = pc
+	}
+	for name, cur := range prov {
+		base := t.provBase[name]
+		calls, fails := cur.TotalRequests-base.TotalRequests, cur.FailCount-base.FailCount
+		if calls <= 0 && fails <= 0 {
+			continue
+		}
+		pc := r.Providers[name]
+		pc.Calls += calls
+		pc.Failures += fails
+		r.Providers[name] = pc
+		r.ProviderCalls += calls
+		r.ProviderFailures += fails
+		r.EstimatedQuotaSpend += cur.SuccessCount - base.SuccessCount
+	}
+
+	// 重启前只保留了前 dailyTopTags 个 Tag，合并后的排名为近似值
+	tags := make(map[string]int64, int(cur.ntags.Load())+len(c.TopTags))
+	for _, tc := range c.TopTags {
+		tags[tc.Tag] += tc.Lookups
+	}
+	cur.tags.Range(func(k, v any) bool {
+		tags[k.(string)] += v.(*atomic.Int64).Load()
+		return true
+	})
+	r.TopTags = make([]DailyTagCount, 0, len(tags))
+	for tag, n := range tags {
+		r.TopTags = append(r.TopTags, DailyTagCount{Tag: tag, Lookups: n})
+	}
+	sort.Slice(r.TopTags, func(i, j int) bool {
+		if r.TopTags[i].Lookups != r.TopTags[j].Lookups {
+			return r.TopTags[i].Lookups > r.TopTags[j].Lookups
+		}
+		return r.TopTags[i].Tag < r.TopTags[j].Tag
+	})
+	if len(r.TopTags) > dailyTopTags {
+		r.TopTags = r.TopTags[:dailyTopTags]
+	}
+	return r
+}
+
+func (m *Manager) providerCounters() map[string]monitor.ProviderStats {
+	if m.mon == nil {
+		return nil
+	}
+	return m.mon.ProviderStats()
+}
+
+// runDailyReport 接手当天已持久化的汇总，每天结束时记录日志并写入 SQLite
+func (m *Manager) runDailyReport() {
+	today := time.Now().Format(dateLayout)
+	carried, _ := m.loadDaily(today)
+
+	m.daily.mu.Lock()
+	m.daily.reset(today, carried, m.providerCounters())
+	m.daily.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(dailyCheckInterval)
+		defer ticker.Stop()
+		lastFlush := time.Now()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				if m.rollDaily(now) || now.Sub(lastFlush) >= dailyFlushInterval {
+					m.saveDaily()
+					lastFlush = now
+				}
+			case <-m.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// rollDaily 日期切换时结束前一天的汇总，返回是否发生切换
+func (m *Manager) rollDaily(now time.Time) bool {
+	day := now.Format(dateLayout)
+	prov := m.providerCounters()
+
+	m.daily.mu.Lock()
+	if day == m.daily.day {
+		m.daily.mu.Unlock()
+		return false
+	}
+	r := m.daily.build(prov, m.lastQuota.Load())
+	r.Complete = true
+	m.daily.reset(day, DailyReport{}, prov)
+	if !m.cache.HasStore() {
+		m.daily.history = append(m.daily.history, r)
+		if len(m.daily.history) > dailyMemoryDays {
+			m.daily.history = m.daily.history[1:]
+		}
+	}
+	m.daily.mu.Unlock()
+
+	cacheLog.Info("每日汇总", "date", r.Date, "lookups", r.Lookups, "hit_ratio", strconv.FormatFloat(r.HitRatio, 'f', 4, 64),
+		"provider_calls", r.ProviderCalls, "provider_failures", r.ProviderFailures, "estimated_quota_spend", r.EstimatedQuotaSpend,
+		"top_tags", topTagNames(r.TopTags))
+	m.persistDaily(r)
+	return true
+}
+
+// saveDaily 保存当天进行中的汇总 (定期及关闭时调用)
+func (m *Manager) saveDaily() {
+	m.persistDaily(m.currentDaily())
+}
+
+func (m *Manager) currentDaily() DailyReport {
+	prov := m.providerCounters()
+	m.daily.mu.Lock()
+	defer m.daily.mu.Unlock()
+	return m.daily.build(prov, m.lastQuota.Load())
+}
+
+func (m *Manager) persistDaily(r DailyReport) {
+	if !m.cache.HasStore() || r.Date == "" {
+		return
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	keepAfter := time.Now().AddDate(0, 0, -dailyRetentionDays).Format(dateLayout)
+	if err := m.cache.SaveDailyReport(r.Date, string(data), r.UpdatedAt.Unix(), keepAfter); err != nil {
+		cacheLog.Warn("保存每日汇总失败", "date", r.Date, "err", err)
+	}
+}
+
+func (m *Manager) loadDaily(day string) (DailyReport, bool) {
+	if !m.cache.HasStore() {
+		return DailyReport{}, false
+	}
+	recs, err := m.cache.DailyReports(context.Background(), day, day)
+	if err != nil {
+		cacheLog.Warn("读取每日汇总失败", "date", day, "err", err)
+		return DailyReport{}, false
+	}
+	if len(recs) == 0 {
+		return DailyReport{}, false
+	}
+	var r DailyReport
+	if err := json.Unmarshal([]byte(recs[0].Data), &r); err != nil {
+		return DailyReport{}, false
+	}
+	return r, true
+}
+
+func topTagNames(tags []DailyTagCount) []string {
+	names := make([]string, len(tags))
+	for i, t := range tags {
+		names[i] = t.Tag
+	}
+	return names
+}
+
+// HandleDailyStats GET /stats/daily: 最近 ?days= 天 (默认 7，含当天) 的每日汇总，按日期降序
+func (m *Manager) HandleDailyStats(w http.ResponseWriter, r *http.Request) {
+	days := dailyDefaultDays
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > dailyMaxDays {
+			http.Error(w, "invalid days, expected 1-366", http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+
+	today := m.currentDaily()
+	reports := []DailyReport{today}
+	if days > 1 {
+		now := time.Now()
+		from := now.AddDate(0, 0, -(days - 1)).Format(dateLayout)
+		if m.cache.HasStore() {
+			recs, err := m.cache.DailyReports(r.Context(), from, now.AddDate(0, 0, -1).Format(dateLayout))
+			if err != nil {
+				cacheLog.Error("读取每日汇总失败", "err", err)
+				http.Error(w, "Failed to retrieve statistics from database", http.StatusInternalServerError)
+				return
+			}
+			for _, rec := range recs {
+				var d DailyReport
+				if err := json.Unmarshal([]byte(rec.Data), &d); err == nil && d.Date != today.Date {
+					reports = append(reports, d)
+				}
+			}
+		} else {
+			m.daily.mu.Lock()
+			for i := len(m.daily.history) - 1; i >= 0; i-- {
+				if d := m.daily.history[i]; d.Date >= from {
+					reports = append(reports, d)
+				}
+			}
+			m.daily.mu.Unlock()
+		}
+	}
+	writeJSONBody(w, http.StatusOK, map[string]any{"days": reports})
+}
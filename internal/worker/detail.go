@@ -0,0 +1,63 @@
+package worker
+
+import (
+	"encoding/json"
+	"ip-resolver/internal/model"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// detailResponse 标准化后的省份 / 运营商原始值 (Tag 映射之前)
+type detailResponse struct {
+	IP           string `json:"ip"`
+	Key          string `json:"key"`
+	Tag          string `json:"tag,omitempty"`
+	Province     string `json:"province,omitempty"`
+	ISP          string `json:"isp,omitempty"`
+	ProvinceCode string `json:"province_code,omitempty"`
+	ISPCode      string `json:"isp_code,omitempty"`
+	Cache        string `json:"cache"`
+	TTL          int64  `json:"ttl"`
+}
+
+// HandleDetail 返回 IP 的省份与运营商原文: GET /detail/{ip}
+// 未命中时与普通查询一样加入解析队列并返回 202，支持 ?wait=1
+func (m *Manager) HandleDetail(w http.ResponseWriter, r *http.Request) {
+	rawIP := strings.TrimPrefix(r.URL.Path, "/detail/")
+	ip, errMsg := m.normalizeIP(rawIP)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	res := m.lookup(r.Context(), ip)
+	if res.Status == CacheMiss && wantsWait(r) {
+		res = m.waitResolved(r.Context(), res)
+	}
+
+	setCacheHeaders(w, res)
+	if res.Status == CacheRejected {
+		m.setRetryAfter(w)
+	}
+
+	resp := detailResponse{
+		IP:    res.IP,
+		Key:   res.Key,
+		Tag:   res.Tag,
+		Cache: res.Status,
+		TTL:   int64(res.Remaining / time.Second),
+	}
+	if res.Tag != "" {
+		if info := model.ParseDetail(m.cache.GetDetail(res.Key)); info != nil {
+			resp.Province = info.Province
+			resp.ISP = info.ISP
+			resp.ProvinceCode = info.ProvinceCode
+			resp.ISPCode = info.ISPCode
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(res.Code)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,88 @@
+package worker
+
+import (
+	"ip-resolver/internal/monitor"
+	"sync"
+	"time"
+)
+
+// ======== 硬编码参数 =========
+const (
+	dropRateSampleInterval = 10 * time.Second
+	dropRateWindow         = time.Minute // 丢弃速率按最近 1 分钟计算
+)
+
+type dropSample struct {
+	at    time.Time
+	count int64
+}
+
+// dropRateTracker 定期采样持久化丢弃的累计数，用于计算丢弃速率 (偶发丢弃与持续丢弃区分对待)
+type dropRateTracker struct {
+	mu      sync.Mutex
+	samples []dropSample
+}
+
+func (t *dropRateTracker) add(s dropSample) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.samples = append(t.samples, s)
+	cutoff := s.at.Add(-dropRateWindow)
+	i := 0
+	for i < len(t.samples)-1 && !t.samples[i+1].at.After(cutoff) {
+		i++
+	}
+	t.samples = t.samples[i:]
+}
+
+// perMinute 以窗口内最早的采样为基准计算到 cur 为止的每分钟丢弃数
+func (t *dropRateTracker) perMinute(cur dropSample) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.samples) == 0 {
+		return 0
+	}
+	base := t.samples[0]
+	elapsed := cur.at.Sub(base.at)
+	if elapsed < time.Second || cur.count <= base.count {
+		return 0
+	}
+	return float64(cur.count-base.count) / elapsed.Minutes()
+}
+
+// runDropRateSampler 定期记录持久化丢弃的累计数
+func (m *Manager) runDropRateSampler() {
+	if !m.cache.HasStore() {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(dropRateSampleInterval)
+		defer ticker.Stop()
+
+		for {
+			m.dropRate.add(dropSample{at: time.Now(), count: m.cache.DroppedCount()})
+			select {
+			case <-ticker.C:
+			case <-m.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// PersistenceDropRate 返回最近 1 分钟内持久化丢弃更新的速率 (条/分钟)
+func (m *Manager) PersistenceDropRate() float64 {
+	return m.dropRate.perMinute(dropSample{at: time.Now(), count: m.cache.DroppedCount()})
+}
+
+// PersistenceStats 返回持久化重试 / 丢弃计数与丢弃速率，供 /status 展示
+func (m *Manager) PersistenceStats() monitor.PersistenceStats {
+	return monitor.PersistenceStats{
+		RetriedUpdates: m.cache.RetriedCount(),
+		DroppedUpdates: m.cache.DroppedCount(),
+		DropsPerMinute: m.PersistenceDropRate(),
+	}
+}
@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+)
+
+// lookupETag 由缓存 Key、Tag (及定制的 body) 与响应格式生成弱 ETag
+// 只在 Tag 变化时改变 (JSON 中的 ttl 不参与计算)，响应可能被压缩，因此使用弱校验
+func lookupETag(res lookupResult, json bool) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(res.Key))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(res.Tag))
+	if res.Body != "" && !json {
+		_, _ = h.Write([]byte{0, 'b'})
+		_, _ = h.Write([]byte(res.Body))
+	}
+	if json {
+		_, _ = h.Write([]byte{0, 'j'})
+	}
+	return fmt.Sprintf(`W/"%016x"`, h.Sum64())
+}
+
+// etagMatches 按弱比较判断 If-None-Match 是否命中
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNotModified 命中缓存时写出 ETag，If-None-Match 匹配则返回 304 并返回 true
+func checkNotModified(w http.ResponseWriter, r *http.Request, res lookupResult, json bool) bool {
+	if res.Code != http.StatusOK || res.Tag == "" {
+		return false
+	}
+	etag := lookupETag(res, json)
+	w.Header().Set("ETag", etag)
+	if !etagMatches(r.Header.Get("If-None-Match"), etag) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"net/http"
+	"time"
+)
+
+// ================= 解析事件流 =================
+
+// 事件类型
+const (
+	EventResolved = "resolved" // 解析成功并写入缓存
+	EventFailed   = "failed"   // 上游查询失败
+)
+
+// ResolveEvent 单次上游解析的结果
+type ResolveEvent struct {
+	Type      string    `json:"type"`
+	Key       string    `json:"key"`
+	IP        string    `json:"ip"`
+	Tag       string    `json:"tag,omitempty"`
+	Provider  string    `json:"provider"`
+	Source    string    `json:"source"`     // miss / refresh
+	LatencyMs int64     `json:"latency_ms"` // 上游耗时 (毫秒)
+	Error     string    `json:"error,omitempty"`
+	JobID     string    `json:"job_id,omitempty"`     // 解析任务 ID (与未命中响应的 X-Job-ID 一致)
+	RequestID string    `json:"request_id,omitempty"` // 触发解析的请求 ID
+	Time      time.Time `json:"time"`
+}
+
+// SubscribeEvents 订阅解析事件，返回事件通道与取消函数；
+// 订阅者消费过慢时丢弃事件，不阻塞 worker
+func (m *Manager) SubscribeEvents(buf int) (<-chan ResolveEvent, func()) {
+	return m.events.Subscribe(buf)
+}
+
+// HandleEvents 以 SSE 推送上游解析事件 (key、tag、provider、耗时)
+func (m *Manager) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	events, cancel := m.SubscribeEvents(0)
+	defer cancel()
+
+	streamSSE(w, r, events, m.streamsQuit, func(ev ResolveEvent) string { return ev.Type })
+}
+
+// CloseStreams 结束所有 SSE 长连接 (/events、/changes)，由 HTTP Server 关闭时调用，
+// 避免长连接拖住 Shutdown 直到超时；事件订阅本身 (如变更 webhook) 不受影响
+func (m *Manager) CloseStreams() {
+	m.streamsOnce.Do(func() { close(m.streamsQuit) })
+}
@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// CloseStreams 结束进行中的 SSE 长连接，HTTP Server 关闭时不必等到超时
+func TestCloseStreams(t *testing.T) {
+	m := &Manager{streamsQuit: make(chan struct{})}
+	srv := httptest.NewServer(http.HandlerFunc(m.HandleEvents))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(io.Discard, resp.Body)
+		done <- err
+	}()
+	m.CloseStreams()
+	m.CloseStreams() // 可重复调用
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stream still open after CloseStreams")
+	}
+}
@@ -0,0 +1,38 @@
+package worker
+
+import (
+	"ip-resolver/internal/config"
+	"ip-resolver/internal/model"
+)
+
+// applyFallback 按配置改写 fallback 结果与未命中结果的状态码、Tag 与纯文本 body
+// 只影响返回给客户端的内容，X-Cache 等缓存状态保持不变；所有对外接口 (单个 / 多 IP / 批量查询、
+// auth_request、gRPC、DNS) 均经过此处，没有状态码或 body 的接口只使用 Tag
+func (m *Manager) applyFallback(res lookupResult) lookupResult {
+	var o config.ResponseOverride
+	switch {
+	case res.Status == CacheMiss:
+		o = m.fallback.Pending
+	case res.Tag == model.FallbackTag:
+		o = m.fallback.Fallback
+	default:
+		return res
+	}
+
+	if o.Status != 0 {
+		res.Code = o.Status
+	}
+	if o.Tag != "" {
+		res.Tag = o.Tag
+	}
+	res.Body = o.Body
+	return res
+}
+
+// text 纯文本响应的 body
+func (r lookupResult) text() string {
+	if r.Body != "" {
+		return r.Body
+	}
+	return r.Tag
+}
@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"ip-resolver/internal/config"
+	"ip-resolver/internal/model"
+	"net/http"
+	"testing"
+)
+
+func TestApplyFallback(t *testing.T) {
+	m := &Manager{fallback: config.FallbackConfig{
+		Fallback: config.ResponseOverride{Tag: "default", Body: "unknown-region"},
+		Pending:  config.ResponseOverride{Status: http.StatusOK, Tag: "pending"},
+	}}
+	tests := []struct {
+		name     string
+		in       lookupResult
+		wantCode int
+		wantTag  string
+		wantText string
+	}{
+		{"hit untouched", lookupResult{Status: CacheHit, Code: http.StatusOK, Tag: "beijing_cmcc"}, http.StatusOK, "beijing_cmcc", "beijing_cmcc"},
+		{"fallback tag and body", lookupResult{Status: CacheHit, Code: http.StatusOK, Tag: model.FallbackTag}, http.StatusOK, "default", "unknown-region"},
+		{"miss status and tag", lookupResult{Status: CacheMiss, Code: http.StatusAccepted}, http.StatusOK, "pending", "pending"},
+		{"rejected untouched", lookupResult{Status: CacheRejected, Code: http.StatusServiceUnavailable}, http.StatusServiceUnavailable, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := m.applyFallback(tt.in)
+			if got.Code != tt.wantCode || got.Tag != tt.wantTag || got.text() != tt.wantText {
+				t.Fatalf("got code=%d tag=%q text=%q, want %d %q %q", got.Code, got.Tag, got.text(), tt.wantCode, tt.wantTag, tt.wantText)
+			}
+		})
+	}
+}
+
+// 定制 body 时纯文本 ETag 随之变化，JSON ETag 不变
+func TestLookupETagBody(t *testing.T) {
+	res := lookupResult{Key: "1.1.1.0", Tag: "default"}
+	withBody := res
+	withBody.Body = "unknown-region"
+
+	if lookupETag(res, false) == lookupETag(withBody, false) {
+		t.Fatal("plain ETag should include the body")
+	}
+	if lookupETag(res, true) != lookupETag(withBody, true) {
+		t.Fatal("JSON ETag should ignore the body")
+	}
+}
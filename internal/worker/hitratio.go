@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"ip-resolver/internal/monitor"
+	"sync/atomic"
+	"time"
+)
+
+// maxHitRatioWindow 命中率统计窗口上限 (按秒分槽，窗口越长占用内存越多)
+const maxHitRatioWindow = 24 * time.Hour
+
+// hitSlot 一秒内的命中 / 未命中次数
+type hitSlot struct {
+	sec    atomic.Int64
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// hitRatioTracker 按秒分槽的环形计数器，计算最近若干窗口内的缓存命中率
+// 命中包括需要预刷新的条目 (REFRESH)，未命中即需要排队解析 (消耗上游配额) 的查询
+// 每次查询都会记录，槽位无锁更新 (与 monitor.Histogram 相同)；换秒时的少量计数可能计入相邻一秒
+type hitRatioTracker struct {
+	slots   []hitSlot
+	windows []hitWindow
+}
+
+type hitWindow struct {
+	name string
+	d    time.Duration
+}
+
+// newHitRatioTracker windows 为配置中的窗口 (如 "1m")，无效或超出上限的窗口在配置校验时已拒绝
+func newHitRatioTracker(windows []string) *hitRatioTracker {
+	t := &hitRatioTracker{}
+	var longest time.Duration
+	for _, w := range windows {
+		d, err := time.ParseDuration(w)
+		if err != nil || d < time.Second {
+			continue
+		}
+		d = min(d, maxHitRatioWindow)
+		t.windows = append(t.windows, hitWindow{name: w, d: d})
+		longest = max(longest, d)
+	}
+	if len(t.windows) > 0 {
+		t.slots = make([]hitSlot, int(longest/time.Second))
+	}
+	return t
+}
+
+func (t *hitRatioTracker) record(hit bool) {
+	if len(t.slots) == 0 {
+		return
+	}
+	sec := time.Now().Unix()
+	s := &t.slots[sec%int64(len(t.slots))]
+	if old := s.sec.Load(); old != sec && s.sec.CompareAndSwap(old, sec) {
+		// 槽位上一次使用已是一圈之前，由换秒的一方清零
+		s.hits.Store(0)
+		s.misses.Store(0)
+	}
+	if hit {
+		s.hits.Add(1)
+	} else {
+		s.misses.Add(1)
+	}
+}
+
+// stats 返回各窗口的命中率，窗口内没有查询时 ratio 为 0
+func (t *hitRatioTracker) stats() map[string]monitor.HitRatio {
+	if len(t.windows) == 0 {
+		return nil
+	}
+	now := time.Now().Unix()
+	out := make(map[string]monitor.HitRatio, len(t.windows))
+
+	for _, w := range t.windows {
+		since := now - int64(w.d/time.Second)
+		var r monitor.HitRatio
+		for i := range t.slots {
+			s := &t.slots[i]
+			if sec := s.sec.Load(); sec > since && sec <= now {
+				r.Hits += s.hits.Load()
+				r.Misses += s.misses.Load()
+			}
+		}
+		if total := r.Hits + r.Misses; total > 0 {
+			r.Ratio = float64(r.Hits) / float64(total)
+		}
+		out[w.name] = r
+	}
+	return out
+}
+
+// HitRatios 返回配置的各窗口缓存命中率，供 /status 展示
+func (m *Manager) HitRatios() map[string]monitor.HitRatio {
+	return m.hitRatio.stats()
+}
@@ -0,0 +1,67 @@
+package worker
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestHitRatioConcurrentRecord(t *testing.T) {
+	tr := newHitRatioTracker([]string{"1m", "bogus"})
+	if len(tr.windows) != 1 {
+		t.Fatalf("windows = %v", tr.windows)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 1000; n++ {
+				tr.record(n%4 != 0) // 3/4 命中
+			}
+		}()
+	}
+	wg.Wait()
+
+	r := tr.stats()["1m"]
+	// 跨秒时换秒的一方清零前后可能有少量计数落入新槽位之外，允许极小误差
+	if total := r.Hits + r.Misses; total < 7990 || total > 8000 {
+		t.Fatalf("total = %d, want ~8000", total)
+	}
+	if r.Ratio < 0.74 || r.Ratio > 0.76 {
+		t.Fatalf("ratio = %v, want ~0.75", r.Ratio)
+	}
+}
+
+func TestDailyRecord(t *testing.T) {
+	var d dailyTracker
+	d.record(true, "ignored before reset")
+	d.reset("2026-10-15", DailyReport{Hits: 5, TopTags: []DailyTagCount{{Tag: "a", Lookups: 2}}}, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				d.record(true, "a")
+				d.record(true, "b")
+				d.record(false, "")
+			}
+		}()
+	}
+	wg.Wait()
+
+	r := d.build(nil, -1)
+	if r.Hits != 805 || r.Misses != 400 || r.Lookups != 1205 {
+		t.Fatalf("hits=%d misses=%d lookups=%d", r.Hits, r.Misses, r.Lookups)
+	}
+	if len(r.TopTags) != 2 || r.TopTags[0] != (DailyTagCount{Tag: "a", Lookups: 402}) || r.TopTags[1].Lookups != 400 {
+		t.Fatalf("top tags = %+v", r.TopTags)
+	}
+
+	d.reset("2026-10-16", DailyReport{}, nil)
+	if r := d.build(nil, -1); r.Lookups != 0 || len(r.TopTags) != 0 {
+		t.Fatalf("after reset: %+v", r)
+	}
+}
@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"ip-resolver/internal/logging"
+	"time"
+)
+
+var inflightLog = logging.For("Inflight")
+
+// maxInflightSweepInterval 处理中标记的最长扫描间隔
+const maxInflightSweepInterval = time.Minute
+
+// runInflightSweep 定期清除长时间无进展的处理中标记 (Worker 丢失、入队失败等导致的泄漏)
+// 被清除的 key 可重新入队刷新；若原任务之后仍完成，最多多一次上游请求
+func (m *Manager) runInflightSweep() {
+	if m.inflightMaxAge <= 0 {
+		return
+	}
+
+	interval := min(m.inflightMaxAge/2, maxInflightSweepInterval)
+	m.sweepWg.Add(1)
+	go func() {
+		defer m.sweepWg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-m.stopCh:
+				return
+			}
+
+			if keys := m.inflight.Sweep(m.inflightMaxAge); len(keys) > 0 {
+				inflightLog.Warn("清除长时间无进展的处理中标记 (疑似泄漏)", "count", len(keys), "max_age", m.inflightMaxAge, "key", keys[0])
+			}
+		}
+	}()
+}
@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInflightSweep(t *testing.T) {
+	tests := []struct {
+		name  string
+		age   time.Duration // 距上次活动的时间
+		touch bool          // 清除前是否 Touch (如从溢出日志送回队列)
+		swept bool
+	}{
+		{"fresh", 0, false, false},
+		{"stale", 10 * time.Minute, false, true},
+		{"stale but touched", 10 * time.Minute, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newInflightSet()
+			if !s.TryAdd("k") || s.TryAdd("k") {
+				t.Fatal("TryAdd should succeed exactly once")
+			}
+			done := s.m["k"].done
+			s.m["k"].active = time.Now().Add(-tt.age)
+			if tt.touch {
+				s.Touch("k")
+			}
+
+			keys := s.Sweep(5 * time.Minute)
+			if got := len(keys) == 1; got != tt.swept {
+				t.Fatalf("swept = %v, want %v", got, tt.swept)
+			}
+			select {
+			case <-done:
+				if !tt.swept {
+					t.Fatal("waiters woken for a live key")
+				}
+			default:
+				if tt.swept {
+					t.Fatal("waiters not woken for a swept key")
+				}
+			}
+			if s.TryAdd("k") != tt.swept {
+				t.Fatalf("re-add after sweep = %v, want %v", !tt.swept, tt.swept)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"ip-resolver/internal/logging"
+	"ip-resolver/internal/model"
+	"ip-resolver/internal/requestid"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var lookupLog = logging.For("Lookup")
+
+// 缓存状态
+const (
+	CacheHit      = "HIT"      // 命中
+	CacheRefresh  = "REFRESH"  // 命中且已触发预刷新
+	CacheMiss     = "MISS"     // 未命中，已加入解析队列 (或正在解析)
+	CacheRejected = "REJECTED" // 未命中且队列已满 (返回 503 + Retry-After)
+)
+
+// lookupResult 单个 IP 的查询结果
+type lookupResult struct {
+	IP        string
+	Key       string
+	Tag       string
+	Status    string
+	Code      int
+	Remaining time.Duration
+	JobID     string // 本次请求触发入队时的解析任务 ID
+	Body      string // fallback_response 定制的纯文本 body，为空时输出 Tag
+}
+
+// lookup 查询缓存，未命中或需要刷新时加入解析队列
+// ctx 中的请求 ID 会随任务传递给 worker 与上游调用
+func (m *Manager) lookup(ctx context.Context, ip string) lookupResult {
+	cacheKey := m.cacheKey(ip)
+	m.hotKeys.Touch(cacheKey)
+
+	res := lookupResult{IP: ip, Key: cacheKey}
+	// 任务 ID 只在确实入队时分配 (assignID)，命中缓存的请求不产生
+	j := job{IP: ip, RequestID: requestid.FromContext(ctx)}
+
+	tag, found, needsRefresh, remaining := m.cache.Get(cacheKey)
+	m.hitRatio.record(found)
+	m.daily.record(found, tag)
+	if found {
+		lookupLog.Debug("缓存命中", "ip", ip, "key", cacheKey, "remaining", remaining)
+		res.Tag = tag
+		res.Status = CacheHit
+		res.Code = http.StatusOK
+		res.Remaining = remaining
+
+		if needsRefresh {
+			res.Status = CacheRefresh
+			if m.shouldShedRefresh() {
+				m.shedRefreshes.Add(1)
+			} else if m.inflight.TryAdd(cacheKey) {
+				j.assignID()
+				lookupLog.Debug("缓存预刷新", "key", cacheKey, "remaining", remaining, "job_id", j.ID, "request_id", j.RequestID)
+				j.Background = true
+				if m.refreshQueue.tryPush(j) {
+					res.JobID = j.ID
+				} else {
+					m.inflight.Delete(cacheKey)
+				}
+			}
+		}
+		return res
+	}
+
+	lookupLog.Debug("缓存未命中", "ip", ip, "key", cacheKey, "request_id", j.RequestID)
+
+	res.Status = CacheMiss
+	res.Code = http.StatusAccepted
+
+	if !m.inflight.TryAdd(cacheKey) {
+		return res
+	}
+	j.assignID()
+
+	// 队列已满时写入溢出日志，有空位后再送回队列
+	if !m.queue.tryPush(j) && !m.spill.push(j) {
+		m.inflight.Delete(cacheKey)
+		res.Status = CacheRejected
+		res.Code = http.StatusServiceUnavailable
+		return res
+	}
+	res.JobID = j.ID
+	return res
+}
+
+// serveExists 处理 HEAD /{ip}: 仅检查缓存是否存在 (200 / 404)，不入队、不写 body
+func (m *Manager) serveExists(w http.ResponseWriter, rawIP string) {
+	ip, errMsg := m.normalizeIP(rawIP)
+	if errMsg != "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	key := m.cacheKey(ip)
+	w.Header().Set("X-Cache-Key", key)
+
+	_, found, _, _ := m.cache.Get(key)
+	if !found {
+		w.Header().Set("X-Cache", CacheMiss)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("X-Cache", CacheHit)
+	w.WriteHeader(http.StatusOK)
+}
+
+// wantsWait 通过 ?wait=1 判断未命中时是否同步等待解析完成
+func wantsWait(r *http.Request) bool {
+	switch r.URL.Query().Get("wait") {
+	case "1", "true":
+		return true
+	}
+	return false
+}
+
+// waitResolved 阻塞等待 key 解析结束 (最长 maxWait)，随后重新读取缓存
+// 超时、解析失败或客户端断开时返回原始的 MISS 结果
+func (m *Manager) waitResolved(ctx context.Context, res lookupResult) lookupResult {
+	return m.waitKey(ctx, res, m.maxWait)
+}
+
+func (m *Manager) waitKey(ctx context.Context, res lookupResult, maxWait time.Duration) lookupResult {
+	if maxWait <= 0 {
+		return res
+	}
+
+	if done := m.inflight.Done(res.Key); done != nil {
+		timer := time.NewTimer(maxWait)
+		defer timer.Stop()
+
+		select {
+		case <-done:
+		case <-timer.C:
+			lookupLog.Debug("同步等待超时", "ip", res.IP, "key", res.Key)
+			return res
+		case <-ctx.Done():
+			return res
+		}
+	}
+
+	tag, found, _, remaining := m.cache.Get(res.Key)
+	if !found {
+		return res
+	}
+
+	res.Tag = tag
+	res.Status = CacheHit
+	res.Code = http.StatusOK
+	res.Remaining = remaining
+	return res
+}
+
+// setCacheHeaders 写出 X-Cache / X-Cache-Key，便于从访问日志统计命中率
+func setCacheHeaders(w http.ResponseWriter, res lookupResult) {
+	status := res.Status
+	if status == CacheRejected {
+		status = CacheMiss
+	}
+	w.Header().Set("X-Cache", status)
+	w.Header().Set("X-Cache-Key", res.Key)
+	if res.JobID != "" {
+		w.Header().Set(requestid.JobHeader, res.JobID)
+	}
+}
+
+// wantsJSON 通过 ?format=json 或 Accept 头判断是否返回 JSON
+func wantsJSON(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "json"
+	}
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+// LookupResponse 结构化的查询结果 (HTTP JSON / gRPC 共用)
+type LookupResponse struct {
+	IP       string `json:"ip"`
+	Key      string `json:"key"`
+	Tag      string `json:"tag,omitempty"`
+	Province string `json:"province,omitempty"`
+	ISP      string `json:"isp,omitempty"`
+	Cache    string `json:"cache"`
+	TTL      int64  `json:"ttl"` // 剩余有效期 (秒)
+}
+
+func (m *Manager) toResponse(res lookupResult) LookupResponse {
+	resp := LookupResponse{
+		IP:    res.IP,
+		Key:   res.Key,
+		Tag:   res.Tag,
+		Cache: res.Status,
+		TTL:   int64(res.Remaining / time.Second),
+	}
+	if res.Tag != "" {
+		if info := model.ParseDetail(m.cache.GetDetail(res.Key)); info != nil {
+			resp.Province = info.Province
+			resp.ISP = info.ISP
+		}
+	}
+	return resp
+}
+
+func (m *Manager) writeJSON(w http.ResponseWriter, res lookupResult) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(res.Code)
+	_ = json.NewEncoder(w).Encode(m.toResponse(res))
+}
+
+// ================= 对外接口 (gRPC 等) ===================
+
+// Resolve 查询单个 IP，wait 为 true 时未命中会同步等待解析完成
+func (m *Manager) Resolve(ctx context.Context, rawIP string, wait bool) (LookupResponse, error) {
+	ip, errMsg := m.normalizeIP(rawIP)
+	if errMsg != "" {
+		return LookupResponse{}, errors.New(errMsg)
+	}
+
+	res := m.lookup(ctx, ip)
+	if res.Status == CacheMiss && wait {
+		res = m.waitResolved(ctx, res)
+	}
+	return m.toResponse(m.applyFallback(res)), nil
+}
+
+// KeyForIP 返回 IP 对应的缓存 Key
+func (m *Manager) KeyForIP(rawIP string) (string, error) {
+	ip, errMsg := m.normalizeIP(rawIP)
+	if errMsg != "" {
+		return "", errors.New(errMsg)
+	}
+	return m.cacheKey(ip), nil
+}
+
+// Invalidate 删除缓存 Key，返回是否存在
+func (m *Manager) Invalidate(key string) bool {
+	return m.cache.Delete(key)
+}
@@ -5,30 +5,50 @@ import (
 	"fmt"
 	"ip-resolver/internal/cache"
 	"ip-resolver/internal/config"
+	"ip-resolver/internal/errreport"
+	"ip-resolver/internal/fanout"
+	"ip-resolver/internal/logging"
+	"ip-resolver/internal/model"
+	"ip-resolver/internal/monitor"
 	"ip-resolver/internal/provider"
-	"log"
+	"ip-resolver/internal/requestid"
 	"net"
 	"net/http"
-	"sort"
+	"runtime/debug"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 )
 
+var (
+	workerLog = logging.For("Worker")
+	queueLog  = logging.For("Queue")
+	cacheLog  = logging.For("Cache")
+)
+
 /*
 inflightSet：
 - 核心去重组件
 - 保证同一个 cacheKey(/24) 在“等待队列”或“执行中”只能存在一份
+- 每个 key 关联一个 done 通道，解析结束 (Delete) 时关闭，供同步等待使用
+- 记录最近一次活动时间，长时间无进展的 key 由 Sweep 清除，避免泄漏后永远无法刷新
 */
 type inflightSet struct {
-	mu sync.Mutex
-	m  map[string]struct{}
+	mu      sync.Mutex
+	m       map[string]*inflightEntry
+	expired atomic.Int64
+}
+
+type inflightEntry struct {
+	done   chan struct{}
+	active time.Time // 加入或最近一次 Touch 的时间
 }
 
 func newInflightSet() *inflightSet {
 	return &inflightSet{
-		m: make(map[string]struct{}),
+		m: make(map[string]*inflightEntry),
 	}
 }
 
@@ -38,33 +58,172 @@ func (s *inflightSet) TryAdd(key string) bool {
 	if _, exists := s.m[key]; exists {
 		return false
 	}
-	s.m[key] = struct{}{}
+	s.m[key] = &inflightEntry{done: make(chan struct{}), active: time.Now()}
 	return true
 }
 
 func (s *inflightSet) Delete(key string) {
 	s.mu.Lock()
-	delete(s.m, key)
+	if e, ok := s.m[key]; ok {
+		close(e.done)
+		delete(s.m, key)
+	}
 	s.mu.Unlock()
 }
 
+// Touch 刷新 key 的活动时间 (送回队列、Worker 开始处理、安排重试时调用)
+func (s *inflightSet) Touch(key string) {
+	s.mu.Lock()
+	if e, ok := s.m[key]; ok {
+		e.active = time.Now()
+	}
+	s.mu.Unlock()
+}
+
+// Sweep 清除超过 maxAge 无活动的 key (视为泄漏) 并唤醒等待方，返回被清除的 key
+func (s *inflightSet) Sweep(maxAge time.Duration) []string {
+	cutoff := time.Now().Add(-maxAge)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []string
+	for key, e := range s.m {
+		if e.active.Before(cutoff) {
+			close(e.done)
+			delete(s.m, key)
+			keys = append(keys, key)
+		}
+	}
+	s.expired.Add(int64(len(keys)))
+	return keys
+}
+
+// Expired 返回累计被 Sweep 清除的 key 数
+func (s *inflightSet) Expired() int64 {
+	return s.expired.Load()
+}
+
+func (s *inflightSet) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.m)
+}
+
+// Done 返回 key 解析结束时关闭的通道，key 不在处理中时返回 nil
+func (s *inflightSet) Done(key string) <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.m[key]; ok {
+		return e.done
+	}
+	return nil
+}
+
 // ================= Manager ===================
 
+// job 解析任务，RequestID 为触发该任务的请求 ID (可能为空)
+type job struct {
+	ID         string // 解析任务 ID，入队时生成，贯穿 Worker、上游调用与日志
+	IP         string
+	RequestID  string
+	Attempt    int  // 已重试次数
+	Requeues   int  // 因短暂故障 (超时 / 5xx) 重新入队的次数，不计入重试次数
+	Force      bool // 忽略缓存有效期强制重新解析 (管理接口触发)
+	Background bool // 来自后台刷新队列
+}
+
 type Manager struct {
 	provider provider.IPProvider
-	queue    chan string
+	provider6 provider.IPProvider // IPv6 查询使用的提供商
+	ipv6PrefixLen int             // IPv6 聚合前缀长度，0 表示不支持 IPv6
+	queue    *workQueue // 首次查询 (未命中) 任务，优先处理
+	refreshQueue *workQueue // 预刷新与预热任务，仅在 queue 为空时处理
 	cache    *cache.Cache
 	inflight *inflightSet
+	hotKeys  *cache.HotKeyTracker
 	wg       sync.WaitGroup
-	debugMode bool
-	cacheTTL  time.Duration
+	hookWg   sync.WaitGroup
+	events   fanout.Hub[ResolveEvent]
+	streamsQuit chan struct{} // 关闭时结束 SSE 长连接 (/events、/changes)
+	streamsOnce sync.Once
+	fetchLatency latencyEWMA // 上游平均耗时，用于估算 Retry-After
+	changeWebhookURL string
 	concurrency int
+	minWorkers   int // 自动伸缩下限
+	maxWorkers   int // 自动伸缩上限，0 表示固定为 concurrency
+	workers      atomic.Int32
+	nextWorkerID atomic.Int32
+	retire       chan struct{} // 缩容信号，领取到的空闲 Worker 退出
+	scaleQuit    chan struct{}
+	scaleWg      sync.WaitGroup
+	retiring     *retirement // 进行中的固定数量缩容 (仅热加载修改)
+	maxRetries   int           // 解析失败后的重试次数
+	retryBase    time.Duration // 首次重试的等待时间，之后逐次翻倍
+	maxRequeues  int           // 短暂故障最多重新入队次数
+	retryWheel   *timerWheel   // 等待中的重试与重新入队
+	deadLetters  deadLetterList // 重试耗尽的任务
+	halt         chan struct{}  // 通知 Worker 处理完当前任务后退出 (队列持久化时使用)
+	abandonedMu  sync.Mutex
+	abandoned    []job // 关闭时未能送回队列的任务
+	queueMu      sync.Mutex // 串行化 RestoreQueue 与 Stop 关闭队列
+	stopping     bool
+	limiter      *rateLimiter // 上游全局 QPS 上限
+	adaptive     *adaptiveLimiter // 自适应并发，nil 为关闭
+	shedQueueDepth int // 队列合计深度达到该值时跳过预刷新，0 为关闭
+	shedInflight   int // 处理中任务数达到该值时跳过预刷新，0 为关闭
+	shedRefreshes  atomic.Int64
+	crawlInterval  time.Duration // 过期扫描间隔，0 为关闭
+	crawlRate      int           // 扫描结果每秒入队数
+	crawlQuit      chan struct{}
+	crawlWg        sync.WaitGroup
+	mon            *monitor.Monitor // 可选，记录 Worker panic
+	errReporter    *errreport.Reporter // 可选，上报 panic 与提供商持续失败
+	reportFailures int64               // 提供商连续失败达到该次数时上报，0 为不上报
+	workerStats    workerStatSet
+	batchSize      int // 提供商支持批量查询时单次合并的任务数，1 为关闭
+	fetchTimeout   time.Duration            // 主提供商请求超时
+	fetchTimeout6  time.Duration            // IPv6 提供商请求超时
+	namedTimeouts  map[string]time.Duration // 按配置名称的超时 (?provider= 覆盖使用)
+	inflightMaxAge time.Duration            // 处理中标记的最长无活动时间，0 为不清除
+	drainTimeout   time.Duration            // 关闭时处理剩余队列的最长时间
+	runCtx         context.Context          // 上游请求的父 Context，关闭等待超时后取消
+	spill          *spillJournal            // 首次查询队列溢出日志，nil 为关闭
+	hitRatio       *hitRatioTracker         // 滚动窗口缓存命中率
+	tagHistory     tagHistory               // 定期记录的 Tag 分布 (/stats/tags 的变化量)
+	dropRate       dropRateTracker          // 持久化丢弃速率
+	daily          dailyTracker             // 每日汇总 (/stats/daily)
+	counterPersistInterval time.Duration // 监控计数写入 SQLite 的间隔，0 为不持久化
+	quotaFetcher       func(context.Context) int64 // 可选，剩余配额查询
+	quotaInterval      time.Duration // 配额检查间隔，0 为不检查
+	quotaTimeout       time.Duration // 单次配额查询的超时
+	quotaProbeInterval time.Duration // 配额耗尽暂停期间的检查间隔
+	quotaGate          quotaGate
+	lastQuota          atomic.Int64 // 最近一次查询到的剩余配额，-1 为未知
+	spillQuit      chan struct{}
+	spillWg        sync.WaitGroup
+	sweepWg        sync.WaitGroup
+	cancelRun      context.CancelFunc
+	maxWait   time.Duration // ?wait=1 同步等待的最长时间
+	callbacksEnabled bool          // 是否允许 ?callback= 完成回调
+	callbackHosts    []string      // 允许回调的主机白名单
+	callbackClient   *http.Client
+	callbackTimeout  time.Duration // 回调等待解析的最长时间
+	callbackSem      chan struct{} // 限制等待中的回调数量
+	stopCh           chan struct{}
+	trustedProxies []*net.IPNet // 允许设置 X-Forwarded-For / X-Real-IP 的代理
+	authRequestHeader string    // auth_request 模式读取 IP 的请求头
+	fallback config.FallbackConfig // fallback / 未命中时的响应定制
+	preload  *preloader
+	providers map[string]provider.IPProvider // 按配置名称登记的提供商 (?provider= 覆盖)
+	adminAuth func(r *http.Request) bool
 }
 
 // ======== 硬编码参数 =========
 const (
-	ApiRequestTimeout = 3 * time.Second
-	QueueSize         = 4096
+	ApiRequestTimeout = 3 * time.Second // 默认上游超时 (provider_timeout_ms 未配置时)
+	DefaultQueueSize  = 4096
+	HotKeyTopN        = 20
 )
 
 // ================= 构造 ===================
@@ -75,34 +234,188 @@ func NewManager(p provider.IPProvider, cfg *config.Config) *Manager {
 
 	c := cache.New(ttl, ratio)
 
+	policy, err := cache.ParseEvictionPolicy(cfg.CacheEvictionPolicy)
+	if err != nil {
+		cacheLog.Warn("淘汰策略无效, 使用默认策略", "err", err, "policy", cache.EvictRandom)
+		policy = cache.EvictRandom
+	}
+	c.SetEvictionPolicy(policy)
+	c.SetRefreshJitter(float64(cfg.CacheRefreshJitterPercent) / 100.0)
+
 	// 如果配置了持久化路径，尝试加载并开启自动保存
 	if cfg.CacheStorePath != "" {
 		if err := c.LoadFromSQLite(cfg.CacheStorePath); err != nil {
-			log.Printf("尝试从 SQLite 加载缓存失败 (可能是首次启动): %v", err)
+			cacheLog.Warn("尝试从 SQLite 加载缓存失败 (可能是首次启动)", "path", cfg.CacheStorePath, "err", err)
 		}
 		// 开启 Write-Behind 持久化 (批处理参数已内置)
 		c.StartPersistence(cfg.CacheStorePath)
 	}
 
-	return &Manager{
+	// 高 QPS 场景下启用无锁只读快照
+	if cfg.CacheSnapshotIntervalSeconds > 0 {
+		c.EnableSnapshot(time.Duration(cfg.CacheSnapshotIntervalSeconds) * time.Second)
+	}
+
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+
+	concurrency := cfg.WorkerConcurrency
+	if cfg.WorkerMaxConcurrency > 0 {
+		concurrency = min(max(concurrency, cfg.WorkerMinConcurrency), cfg.WorkerMaxConcurrency)
+	}
+
+	timeout := cfg.ProviderTimeout(cfg.Primary())
+	if timeout <= 0 {
+		timeout = ApiRequestTimeout
+	}
+	timeout6 := timeout
+	namedTimeouts := make(map[string]time.Duration, len(cfg.Providers)+1)
+	for _, pc := range cfg.Providers {
+		namedTimeouts[pc.Name] = cfg.ProviderTimeout(pc)
+	}
+	if cfg.IPv6Provider.Name != "" {
+		if t := cfg.ProviderTimeout(cfg.IPv6Provider); t > 0 {
+			timeout6 = t
+		}
+		namedTimeouts[cfg.IPv6Provider.Name] = timeout6
+	}
+
+	var adaptive *adaptiveLimiter
+	if ac := cfg.AdaptiveConcurrency; ac.Enabled {
+		adaptive = newAdaptiveLimiter(ac.MinLimit, max(concurrency, cfg.WorkerMaxConcurrency),
+			time.Duration(ac.LatencyMs)*time.Millisecond, ac.ErrorRate)
+	}
+
+	var spill *spillJournal
+	if cfg.QueueSpillPath != "" {
+		if spill, err = openSpillJournal(cfg.QueueSpillPath, cfg.QueueSpillMaxItems); err != nil {
+			spillLog.Error("打开队列溢出日志失败, 队列满时将直接拒绝", "path", cfg.QueueSpillPath, "err", err)
+		}
+	}
+
+	runCtx, cancelRun := context.WithCancel(context.Background())
+
+	prefixLen := cfg.IPv6PrefixLen
+	if prefixLen > 128 {
+		prefixLen = 128
+	}
+
+	m := &Manager{
 		provider:  p,
-		queue:     make(chan string, QueueSize),
+		queue:     newWorkQueue(queueSize, cfg.QueueShards),
+		refreshQueue: newWorkQueue(queueSize, cfg.QueueShards),
 		cache:     c,
 		inflight:  newInflightSet(),
-		debugMode: cfg.LogLevel == "debug",
-		cacheTTL:  ttl,
-		concurrency: cfg.WorkerConcurrency,
+		hotKeys:   cache.NewHotKeyTracker(HotKeyTopN),
+		concurrency: concurrency,
+		minWorkers:  cfg.WorkerMinConcurrency,
+		maxWorkers:  cfg.WorkerMaxConcurrency,
+		retire:      make(chan struct{}, 1),
+		scaleQuit:   make(chan struct{}),
+		maxRetries:  cfg.FetchMaxRetries,
+		retryBase:   time.Duration(cfg.FetchRetryBackoffMs) * time.Millisecond,
+		maxRequeues: cfg.FetchTransientMaxRequeues,
+		halt:        make(chan struct{}),
+		limiter:     newRateLimiter(cfg.ProviderRateLimit),
+		adaptive:    adaptive,
+		shedQueueDepth: cfg.RefreshShedQueueDepth,
+		shedInflight:   cfg.RefreshShedInflight,
+		crawlInterval:  time.Duration(cfg.RefreshCrawlIntervalSeconds) * time.Second,
+		crawlRate:      cfg.RefreshCrawlRatePerSecond,
+		crawlQuit:      make(chan struct{}),
+		batchSize:      min(max(cfg.WorkerBatchSize, 1), MaxWorkerBatchSize),
+		fetchTimeout:   timeout,
+		fetchTimeout6:  timeout6,
+		namedTimeouts:  namedTimeouts,
+		inflightMaxAge: time.Duration(cfg.InflightMaxAgeSeconds) * time.Second,
+		drainTimeout:   time.Duration(cfg.ShutdownDrainTimeoutSeconds) * time.Second,
+		runCtx:         runCtx,
+		spill:          spill,
+		hitRatio:       newHitRatioTracker(cfg.HitRatioWindows),
+		quotaInterval:      time.Duration(cfg.Quota.RefreshIntervalSeconds) * time.Second,
+		quotaTimeout:       time.Duration(cfg.Quota.RefreshTimeoutSeconds) * time.Second,
+		quotaProbeInterval: time.Duration(cfg.Quota.ProbeIntervalSeconds) * time.Second,
+		spillQuit:      make(chan struct{}),
+		cancelRun:      cancelRun,
+		changeWebhookURL: cfg.ChangeWebhookURL,
+		provider6: p,
+		ipv6PrefixLen: prefixLen,
+		maxWait: time.Duration(cfg.LookupMaxWaitMs) * time.Millisecond,
+		callbacksEnabled: cfg.LookupCallbackEnabled,
+		callbackHosts:    cfg.LookupCallbackAllowedHosts,
+		callbackClient:   newCallbackClient(cfg.LookupCallbackAllowPrivate),
+		callbackTimeout:  time.Duration(cfg.LookupCallbackTimeoutMs) * time.Millisecond,
+		callbackSem:      make(chan struct{}, maxPendingCallbacks),
+		stopCh:           make(chan struct{}),
+		streamsQuit:      make(chan struct{}),
+		authRequestHeader: cfg.AuthRequestHeader,
+		fallback: cfg.FallbackResponse,
+		preload:  newPreloader(cfg.PreloadRatePerSecond),
+		trustedProxies: parseCIDRs(cfg.TrustedProxies),
+		counterPersistInterval: time.Duration(cfg.CounterPersistIntervalSeconds) * time.Second,
 	}
+	m.retryWheel = newTimerWheel(retryWheelTick, retryWheelSlots, m.requeueDelayed)
+	m.lastQuota.Store(-1)
+	return m
+}
+
+// SetMonitor 设置监控对象，用于记录 Worker panic
+func (m *Manager) SetMonitor(mon *monitor.Monitor) {
+	m.mon = mon
 }
 
-func (m *Manager) debugLog(format string, v ...interface{}) {
-	if m.debugMode {
-		log.Printf("[DEBUG] "+format, v...)
+// SetErrorReporter 设置错误上报，panic 总是上报，提供商连续失败达到 failures 次时上报一次
+func (m *Manager) SetErrorReporter(r *errreport.Reporter, failures int) {
+	m.errReporter = r
+	m.reportFailures = int64(failures)
+}
+
+// SetIPv6Provider 为 IPv6 查询指定独立的提供商 (默认与 IPv4 共用)
+func (m *Manager) SetIPv6Provider(p provider.IPProvider) {
+	m.provider6 = p
+}
+
+// providerFor 根据 IP 地址族选择提供商
+func (m *Manager) providerFor(ip string) provider.IPProvider {
+	if isIPv6(ip) {
+		return m.provider6
+	}
+	return m.provider
+}
+
+// timeoutFor 返回该 IP 所用提供商的请求超时
+func (m *Manager) timeoutFor(ip string) time.Duration {
+	if isIPv6(ip) {
+		return m.fetchTimeout6
 	}
+	return m.fetchTimeout
 }
 
 // ================= 工具函数 ===================
 
+func isIPv6(ip string) bool {
+	return strings.IndexByte(ip, ':') >= 0
+}
+
+// cacheKey IPv4 按 /24 聚合，IPv6 按配置的前缀聚合 (如 2001:db8:1::/48)
+func (m *Manager) cacheKey(ip string) string {
+	if isIPv6(ip) {
+		return getCacheKeyV6(ip, m.ipv6PrefixLen)
+	}
+	return getCacheKey(ip)
+}
+
+func getCacheKeyV6(ip string, prefixLen int) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+	masked := parsed.Mask(net.CIDRMask(prefixLen, 128))
+	return fmt.Sprintf("%s/%d", masked.String(), prefixLen)
+}
+
 func getCacheKey(ip string) string {
 	dot := 0
 	for i := 0; i < len(ip); i++ {
@@ -119,111 +432,425 @@ func getCacheKey(ip string) string {
 // ================= 启停 ===================
 
 func (m *Manager) Start() {
-	for i := 0; i < m.concurrency; i++ {
-		m.wg.Add(1)
-		go m.worker(i)
+	m.restoreCounters()
+	if m.cache.HasStore() {
+		m.restoreQueue()
 	}
+
+	m.retryWheel.start()
+
+	m.spawnWorkers(m.concurrency)
+	m.runAutoscale()
+	m.runSpillFeeder()
+
+	if m.changeWebhookURL != "" {
+		m.runChangeWebhook(m.changeWebhookURL)
+	}
+
+	m.runPreload()
+	m.runCrawl()
+	m.runInflightSweep()
+	m.runQuotaWatch()
+	m.runTagSnapshots()
+	m.runDropRateSampler()
+	m.runDailyReport()
+	m.runCounterPersistence()
 }
 
 func (m *Manager) Stop() {
-	close(m.queue)
-	m.wg.Wait()
+	m.queueMu.Lock()
+	m.stopping = true
+	m.queueMu.Unlock()
+
+	m.stopAutoscale()
+	m.stopPreload()
+	m.stopCrawl()
+	m.stopSpillFeeder()
+	m.stopRetry()
+
+	// 在 drainTimeout 内继续处理剩余任务，超时后中止进行中的上游请求，剩余任务保存后在下次启动时恢复
+	m.queue.close()
+	m.refreshQueue.close()
+	if !m.waitWorkers(m.drainTimeout) {
+		close(m.halt)
+		m.cancelRun()
+		m.wg.Wait()
+	}
+	m.cancelRun()
+	m.saveQueue()
+	m.saveDaily()
+	m.saveCounters()
+	m.spill.close()
+
+	m.events.Close()
+	close(m.stopCh)
+	m.sweepWg.Wait()
 	m.cache.Close()
+	m.hookWg.Wait()
+}
+
+// waitWorkers 等待所有 Worker 退出，超过 d 时返回 false
+func (m *Manager) waitWorkers(d time.Duration) bool {
+	if d <= 0 {
+		return false
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(stopped)
+	}()
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-stopped:
+		return true
+	case <-t.C:
+		queueLog.Warn("关闭等待超时, 剩余任务不再处理", "timeout", d, "remaining", m.queue.len()+m.refreshQueue.len())
+		return false
+	}
 }
 
 // ================= HTTP Handler ===================
 
 func (m *Manager) HandleUpdate(w http.ResponseWriter, r *http.Request) {
-	rawIP := strings.TrimPrefix(r.URL.Path, "/")
+	m.LookupHandler("/")(w, r)
+}
 
-	if rawIP == "" || rawIP == "favicon.ico" {
-		w.WriteHeader(http.StatusBadRequest)
-		return
+// LookupHandler 返回挂载在 prefix 下的单 IP 查询 Handler (路径为 <prefix><ip>)
+func (m *Manager) LookupHandler(prefix string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rawIP, ok := strings.CutPrefix(r.URL.Path, prefix)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if rawIP == "" || rawIP == "favicon.ico" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodHead:
+			m.serveExists(w, rawIP)
+			return
+		case http.MethodPut:
+			m.serveManualTag(w, r, rawIP)
+			return
+		}
+
+		if strings.Contains(rawIP, ",") {
+			m.serveMultiLookup(w, r, strings.Split(rawIP, ","))
+			return
+		}
+
+		m.serveLookup(w, r, rawIP)
 	}
+}
 
-	parsedIP := net.ParseIP(rawIP)
-	if parsedIP == nil {
+// serveLookup 查询单个 IP 并按请求格式写出响应
+func (m *Manager) serveLookup(w http.ResponseWriter, r *http.Request, rawIP string) {
+	ip, errMsg := m.normalizeIP(rawIP)
+	if errMsg != "" {
 		w.WriteHeader(http.StatusBadRequest)
-		_, _ = w.Write([]byte("invalid ip format"))
+		_, _ = w.Write([]byte(errMsg))
 		return
 	}
-	if parsedIP.To4() == nil {
-		w.WriteHeader(http.StatusBadRequest)
-		_, _ = w.Write([]byte("only ipv4 supported"))
+
+	if name := r.URL.Query().Get("provider"); name != "" {
+		m.serveProviderOverride(w, r, ip, name)
 		return
 	}
 
-	cacheKey := getCacheKey(rawIP)
+	var callbackURL string
+	if raw := r.URL.Query().Get("callback"); raw != "" && m.callbacksEnabled {
+		u, err := parseCallbackURL(raw, m.callbackHosts)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(err.Error()))
+			return
+		}
+		callbackURL = u
+	}
 
-	tag, found, needsRefresh, remaining := m.cache.Get(cacheKey)
-	if found {
-		m.debugLog("缓存命中 | IP=%s | Key=%s | 剩余有效期=%v", rawIP, cacheKey, remaining)
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte(tag))
-
-		if needsRefresh {
-			if m.inflight.TryAdd(cacheKey) {
-				m.debugLog("缓存预刷新 | Key=%s | 剩余有效期=%v", cacheKey, remaining)
-				select {
-				case m.queue <- rawIP:
-				default:
-					m.inflight.Delete(cacheKey)
-				}
-			}
+	res := m.lookup(r.Context(), ip)
+	if res.Status == CacheMiss && wantsWait(r) {
+		res = m.waitResolved(r.Context(), res)
+	}
+
+	setCacheHeaders(w, res)
+	if res.Status == CacheRejected {
+		m.setRetryAfter(w)
+	}
+
+	// 未命中且携带回调地址: 解析结束后异步 POST 结果
+	if callbackURL != "" && res.Status == CacheMiss {
+		if m.registerCallback(res, callbackURL) {
+			w.Header().Set("X-Callback", "accepted")
+		} else {
+			w.Header().Set("X-Callback", "rejected")
 		}
-		return
 	}
 
-	m.debugLog("缓存未命中 | IP=%s | Key=%s", rawIP, cacheKey)
+	res = m.applyFallback(res)
 
-	if !m.inflight.TryAdd(cacheKey) {
-		w.WriteHeader(http.StatusAccepted)
+	asJSON := wantsJSON(r)
+	if checkNotModified(w, r, res, asJSON) {
 		return
 	}
 
-	select {
-	case m.queue <- rawIP:
-		w.WriteHeader(http.StatusAccepted)
-	default:
-		m.inflight.Delete(cacheKey)
-		w.WriteHeader(http.StatusTooManyRequests)
+	if asJSON {
+		m.writeJSON(w, res)
+		return
 	}
+
+	w.WriteHeader(res.Code)
+	if body := res.text(); body != "" {
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+// normalizeIP 校验并规范化 IP 文本，失败时返回错误描述
+func (m *Manager) normalizeIP(rawIP string) (string, string) {
+	parsedIP := net.ParseIP(rawIP)
+	if parsedIP == nil {
+		return "", "invalid ip format"
+	}
+	if v4 := parsedIP.To4(); v4 != nil {
+		// 统一 IPv4-mapped 地址 (::ffff:1.2.3.4) 的表示
+		return v4.String(), ""
+	}
+	if m.ipv6PrefixLen <= 0 {
+		return "", "ipv6 not enabled"
+	}
+	return parsedIP.String(), ""
 }
 
 // ================= Worker ===================
 
 func (m *Manager) worker(id int) {
 	defer m.wg.Done()
+	defer m.workers.Add(-1)
 
-	for rawIP := range m.queue {
-		func() {
-			cacheKey := getCacheKey(rawIP)
-			defer m.inflight.Delete(cacheKey)
+	st := m.workerStats.register(id)
+	defer m.workerStats.unregister(id)
 
-			_, found, needsRefresh, _ := m.cache.Get(cacheKey)
-			if found && !needsRefresh {
-				return
-			}
+	// 所在分片为空时阻塞等待，分片数大于 1 时由入队信号唤醒并窃取其他分片的任务
+	queue, refresh := m.queue.home(id), m.refreshQueue.home(id)
 
-			ctx, cancel := context.WithTimeout(context.Background(), ApiRequestTimeout)
-			defer cancel()
+	for {
+		select {
+		case <-m.halt:
+			return
+		default:
+		}
 
-			start := time.Now()
+		// 优先取首次查询任务，仅在其为空时处理后台刷新，避免刷新流量挤占未命中查询
+		// 取走后仍有剩余时传递唤醒信号 (多次入队只合并为一个信号)，让其他空闲 Worker 一起窃取
+		if j, ok := m.queue.tryPop(id); ok {
+			if m.queue.len() > 0 {
+				m.queue.signal()
+			}
+			m.handle(id, st, j)
+			continue
+		}
+		if j, ok := m.refreshQueue.tryPop(id); ok {
+			if m.refreshQueue.len() > 0 {
+				m.refreshQueue.signal()
+			}
+			m.handle(id, st, j)
+			continue
+		}
+		if m.queue.isClosed() && m.refreshQueue.isClosed() {
+			return
+		}
 
-			info, err := m.provider.Fetch(ctx, rawIP)
-			if err != nil {
-				log.Printf("[Worker %d] 获取 %s 失败: %v", id, rawIP, err)
-				return
+		select {
+		case j, ok := <-queue:
+			if !ok {
+				queue = nil
+				continue
+			}
+			m.handle(id, st, j)
+		case j, ok := <-refresh:
+			if !ok {
+				refresh = nil
+				continue
 			}
+			m.handle(id, st, j)
+		case <-m.queue.notify:
+		case <-m.refreshQueue.notify:
+		case <-m.retire:
+			return
+		case <-m.halt:
+			return
+		}
+	}
+}
+
+// assignID 为未携带 ID 的任务 (预热、扫描、恢复等) 生成任务 ID
+func (j *job) assignID() {
+	if j.ID == "" {
+		j.ID = requestid.NewJobID()
+	}
+}
+
+// task 处理中的任务及其缓存上下文
+type task struct {
+	job
+	key    string
+	rev    uint64 // 处理开始时的缓存版本，写入时用于 CompareAndSet
+	source string
+}
+
+// prepare 检查任务是否仍需解析，已缓存且无需刷新时返回 false
+func (m *Manager) prepare(j job) (task, bool) {
+	t := task{job: j, key: m.cacheKey(j.IP)}
+	m.inflight.Touch(t.key)
+
+	t.rev = m.cache.Revision(t.key)
+	_, found, needsRefresh, _ := m.cache.Get(t.key)
+	if found && !needsRefresh && !j.Force {
+		return t, false
+	}
+
+	t.source = cache.SourceMiss
+	if found {
+		t.source = cache.SourceRefresh
+	}
+	return t, true
+}
+
+// complete 处理上游结果: 失败时安排重试 (返回 true 表示 key 保持处理中)，成功时写入缓存
+func (m *Manager) complete(id int, p provider.IPProvider, t task, info *model.IPInfo, err error, latency time.Duration) bool {
+	if err != nil {
+		workerLog.Warn("获取失败", "worker", id, "ip", t.IP, "key", t.key, "provider", p.Name(), "job_id", t.ID, "request_id", t.RequestID, "err", err)
+		m.reportFailure(p.Name(), t, err)
+		if m.events.HasSubscribers() {
+			m.events.Publish(ResolveEvent{
+				Type: EventFailed, Key: t.key, IP: t.IP, Provider: p.Name(), Source: t.source,
+				LatencyMs: latency.Milliseconds(), Error: err.Error(), JobID: t.ID, RequestID: t.RequestID, Time: time.Now(),
+			})
+		}
+		return m.scheduleRetry(t.job, t.key, err)
+	}
+	m.deadLetters.remove(t.key)
+
+	info.Standardize()
+	tag := info.ToTag()
+
+	if !m.cache.CompareAndSet(t.key, tag, info.EncodeDetail(), t.rev, t.source) {
+		workerLog.Debug("结果已过时, 跳过写入", "worker", id, "ip", t.IP, "key", t.key, "job_id", t.ID)
+		return false
+	}
+
+	if m.events.HasSubscribers() {
+		m.events.Publish(ResolveEvent{
+			Type: EventResolved, Key: t.key, IP: t.IP, Tag: tag, Provider: p.Name(), Source: t.source,
+			LatencyMs: latency.Milliseconds(), JobID: t.ID, RequestID: t.RequestID, Time: time.Now(),
+		})
+	}
+
+	workerLog.Debug("解析完成", "worker", id, "ip", t.IP, "key", t.key, "tag", tag, "provider", p.Name(), "latency", latency, "job_id", t.ID, "request_id", t.RequestID)
+	return false
+}
+
+// reportFailure 提供商连续失败达到阈值时上报 (每轮连续失败只上报一次)
+func (m *Manager) reportFailure(provider string, t task, err error) {
+	if m.errReporter == nil || m.reportFailures <= 0 || m.mon == nil {
+		return
+	}
+	n, ok := m.mon.ClaimFailureReport(provider, m.reportFailures)
+	if !ok {
+		return
+	}
+	m.errReporter.Report(errreport.Event{
+		Kind:      "provider_failure",
+		Message:   fmt.Sprintf("提供商 %s 连续失败 %d 次: %v", provider, n, err),
+		IP:        t.IP,
+		Provider:  provider,
+		RequestID: t.RequestID,
+		JobID:     t.ID,
+		Extra:     map[string]any{"consecutive_failures": n, "key": t.key, "attempt": t.Attempt},
+	})
+}
+
+// recoverPanic 记录任务处理中的 panic，单个异常响应不应终止 Worker
+func (m *Manager) recoverPanic(id int, st *workerStat, j job) {
+	if r := recover(); r != nil {
+		st.errors.Add(1)
+		stack := string(debug.Stack())
+		workerLog.Error("处理任务时发生 panic", "worker", id, "ip", j.IP, "job_id", j.ID, "request_id", j.RequestID, "panic", fmt.Sprint(r), "stack", stack)
+		if m.mon != nil {
+			m.mon.RecordPanic(j.IP, fmt.Sprint(r))
+		}
+		m.errReporter.Report(errreport.Event{
+			Kind: "panic", Message: "panic: " + fmt.Sprint(r), IP: j.IP, RequestID: j.RequestID, JobID: j.ID,
+			Stack: stack, Extra: map[string]any{"worker": id},
+		})
+	}
+}
+
+// process 解析单个任务并写入缓存
+func (m *Manager) process(id int, st *workerStat, j job) {
+	st.begin(j.IP)
+	defer st.end()
+	j.assignID()
+	defer m.recoverPanic(id, st, j)
+
+	t, ok := m.prepare(j)
+
+	// 安排重试时保持处理中状态，避免重复入队
+	retrying := false
+	defer func() {
+		if !retrying {
+			m.inflight.Delete(t.key)
+		}
+	}()
+
+	if !ok {
+		return
+	}
+
+	// 等待配额恢复、自适应并发名额与全局速率许可，关闭时放弃并保存任务
+	if !m.quotaGate.wait(m.halt) {
+		m.abandon(j)
+		return
+	}
+	if !m.adaptive.acquire(m.halt) {
+		m.abandon(j)
+		return
+	}
+	defer m.adaptive.release()
 
-			info.Standardize()
-			tag := info.ToTag()
+	if !m.limiter.wait(m.halt) {
+		m.abandon(j)
+		return
+	}
 
-			m.cache.Set(cacheKey, tag)
+	ctx, cancel := context.WithTimeout(requestid.WithJob(requestid.NewContext(m.runCtx, j.RequestID), t.ID), m.timeoutFor(j.IP))
+	defer cancel()
 
-			m.debugLog("[Worker %d] %s (subnet=%s) -> %s | 耗时=%v", id, rawIP, cacheKey, tag, time.Since(start))
-		}()
+	start := time.Now()
+
+	p := m.providerFor(j.IP)
+	info, err := p.Fetch(ctx, j.IP)
+	latency := time.Since(start)
+
+	// 因关闭被中止的请求不计入失败与重试，直接保存任务
+	if err != nil && m.runCtx.Err() != nil {
+		m.abandon(j)
+		return
 	}
+
+	m.fetchLatency.observe(latency)
+	m.adaptive.observe(latency, err != nil)
+	st.observe(latency, err != nil)
+
+	retrying = m.complete(id, p, t, info, err, latency)
 }
 
 func (m *Manager) GetCacheCount() int64 {
@@ -233,75 +860,44 @@ func (m *Manager) GetCacheCount() int64 {
 	return m.cache.Count()
 }
 
-func (m *Manager) HandleStatistics(w http.ResponseWriter, r *http.Request) {
-    // 1. 获取数据并处理可能的错误
-    items, err := m.cache.GetAllItems()
-    if err != nil {
-        log.Printf("获取统计数据失败: %v", err)
-        http.Error(w, "Failed to retrieve statistics from database", http.StatusInternalServerError)
-        return
-    }
-
-    // map[tag][]string
-    stats := make(map[string][]string)
-    for k, v := range items {
-        stats[v] = append(stats[v], k)
-    }
-
-    // Sort tags
-    var tags []string
-    for t := range stats {
-        tags = append(tags, t)
-    }
-    sort.Strings(tags)
-
-    // 2. 获取丢弃计数 (用于监控磁盘写入压力)
-    droppedCount := m.cache.DroppedCount()
-
-    w.Header().Set("Content-Type", "text/html; charset=utf-8")
-    
-    // 在 HTML 中增加了 Dropped Updates 的展示
-    fmt.Fprintf(w, `<html>
-<head>
-    <title>IP Cache Statistics</title>
-    <style>
-        body { font-family: sans-serif; }
-        table { border-collapse: collapse; width: 100%%; }
-        th, td { border: 1px solid #ddd; padding: 8px; text-align: left; }
-        th { background-color: #f2f2f2; }
-        .metric { margin-bottom: 20px; font-weight: bold; }
-        .warn { color: red; }
-    </style>
-</head>
-<body>
-    <h1>IP Cache Statistics</h1>
-    <div class="metric">
-        <p>Total Cached Items: %d</p>
-        <p>Dropped Updates (Disk Pressure): <span class="%s">%d</span></p>
-    </div>
-    <table>
-        <tr>
-            <th>Tag</th>
-            <th>IP Ranges (Count)</th>
-        </tr>`, 
-        len(items), 
-        func() string { if droppedCount > 0 { return "warn" } else { return "" } }(), //如果有丢弃显示红色
-        droppedCount,
-    )
-
-    for _, tag := range tags {
-        keys := stats[tag]
-        sort.Strings(keys)
-        
-        // 为了展示没那么长，只展示前 50 个 + 计数
-        displayKeys := keys
-        if len(keys) > 50 {
-            displayKeys = keys[:50]
-            displayKeys = append(displayKeys, fmt.Sprintf("... and %d others", len(keys)-50))
-        }
-        
-        fmt.Fprintf(w, "<tr><td>%s</td><td>%s <br/>(Count: %d)</td></tr>", 
-            tag, strings.Join(displayKeys, ", "), len(keys))
-    }
-    fmt.Fprintf(w, "</table></body></html>")
-}
\ No newline at end of file
+// Stats 缓存与队列统计
+type Stats struct {
+	CacheItems     int64
+	DroppedUpdates int64
+	RetriedUpdates int64
+	QueueLength    int // 两个优先级队列的总长度
+	RefreshQueueLength int
+	HotKeys        []cache.HotKey
+}
+
+func (m *Manager) Stats() Stats {
+	return Stats{
+		CacheItems:     m.cache.Count(),
+		DroppedUpdates: m.cache.DroppedCount(),
+		RetriedUpdates: m.cache.RetriedCount(),
+		QueueLength:    m.queue.len() + m.refreshQueue.len(),
+		RefreshQueueLength: m.refreshQueue.len(),
+		HotKeys:        m.hotKeys.Top(HotKeyTopN),
+	}
+}
+
+// SnapshotCache 导出缓存数据库快照，供备份使用
+func (m *Manager) SnapshotCache(dst string) error {
+	return m.cache.Snapshot(dst)
+}
+
+// GetDroppedUpdates 返回持久化缓冲区满而最终丢弃的更新数
+func (m *Manager) GetDroppedUpdates() int64 {
+	if m.cache == nil {
+		return 0
+	}
+	return m.cache.DroppedCount()
+}
+
+// GetCacheDrift 返回累计的缓存计数校准偏差
+func (m *Manager) GetCacheDrift() int64 {
+	if m.cache == nil {
+		return 0
+	}
+	return m.cache.TotalDrift()
+}
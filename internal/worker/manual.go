@@ -0,0 +1,102 @@
+package worker
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"ip-resolver/internal/logging"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+var manualLog = logging.For("Manual")
+
+// ======== 人工指定参数 =========
+const (
+	maxManualBodySize = 4 << 10
+	maxManualTagLen   = 128
+)
+
+// manualTagRequest PUT /{cidr} 的 JSON 请求体；也可直接以纯文本 Tag 作为请求体，配合 ?permanent=1
+type manualTagRequest struct {
+	Tag       string `json:"tag"`
+	Permanent bool   `json:"permanent"` // 永不过期
+}
+
+// serveManualTag 将人工指定的 Tag 写入 CIDR (或单个 IP 所在子网) 覆盖的所有缓存子网并持久化，需要管理鉴权
+func (m *Manager) serveManualTag(w http.ResponseWriter, r *http.Request, raw string) {
+	if !m.requireAdmin(w, r) {
+		return
+	}
+
+	req, err := parseManualTagBody(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var keys []string
+	if strings.Contains(raw, "/") {
+		ips, err := m.expandSubnets(raw, MaxPreloadSubnets)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, ip := range ips {
+			keys = append(keys, m.cacheKey(ip))
+		}
+	} else {
+		ip, errMsg := m.normalizeIP(raw)
+		if errMsg != "" {
+			http.Error(w, errMsg, http.StatusBadRequest)
+			return
+		}
+		keys = []string{m.cacheKey(ip)}
+	}
+
+	for _, key := range keys {
+		m.cache.Pin(key, req.Tag, "", req.Permanent)
+	}
+
+	manualLog.Info("人工指定", "range", raw, "keys", len(keys), "tag", req.Tag, "permanent", req.Permanent)
+
+	writeJSONBody(w, http.StatusOK, struct {
+		CIDR      string `json:"cidr"`
+		Tag       string `json:"tag"`
+		Permanent bool   `json:"permanent"`
+		Count     int    `json:"count"`
+	}{raw, req.Tag, req.Permanent, len(keys)})
+}
+
+// parseManualTagBody 解析 JSON 或纯文本请求体
+func parseManualTagBody(w http.ResponseWriter, r *http.Request) (manualTagRequest, error) {
+	var req manualTagRequest
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxManualBodySize))
+	if err != nil {
+		return req, errors.New("request body too large")
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		if err := json.Unmarshal(body, &req); err != nil {
+			return req, errors.New("invalid json body")
+		}
+	} else {
+		req.Tag = string(body)
+		switch r.URL.Query().Get("permanent") {
+		case "1", "true":
+			req.Permanent = true
+		}
+	}
+
+	req.Tag = strings.TrimSpace(req.Tag)
+	if req.Tag == "" {
+		return req, errors.New("tag is required")
+	}
+	if len(req.Tag) > maxManualTagLen || strings.ContainsAny(req.Tag, "\r\n") {
+		return req, errors.New("invalid tag")
+	}
+	return req, nil
+}
@@ -0,0 +1,84 @@
+package worker
+
+import (
+	"context"
+	"ip-resolver/internal/provider"
+	"net/http"
+)
+
+// RegisterProvider 以配置中的名称 (如 38599) 登记提供商，供 ?provider= 覆盖使用
+func (m *Manager) RegisterProvider(name string, p provider.IPProvider) {
+	if m.providers == nil {
+		m.providers = make(map[string]provider.IPProvider)
+	}
+	m.providers[name] = p
+}
+
+// SetAdminAuth 设置管理鉴权函数，?provider= 等调试能力需要通过该校验
+func (m *Manager) SetAdminAuth(fn func(r *http.Request) bool) {
+	m.adminAuth = fn
+}
+
+// requireAdmin 校验管理鉴权，失败时写出 401 并返回 false
+func (m *Manager) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if m.adminAuth == nil || !m.adminAuth(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="ip-resolver-admin"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// serveProviderOverride 使用指定提供商同步查询 (不读写缓存)，用于排查上游数据差异
+func (m *Manager) serveProviderOverride(w http.ResponseWriter, r *http.Request, ip, name string) {
+	if !m.requireAdmin(w, r) {
+		return
+	}
+
+	p, ok := m.providers[name]
+	if !ok {
+		http.Error(w, "unknown provider: "+name, http.StatusBadRequest)
+		return
+	}
+
+	if !m.limiter.wait(r.Context().Done()) {
+		return
+	}
+
+	timeout, ok := m.namedTimeouts[name]
+	if !ok {
+		timeout = m.fetchTimeout
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	info, err := p.Fetch(ctx, ip)
+	if err != nil {
+		workerLog.Debug("指定提供商查询失败", "ip", ip, "provider", name, "err", err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	info.Standardize()
+	tag := info.ToTag()
+
+	w.Header().Set("X-Cache", "BYPASS")
+	w.Header().Set("X-Cache-Key", m.cacheKey(ip))
+	w.Header().Set("X-Provider", name)
+
+	if wantsJSON(r) {
+		writeJSONBody(w, http.StatusOK, detailResponse{
+			IP:           ip,
+			Key:          m.cacheKey(ip),
+			Tag:          tag,
+			Province:     info.Province,
+			ISP:          info.ISP,
+			ProvinceCode: info.ProvinceCode,
+			ISPCode:      info.ISPCode,
+			Cache:        "BYPASS",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(tag))
+}
@@ -0,0 +1,250 @@
+package worker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"ip-resolver/internal/logging"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var preloadLog = logging.For("Preload")
+
+// ======== 预热参数 =========
+const (
+	MaxPreloadSubnets  = 65536 // 单次请求最多展开的子网数 (IPv4 /8)
+	maxPreloadPending  = 1 << 20
+	defaultPreloadRate = 10
+)
+
+// preloader 按固定速率把待预热子网送入解析队列
+type preloader struct {
+	mu       sync.Mutex
+	pending  []string // 每个子网的代表 IP
+	total    int64
+	enqueued int64
+	skipped  int64
+
+	rate int // 每秒入队数
+	wake chan struct{}
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newPreloader(rate int) *preloader {
+	if rate <= 0 {
+		rate = defaultPreloadRate
+	}
+	return &preloader{
+		rate: rate,
+		wake: make(chan struct{}, 1),
+		quit: make(chan struct{}),
+	}
+}
+
+// PreloadStatus 预热进度
+type PreloadStatus struct {
+	Pending  int   `json:"pending"`
+	Total    int64 `json:"total"`
+	Enqueued int64 `json:"enqueued"`
+	Skipped  int64 `json:"skipped"` // 已缓存或正在解析而跳过
+	Rate     int   `json:"rate"`
+}
+
+func (p *preloader) status() PreloadStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return PreloadStatus{
+		Pending:  len(p.pending),
+		Total:    p.total,
+		Enqueued: p.enqueued,
+		Skipped:  p.skipped,
+		Rate:     p.rate,
+	}
+}
+
+// Preload 把 CIDR 内的每个缓存子网 (IPv4 /24，IPv6 为 ipv6_prefix_len) 加入预热列表，返回新增数量
+func (m *Manager) Preload(cidrs []string) (int, error) {
+	var ips []string
+	for _, raw := range cidrs {
+		sub, err := m.expandSubnets(raw, MaxPreloadSubnets-len(ips))
+		if err != nil {
+			return 0, err
+		}
+		ips = append(ips, sub...)
+	}
+
+	p := m.preload
+	p.mu.Lock()
+	if len(p.pending)+len(ips) > maxPreloadPending {
+		p.mu.Unlock()
+		return 0, errors.New("too many pending preload subnets")
+	}
+	p.pending = append(p.pending, ips...)
+	p.total += int64(len(ips))
+	p.mu.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+
+	preloadLog.Info("新增待预热子网", "count", len(ips))
+	return len(ips), nil
+}
+
+// expandSubnets 解析 CIDR 并按缓存粒度 (IPv4 /24，IPv6 为 ipv6_prefix_len) 展开为各子网的网络地址
+func (m *Manager) expandSubnets(raw string, limit int) ([]string, error) {
+	_, n, err := net.ParseCIDR(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cidr: %s", raw)
+	}
+
+	prefixLen := 24
+	if n.IP.To4() == nil {
+		if m.ipv6PrefixLen == 0 {
+			return nil, errors.New("ipv6 not enabled")
+		}
+		prefixLen = m.ipv6PrefixLen
+	}
+
+	return expandCIDR(n, prefixLen, limit)
+}
+
+// expandCIDR 按 prefixLen 枚举 CIDR 内的子网，返回各子网的网络地址
+// CIDR 本身比 prefixLen 更小时返回其所在的单个子网
+func expandCIDR(n *net.IPNet, prefixLen, limit int) ([]string, error) {
+	ones, bits := n.Mask.Size()
+	if ones >= prefixLen {
+		return []string{n.IP.String()}, nil
+	}
+
+	shift := prefixLen - ones
+	if shift > 30 || 1<<shift > limit {
+		return nil, fmt.Errorf("cidr %s too large (max %d subnets)", n.String(), MaxPreloadSubnets)
+	}
+
+	ip := make(net.IP, len(n.IP))
+	copy(ip, n.IP)
+
+	count := 1 << shift
+	res := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		res = append(res, ip.String())
+		incrementAt(ip, bits-prefixLen)
+	}
+	return res, nil
+}
+
+// incrementAt 把 IP 视为大整数加上 2^bit
+func incrementAt(ip net.IP, bit int) {
+	idx := len(ip) - 1 - bit/8
+	add := 1 << (bit % 8)
+	for ; idx >= 0 && add > 0; idx-- {
+		sum := int(ip[idx]) + add
+		ip[idx] = byte(sum)
+		add = sum >> 8
+	}
+}
+
+// runPreload 按速率把待预热子网送入解析队列，队列满时等待而不丢弃
+func (m *Manager) runPreload() {
+	p := m.preload
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		ticker := time.NewTicker(time.Second / time.Duration(p.rate))
+		defer ticker.Stop()
+
+		for {
+			p.mu.Lock()
+			if len(p.pending) == 0 {
+				p.pending = nil
+				p.mu.Unlock()
+				select {
+				case <-p.wake:
+					continue
+				case <-p.quit:
+					return
+				}
+			}
+			ip := p.pending[0]
+			p.pending = p.pending[1:]
+			p.mu.Unlock()
+
+			key := m.cacheKey(ip)
+			if _, found, needsRefresh, _ := m.cache.Get(key); (found && !needsRefresh) || !m.inflight.TryAdd(key) {
+				p.mu.Lock()
+				p.skipped++
+				p.mu.Unlock()
+				continue
+			}
+
+			select {
+			case <-ticker.C:
+			case <-p.quit:
+				m.inflight.Delete(key)
+				return
+			}
+
+			if !m.refreshQueue.push(job{IP: ip, Background: true}, p.quit) {
+				m.inflight.Delete(key)
+				return
+			}
+			// 队列满时 push 可能阻塞较久，入队后刷新活动时间
+			m.inflight.Touch(key)
+			p.mu.Lock()
+			p.enqueued++
+			p.mu.Unlock()
+		}
+	}()
+}
+
+// stopPreload 停止预热 (需在关闭解析队列之前调用)
+func (m *Manager) stopPreload() {
+	close(m.preload.quit)
+	m.preload.wg.Wait()
+}
+
+// HandlePreload POST: 提交 CIDR 列表 (JSON 数组或按行分隔)；GET: 查询预热进度
+func (m *Manager) HandlePreload(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSONBody(w, http.StatusOK, m.preload.status())
+
+	case http.MethodPost:
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBatchBodySize))
+		if err != nil {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		cidrs, err := parseBatchBody(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		n, err := m.Preload(cidrs)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(struct {
+			Accepted int `json:"accepted"`
+			PreloadStatus
+		}{n, m.preload.status()})
+
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,107 @@
+package worker
+
+import (
+	"ip-resolver/internal/cache"
+)
+
+// abandon 记录因关闭而未能送回队列的任务 (如等待中的重试)，随队列一起保存
+func (m *Manager) abandon(j job) {
+	m.abandonedMu.Lock()
+	m.abandoned = append(m.abandoned, j)
+	m.abandonedMu.Unlock()
+}
+
+// saveQueue 在 Worker 停止后取出队列中剩余的任务并写入 SQLite，下次启动时恢复 (未配置持久化时丢弃)
+// 需在所有入队方 (预热、重试、Worker) 停止之后调用，队列可能已关闭
+func (m *Manager) saveQueue() {
+	var jobs []cache.QueuedJob
+	add := func(j job) {
+		jobs = append(jobs, cache.QueuedJob{ID: j.ID, IP: j.IP, RequestID: j.RequestID, Attempt: j.Attempt, Background: j.Background})
+	}
+
+	for _, q := range []*workQueue{m.queue, m.refreshQueue} {
+		for {
+			j, ok := q.tryPop(0)
+			if !ok {
+				break
+			}
+			add(j)
+		}
+	}
+
+	m.abandonedMu.Lock()
+	for _, j := range m.abandoned {
+		add(j)
+	}
+	m.abandoned = nil
+	m.abandonedMu.Unlock()
+
+	// 尚未送入队列的预热子网
+	m.preload.mu.Lock()
+	for _, ip := range m.preload.pending {
+		add(job{IP: ip, Background: true})
+	}
+	m.preload.mu.Unlock()
+
+	if !m.cache.HasStore() {
+		if len(jobs) > 0 {
+			queueLog.Warn("未配置持久化, 丢弃待解析任务", "count", len(jobs))
+		}
+		return
+	}
+
+	if err := m.cache.SaveQueue(jobs); err != nil {
+		queueLog.Error("保存待解析任务失败", "err", err)
+		return
+	}
+	if len(jobs) > 0 {
+		queueLog.Info("已保存待解析任务，下次启动时恢复", "count", len(jobs))
+	}
+}
+
+// RestoreQueue 零停机重启时由新进程在旧进程退出后调用，接管旧进程在 Stop 中保存的任务
+// (Start 中的恢复早于旧进程保存，只能取到更早的残留)，Stop 之后调用为空操作
+func (m *Manager) RestoreQueue() {
+	if !m.cache.HasStore() {
+		return
+	}
+
+	m.queueMu.Lock()
+	defer m.queueMu.Unlock()
+	if m.stopping {
+		return
+	}
+	m.restoreQueue()
+}
+
+// restoreQueue 恢复上次关闭时保存的待解析任务，已缓存的子网由 Worker 跳过，队列已满时丢弃
+func (m *Manager) restoreQueue() {
+	jobs, err := m.cache.TakeQueue()
+	if err != nil {
+		queueLog.Error("读取待解析任务失败", "err", err)
+		return
+	}
+
+	restored, dropped := 0, 0
+	for _, qj := range jobs {
+		key := m.cacheKey(qj.IP)
+		if !m.inflight.TryAdd(key) {
+			continue
+		}
+
+		queue := m.queue
+		if qj.Background {
+			queue = m.refreshQueue
+		}
+		if queue.tryPush(job{ID: qj.ID, IP: qj.IP, RequestID: qj.RequestID, Attempt: qj.Attempt, Background: qj.Background}) {
+			restored++
+		} else {
+			m.inflight.Delete(key)
+			dropped++
+		}
+	}
+
+	if restored > 0 || dropped > 0 {
+		queueLog.Info("恢复待解析任务", "restored", restored, "dropped", dropped)
+	}
+}
@@ -0,0 +1,134 @@
+package worker
+
+import (
+	"context"
+	"ip-resolver/internal/logging"
+	"sync"
+	"time"
+)
+
+var quotaLog = logging.For("Quota")
+
+// quotaGate 配额耗尽时暂停上游请求，恢复后放行等待中的 Worker
+type quotaGate struct {
+	mu     sync.Mutex
+	paused bool
+	resume chan struct{} // 暂停期间有效，恢复时关闭
+	since  time.Time
+}
+
+func (g *quotaGate) pause() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.paused {
+		return false
+	}
+	g.paused = true
+	g.resume = make(chan struct{})
+	g.since = time.Now()
+	return true
+}
+
+func (g *quotaGate) unpause() (time.Duration, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.paused {
+		return 0, false
+	}
+	g.paused = false
+	close(g.resume)
+	return time.Since(g.since), true
+}
+
+// wait 暂停期间阻塞到恢复，done 关闭时返回 false
+func (g *quotaGate) wait(done <-chan struct{}) bool {
+	g.mu.Lock()
+	paused, resume := g.paused, g.resume
+	g.mu.Unlock()
+
+	if !paused {
+		return true
+	}
+	select {
+	case <-resume:
+		return true
+	case <-done:
+		return false
+	}
+}
+
+// QuotaPaused 配额耗尽、上游请求已暂停时返回 true
+func (m *Manager) QuotaPaused() bool {
+	return m.quotaGate.isPaused()
+}
+
+func (g *quotaGate) isPaused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.paused
+}
+
+// RemainingQuota 返回配额检查最近一次查询到的剩余配额 (-1 为未知或未启用检查)
+func (m *Manager) RemainingQuota() int64 {
+	return m.lastQuota.Load()
+}
+
+// SetQuotaFetcher 设置剩余配额查询函数 (返回 -1 表示未知)，配额为 0 时暂停上游请求
+func (m *Manager) SetQuotaFetcher(f func(context.Context) int64) {
+	m.quotaFetcher = f
+}
+
+// runQuotaWatch 定期查询剩余配额 (唯一的配额轮询)：结果同步给 /status，耗尽时暂停 Worker 的上游请求，
+// 暂停期间按探测间隔检查是否恢复
+func (m *Manager) runQuotaWatch() {
+	if m.quotaFetcher == nil || m.quotaInterval <= 0 {
+		return
+	}
+
+	go func() {
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-timer.C:
+			case <-m.stopCh:
+				return
+			}
+
+			// 查询失败或超时 (-1) 时保持当前状态
+			remaining := m.fetchQuota()
+			if remaining >= 0 {
+				m.lastQuota.Store(remaining)
+				if m.mon != nil {
+					m.mon.SetRemainingQuota(remaining)
+				}
+			}
+			switch {
+			case remaining == 0:
+				if m.quotaGate.pause() {
+					quotaLog.Warn("剩余配额为 0, 暂停上游请求", "probe_interval", m.quotaProbeInterval)
+				}
+			case remaining > 0:
+				if d, ok := m.quotaGate.unpause(); ok {
+					quotaLog.Info("配额已恢复, 继续上游请求", "remaining", remaining, "paused", d.Round(time.Second))
+				}
+			}
+
+			next := m.quotaInterval
+			if m.quotaGate.isPaused() {
+				next = m.quotaProbeInterval
+			}
+			timer.Reset(next)
+		}
+	}()
+}
+
+// fetchQuota 查询剩余配额，超过 quotaTimeout 即放弃 (返回 -1)
+func (m *Manager) fetchQuota() int64 {
+	ctx, cancel := context.WithTimeout(m.runCtx, m.quotaTimeout)
+	defer cancel()
+	return m.quotaFetcher(ctx)
+}
@@ -0,0 +1,61 @@
+package worker
+
+import (
+	"context"
+	"ip-resolver/internal/monitor"
+	"testing"
+	"time"
+)
+
+// 配额检查是唯一的配额轮询: 结果同时用于暂停上游请求与 /status，单次查询受 quotaTimeout 限制
+func TestQuotaWatch(t *testing.T) {
+	called := make(chan struct{})
+	script := make(chan int64)
+	mon := monitor.New()
+	m := &Manager{
+		mon:                mon,
+		runCtx:             context.Background(),
+		stopCh:             make(chan struct{}),
+		quotaInterval:      5 * time.Millisecond,
+		quotaProbeInterval: 5 * time.Millisecond,
+		quotaTimeout:       20 * time.Millisecond,
+	}
+	m.lastQuota.Store(-1)
+	m.SetQuotaFetcher(func(ctx context.Context) int64 {
+		select {
+		case called <- struct{}{}:
+		case <-ctx.Done():
+			return -1
+		}
+		select {
+		case n := <-script:
+			return n
+		case <-ctx.Done():
+			return -1
+		}
+	})
+	m.runQuotaWatch()
+	defer close(m.stopCh)
+
+	// 每次查询开始时上一次的结果已生效
+	check := func(paused bool, remaining int64) {
+		t.Helper()
+		receive(t, called, 1)
+		if m.QuotaPaused() != paused {
+			t.Fatalf("QuotaPaused = %v, want %v", m.QuotaPaused(), paused)
+		}
+		if got := m.RemainingQuota(); got != remaining {
+			t.Fatalf("RemainingQuota = %d, want %d", got, remaining)
+		}
+		if got := mon.Snapshot().RemainingRequestNum; got != remaining {
+			t.Fatalf("monitor remaining = %d, want %d", got, remaining)
+		}
+	}
+
+	check(false, -1)
+	script <- 0
+	check(true, 0) // 本次不回应，查询超时
+	check(true, 0) // 超时保留上次的值与暂停状态
+	script <- 500
+	check(false, 500)
+}
@@ -0,0 +1,79 @@
+package worker
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// rangeEntry CIDR 范围查询中的单个缓存子网
+type rangeEntry struct {
+	Key    string `json:"key"`
+	Subnet string `json:"subnet"`
+	Tag    string `json:"tag"`
+}
+
+// HandleRange 返回与 CIDR 重叠的所有已缓存子网及其 Tag: GET /range/{cidr}
+func (m *Manager) HandleRange(w http.ResponseWriter, r *http.Request) {
+	raw := strings.TrimPrefix(r.URL.Path, "/range/")
+	if raw == "" {
+		raw = r.URL.Query().Get("cidr")
+	}
+
+	_, query, err := net.ParseCIDR(raw)
+	if err != nil {
+		http.Error(w, "invalid cidr", http.StatusBadRequest)
+		return
+	}
+
+	entries := []rangeEntry{}
+	m.cache.Range(func(key, val string) bool {
+		subnet := keyToSubnet(key)
+		if subnet != nil && overlaps(query, subnet) {
+			entries = append(entries, rangeEntry{Key: key, Subnet: subnet.String(), Tag: val})
+		}
+		return true
+	})
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, _, _ := net.ParseCIDR(entries[i].Subnet)
+		b, _, _ := net.ParseCIDR(entries[j].Subnet)
+		return bytes.Compare(a.To16(), b.To16()) < 0
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(struct {
+		CIDR    string       `json:"cidr"`
+		Count   int          `json:"count"`
+		Subnets []rangeEntry `json:"subnets"`
+	}{
+		CIDR:    query.String(),
+		Count:   len(entries),
+		Subnets: entries,
+	})
+}
+
+// keyToSubnet 将缓存 Key 还原为子网: "1.2.3" -> 1.2.3.0/24，IPv6 Key 本身即 CIDR
+func keyToSubnet(key string) *net.IPNet {
+	if isIPv6(key) {
+		_, n, err := net.ParseCIDR(key)
+		if err != nil {
+			return nil
+		}
+		return n
+	}
+
+	_, n, err := net.ParseCIDR(key + ".0/24")
+	if err != nil {
+		return nil
+	}
+	return n
+}
+
+// overlaps 判断两个网段是否有交集
+func overlaps(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
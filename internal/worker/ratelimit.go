@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter 所有 Worker 共享的上游请求速率上限，按固定间隔依次发放许可 (不允许突发)
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration // 0 为不限速
+	next     time.Time
+}
+
+// newRateLimiter rps <= 0 时不限速，之后可通过 setRate 调整 (配置热加载)
+func newRateLimiter(rps float64) *rateLimiter {
+	l := &rateLimiter{}
+	l.setRate(rps)
+	return l
+}
+
+// setRate 修改速率上限，rps <= 0 为不限速
+func (l *rateLimiter) setRate(rps float64) {
+	var interval time.Duration
+	if rps > 0 {
+		interval = time.Duration(float64(time.Second) / rps)
+	}
+	l.mu.Lock()
+	l.interval = interval
+	l.mu.Unlock()
+}
+
+// wait 阻塞到获得许可，done 关闭时放弃等待并返回 false (已预留的时间片不归还)
+func (l *rateLimiter) wait(done <-chan struct{}) bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	if l.interval <= 0 {
+		l.mu.Unlock()
+		return true
+	}
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	at := l.next
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	d := time.Until(at)
+	if d <= 0 {
+		return true
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-done:
+		return false
+	}
+}
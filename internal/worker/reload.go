@@ -0,0 +1,68 @@
+package worker
+
+import "time"
+
+// ======== 配置热加载 =========
+// 以下方法仅由配置热加载调用 (主循环中串行执行)
+
+// SetConcurrency 调整固定 Worker 数量，启用自动伸缩 (worker_max_concurrency > 0) 时返回 false
+// 缩容时由空闲的 Worker 依次退出，处理中的任务不受影响；上一次缩容尚未完成时先取消，按实际数量重新计算
+func (m *Manager) SetConcurrency(n int) bool {
+	if m.maxWorkers > 0 || n <= 0 {
+		return false
+	}
+
+	cur := m.concurrency
+	if r := m.retiring; r != nil {
+		close(r.cancel)
+		cur += <-r.left
+		m.retiring = nil
+	}
+	m.concurrency = n
+	switch {
+	case n > cur:
+		m.spawnWorkers(n - cur)
+	case n < cur:
+		m.retiring = m.retireWorkers(cur - n)
+	}
+	return true
+}
+
+// retirement 逐个发送的缩容信号
+type retirement struct {
+	cancel chan struct{}
+	left   chan int // 结束时尚未发送的信号数
+}
+
+// retireWorkers 在后台发送 k 个退出信号 (每次只能被一个空闲 Worker 领取)，
+// 被取消或 Manager 停止时结束
+func (m *Manager) retireWorkers(k int) *retirement {
+	r := &retirement{cancel: make(chan struct{}), left: make(chan int, 1)}
+	m.scaleWg.Add(1)
+	go func() {
+		defer m.scaleWg.Done()
+		for ; k > 0; k-- {
+			select {
+			case m.retire <- struct{}{}:
+			case <-r.cancel:
+				r.left <- k
+				return
+			case <-m.scaleQuit:
+				r.left <- k
+				return
+			}
+		}
+		r.left <- 0
+	}()
+	return r
+}
+
+// SetProviderRateLimit 修改上游全局 QPS 上限，rps <= 0 为不限速
+func (m *Manager) SetProviderRateLimit(rps float64) {
+	m.limiter.setRate(rps)
+}
+
+// SetCacheTTL 修改新写入缓存条目的有效期，已有条目保持原过期时间
+func (m *Manager) SetCacheTTL(ttl time.Duration) {
+	m.cache.SetTTL(ttl)
+}
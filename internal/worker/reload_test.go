@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+func receive(t *testing.T, ch <-chan struct{}, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-ch:
+		case <-time.After(time.Second):
+			t.Fatalf("received %d of %d retire signals", i, n)
+		}
+	}
+}
+
+// 缩容进行中再次调整时按实际剩余数量计算，发出的退出信号总数等于净缩容数
+func TestSetConcurrencyRetire(t *testing.T) {
+	m := &Manager{concurrency: 10, retire: make(chan struct{}, 1), scaleQuit: make(chan struct{})}
+
+	if !m.SetConcurrency(2) {
+		t.Fatal("SetConcurrency refused without autoscale")
+	}
+	receive(t, m.retire, 3) // 3 个 Worker 已退出，剩余 7
+
+	if !m.SetConcurrency(5) {
+		t.Fatal("SetConcurrency refused")
+	}
+	receive(t, m.retire, 2)
+	m.scaleWg.Wait() // 全部信号已被领取，后台发送结束
+	select {
+	case <-m.retire:
+		t.Fatal("extra retire signal")
+	default:
+	}
+
+	// 没有空闲 Worker 领取时，停止后后台发送随之结束
+	m.SetConcurrency(1)
+	close(m.scaleQuit)
+	m.scaleWg.Wait()
+
+	if (&Manager{maxWorkers: 4}).SetConcurrency(2) {
+		t.Fatal("SetConcurrency should be refused under autoscale")
+	}
+}
@@ -0,0 +1,234 @@
+package worker
+
+import (
+	"ip-resolver/internal/config"
+	"ip-resolver/internal/logging"
+	"ip-resolver/internal/provider"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var deadLetterLog = logging.For("DeadLetter")
+
+// ======== 重试参数 =========
+const (
+	maxRetryBackoff    = time.Minute
+	maxRequeueDelay    = config.MaxRequeueDelaySeconds * time.Second // inflight_max_age_seconds 需大于该值，避免等待中被当作泄漏清除
+	maxDeadLetterItems = 1000
+
+	retryWheelTick  = 100 * time.Millisecond
+	retryWheelSlots = 600 // 一圈 60 秒
+)
+
+// retryBackoff 第 attempt 次重试前的等待时间: base * 2^(attempt-1)，上限 maxRetryBackoff
+func (m *Manager) retryBackoff(attempt int) time.Duration {
+	d := m.retryBase
+	for i := 1; i < attempt && d < maxRetryBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxRetryBackoff)
+}
+
+// requeueDelay 第 n 次因短暂故障重新入队前的等待时间: base * 2^(n-1)，上限 maxRequeueDelay
+func (m *Manager) requeueDelay(n int) time.Duration {
+	d := m.retryBase
+	for i := 1; i < n && d < maxRequeueDelay; i++ {
+		d *= 2
+	}
+	return min(d, maxRequeueDelay)
+}
+
+// scheduleRetry 失败的任务按退避时间延迟重新入队，返回 true 表示已安排重试 (key 保持处理中)
+// 超时、连接失败、5xx 等短暂故障不占用重试次数，最多重新入队 maxRequeues 次；其余错误超过重试次数时写入死信列表
+func (m *Manager) scheduleRetry(j job, key string, err error) bool {
+	var delay time.Duration
+	switch {
+	case provider.IsTransient(err) && j.Requeues < m.maxRequeues:
+		j.Requeues++
+		delay = m.requeueDelay(j.Requeues)
+		queueLog.Debug("上游短暂故障, 稍后重新入队", "ip", j.IP, "job_id", j.ID, "delay", delay, "requeues", j.Requeues)
+	case j.Attempt < m.maxRetries:
+		j.Attempt++
+		delay = m.retryBackoff(j.Attempt)
+		queueLog.Debug("解析失败, 稍后重试", "ip", j.IP, "job_id", j.ID, "delay", delay, "attempt", j.Attempt)
+	default:
+		m.deadLetters.add(DeadLetter{
+			Key: key, IP: j.IP, Error: err.Error(), Attempts: j.Attempt + j.Requeues + 1, JobID: j.ID, RequestID: j.RequestID, Time: time.Now(),
+		})
+		return false
+	}
+
+	// 正在关闭时不再安排重试
+	if !m.retryWheel.add(delay, j) {
+		m.abandon(j)
+		return false
+	}
+	m.inflight.Touch(key)
+	return true
+}
+
+// requeueDelayed 时间轮到期回调，队列已满时返回 false 由时间轮推迟
+func (m *Manager) requeueDelayed(j job) bool {
+	queue := m.queue
+	if j.Background {
+		queue = m.refreshQueue
+	}
+	if !queue.tryPush(j) {
+		return false
+	}
+	m.inflight.Touch(m.cacheKey(j.IP))
+	return true
+}
+
+// stopRetry 放弃等待中的重试并保存 (需在关闭解析队列之前调用)
+func (m *Manager) stopRetry() {
+	for _, j := range m.retryWheel.stop() {
+		m.inflight.Delete(m.cacheKey(j.IP))
+		m.abandon(j)
+	}
+}
+
+// ================= 死信列表 ===================
+
+// DeadLetter 重试耗尽后仍失败的解析任务
+type DeadLetter struct {
+	Key       string    `json:"key"`
+	IP        string    `json:"ip"`
+	Error     string    `json:"error"`
+	Attempts  int       `json:"attempts"`
+	JobID     string    `json:"job_id,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// deadLetterList 按 key 去重的死信列表，超出容量时淘汰最早的条目
+type deadLetterList struct {
+	mu    sync.Mutex
+	items map[string]DeadLetter
+	order []string
+}
+
+func (d *deadLetterList) add(item DeadLetter) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.items == nil {
+		d.items = make(map[string]DeadLetter)
+	}
+	if _, ok := d.items[item.Key]; !ok {
+		d.order = append(d.order, item.Key)
+	}
+	d.items[item.Key] = item
+
+	for len(d.order) > maxDeadLetterItems {
+		delete(d.items, d.order[0])
+		d.order = d.order[1:]
+	}
+}
+
+func (d *deadLetterList) remove(key string) (DeadLetter, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	item, ok := d.items[key]
+	if !ok {
+		return item, false
+	}
+	delete(d.items, key)
+	for i, k := range d.order {
+		if k == key {
+			d.order = append(d.order[:i], d.order[i+1:]...)
+			break
+		}
+	}
+	return item, true
+}
+
+// list 按写入顺序返回所有条目
+func (d *deadLetterList) list() []DeadLetter {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	res := make([]DeadLetter, 0, len(d.order))
+	for _, k := range d.order {
+		res = append(res, d.items[k])
+	}
+	return res
+}
+
+func (d *deadLetterList) len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.order)
+}
+
+// RequeueDeadLetter 将死信重新送入解析队列 (重置重试次数)，key 为空时处理全部
+// 已有同一 key 的解析在进行中时移出死信并计为 Inflight (该次解析失败会重新进入死信)，
+// 队列已满时放回死信列表并计为 Rejected
+func (m *Manager) RequeueDeadLetter(key string) RequeueResult {
+	var items []DeadLetter
+	if key != "" {
+		if item, ok := m.deadLetters.remove(key); ok {
+			items = append(items, item)
+		}
+	} else {
+		items = m.deadLetters.list()
+		for _, item := range items {
+			m.deadLetters.remove(item.Key)
+		}
+	}
+
+	var res RequeueResult
+	for _, item := range items {
+		if !m.inflight.TryAdd(item.Key) {
+			res.Inflight++
+			continue
+		}
+		if !m.queue.tryPush(job{IP: item.IP, RequestID: item.RequestID}) {
+			m.inflight.Delete(item.Key)
+			m.deadLetters.add(item)
+			res.Rejected++
+			continue
+		}
+		res.Requeued++
+	}
+
+	if res.Requeued > 0 || res.Inflight > 0 || res.Rejected > 0 {
+		deadLetterLog.Info("重新入队", "count", res.Requeued, "inflight", res.Inflight, "rejected", res.Rejected)
+	}
+	return res
+}
+
+// HandleAdminDeadLetter GET: 列出死信；POST: 重新入队 (?key= 指定，缺省为全部)；DELETE: 清除 (?key= 指定，缺省为全部)
+func (m *Manager) HandleAdminDeadLetter(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+
+	switch r.Method {
+	case http.MethodGet:
+		items := m.deadLetters.list()
+		writeJSONBody(w, http.StatusOK, map[string]any{"count": len(items), "items": items})
+
+	case http.MethodPost:
+		writeJSONBody(w, http.StatusOK, m.RequeueDeadLetter(key))
+
+	case http.MethodDelete:
+		removed := 0
+		if key != "" {
+			if _, ok := m.deadLetters.remove(key); ok {
+				removed = 1
+			}
+		} else {
+			for _, item := range m.deadLetters.list() {
+				if _, ok := m.deadLetters.remove(item.Key); ok {
+					removed++
+				}
+			}
+		}
+		writeJSONBody(w, http.StatusOK, map[string]any{"removed": removed})
+
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRequeueDelay(t *testing.T) {
+	m := &Manager{retryBase: time.Second}
+	tests := []struct {
+		n    int
+		want time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{5, 16 * time.Second},
+		{7, 64 * time.Second},
+		{8, maxRequeueDelay}, // 128s 截断为 120s
+		{1000, maxRequeueDelay},
+	}
+	for _, tt := range tests {
+		if got := m.requeueDelay(tt.n); got != tt.want {
+			t.Errorf("requeueDelay(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	m := &Manager{retryBase: 500 * time.Millisecond}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 500 * time.Millisecond},
+		{3, 2 * time.Second},
+		{7, 32 * time.Second},
+		{8, maxRetryBackoff},
+		{64, maxRetryBackoff}, // 不溢出
+	}
+	for _, tt := range tests {
+		if got := m.retryBackoff(tt.attempt); got != tt.want {
+			t.Errorf("retryBackoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestRequeueDeadLetter(t *testing.T) {
+	m := &Manager{inflight: newInflightSet(), queue: newWorkQueue(1, 1)}
+	for _, k := range []string{"1.1.1.0", "2.2.2.0", "3.3.3.0"} {
+		m.deadLetters.add(DeadLetter{Key: k, IP: k})
+	}
+	m.inflight.TryAdd("2.2.2.0") // 正在解析
+
+	res := m.RequeueDeadLetter("")
+	// 队列容量为 1: 1.1.1.0 入队，2.2.2.0 正在解析，3.3.3.0 因队列已满留在死信
+	if res.Requeued != 1 || res.Inflight != 1 || res.Rejected != 1 {
+		t.Fatalf("RequeueDeadLetter = %+v", res)
+	}
+	if items := m.deadLetters.list(); len(items) != 1 || items[0].Key != "3.3.3.0" {
+		t.Fatalf("dead letters left = %+v", items)
+	}
+	if !m.inflight.TryAdd("3.3.3.0") {
+		t.Fatal("rejected key left in the inflight set")
+	}
+	m.inflight.Delete("3.3.3.0")
+
+	if res := m.RequeueDeadLetter("9.9.9.0"); res.Requeued+res.Inflight+res.Rejected != 0 {
+		t.Fatalf("unknown key: %+v", res)
+	}
+}
@@ -0,0 +1,275 @@
+package worker
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"ip-resolver/internal/logging"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var spillLog = logging.For("Spill")
+
+/*
+spillJournal：
+- 首次查询队列已满时的溢出日志 (JSON Lines，仅追加)
+- 由单独的协程按队列空闲情况依次取出送回队列
+- 全部取出后截断文件；进程重启后继续消费上次未处理的内容
+- 持有文件的排他锁 (flock)；零停机重启时旧进程仍持有锁，新进程在其退出后再接管
+*/
+type spillJournal struct {
+	mu     sync.Mutex
+	w      *os.File // O_APPEND 写入
+	rf     *os.File
+	r      *bufio.Reader
+	locked bool // 是否已取得文件锁，未取得前不读写
+	count  int  // 未取出的条目数
+	max    int
+	notify chan struct{} // 写入后通知消费协程
+}
+
+type spilledJob struct {
+	ID        string `json:"id,omitempty"`
+	IP        string `json:"ip"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// spillLockRetry 文件锁被其他进程 (零停机重启中的旧进程) 持有时重试的间隔
+const spillLockRetry = 100 * time.Millisecond
+
+func openSpillJournal(path string, maxItems int) (*spillJournal, error) {
+	w, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	rf, err := os.Open(path)
+	if err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+
+	s := &spillJournal{
+		w:      w,
+		rf:     rf,
+		r:      bufio.NewReader(rf),
+		max:    maxItems,
+		notify: make(chan struct{}, 1),
+	}
+	if _, err := s.tryLock(); err != nil {
+		_ = w.Close()
+		_ = rf.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// tryLock 尝试取得文件锁并统计上次遗留的条目，锁被其他进程持有时返回 false
+func (s *spillJournal) tryLock() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.locked {
+		return true, nil
+	}
+	if err := syscall.Flock(int(s.w.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return false, nil
+		}
+		return false, fmt.Errorf("lock spill journal failed: %w", err)
+	}
+
+	count := 0
+	sc := bufio.NewScanner(s.rf)
+	for sc.Scan() {
+		count++
+	}
+	if err := sc.Err(); err != nil {
+		return false, fmt.Errorf("read spill journal failed: %w", err)
+	}
+	if _, err := s.rf.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	s.r.Reset(s.rf)
+	s.count += count
+	s.locked = true
+	return true, nil
+}
+
+// push 追加一个任务，日志已满或写入失败时返回 false
+func (s *spillJournal) push(j job) bool {
+	if s == nil {
+		return false
+	}
+
+	line, err := json.Marshal(spilledJob{ID: j.ID, IP: j.IP, RequestID: j.RequestID})
+	if err != nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.locked || (s.max > 0 && s.count >= s.max) {
+		return false
+	}
+	if _, err := s.w.Write(append(line, '\n')); err != nil {
+		spillLog.Error("写入溢出日志失败", "err", err)
+		return false
+	}
+	s.count++
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// pop 取出最早的任务，日志为空时返回 false；损坏的行被跳过
+func (s *spillJournal) pop() (job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for s.count > 0 {
+		line, err := s.r.ReadBytes('\n')
+		if err != nil && !(errors.Is(err, io.EOF) && len(line) > 0) {
+			// 计数与文件内容不一致 (如文件被外部修改)，重置
+			spillLog.Error("读取溢出日志失败, 丢弃剩余任务", "dropped", s.count, "err", err)
+			s.count = 0
+			s.reset()
+			break
+		}
+		s.count--
+		if s.count == 0 {
+			s.reset()
+		}
+
+		var sj spilledJob
+		if json.Unmarshal(line, &sj) != nil || sj.IP == "" {
+			continue
+		}
+		return job{ID: sj.ID, IP: sj.IP, RequestID: sj.RequestID}, true
+	}
+	return job{}, false
+}
+
+// reset 全部取出后截断文件 (需持有 mu)
+func (s *spillJournal) reset() {
+	if err := s.w.Truncate(0); err != nil {
+		spillLog.Error("截断溢出日志失败", "err", err)
+		return
+	}
+	_, _ = s.rf.Seek(0, io.SeekStart)
+	s.r.Reset(s.rf)
+}
+
+func (s *spillJournal) len() int {
+	if s == nil {
+		return 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count
+}
+
+func (s *spillJournal) close() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = s.w.Close()
+	_ = s.rf.Close()
+}
+
+// runSpillFeeder 在首次查询队列有空位时把溢出日志中的任务依次送回
+func (m *Manager) runSpillFeeder() {
+	if m.spill == nil {
+		return
+	}
+
+	m.spillWg.Add(1)
+	go func() {
+		defer m.spillWg.Done()
+
+		if !m.waitSpillLock() {
+			return
+		}
+		if n := m.spill.len(); n > 0 {
+			spillLog.Info("溢出日志中有上次未处理的任务", "count", n)
+		}
+
+		for {
+			j, ok := m.spill.pop()
+			if !ok {
+				select {
+				case <-m.spill.notify:
+					continue
+				case <-m.spillQuit:
+					return
+				}
+			}
+
+			// 重启后恢复的任务没有处理中标记，补上以避免重复入队；
+			// 已有的标记从写入溢出日志起未再活动，送回队列时刷新，避免在日志中排队期间被当作泄漏清除
+			key := m.cacheKey(j.IP)
+			if !m.inflight.TryAdd(key) {
+				m.inflight.Touch(key)
+			}
+
+			if !m.queue.push(j, m.spillQuit) {
+				m.spill.push(j)
+				return
+			}
+			m.inflight.Touch(key)
+		}
+	}()
+}
+
+// waitSpillLock 等待取得溢出日志的文件锁 (零停机重启时等旧进程退出)，期间队列满时直接拒绝；
+// 停止或加锁出错时返回 false
+func (m *Manager) waitSpillLock() bool {
+	t := time.NewTicker(spillLockRetry)
+	defer t.Stop()
+
+	waiting := false
+	for {
+		ok, err := m.spill.tryLock()
+		if err != nil {
+			spillLog.Error("锁定溢出日志失败, 队列满时将直接拒绝", "err", err)
+			return false
+		}
+		if ok {
+			if waiting {
+				spillLog.Info("已接管溢出日志")
+			}
+			return true
+		}
+		if !waiting {
+			spillLog.Info("溢出日志被其他进程占用, 等待其退出后接管")
+			waiting = true
+		}
+
+		select {
+		case <-t.C:
+		case <-m.spillQuit:
+			return false
+		}
+	}
+}
+
+// stopSpillFeeder 停止消费溢出日志 (需在关闭解析队列之前调用)，未处理的任务留在日志中
+func (m *Manager) stopSpillFeeder() {
+	if m.spill == nil {
+		return
+	}
+	close(m.spillQuit)
+	m.spillWg.Wait()
+}
@@ -0,0 +1,131 @@
+package worker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSpillJournalRecovery(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		count   int      // 打开时统计的条目数 (含损坏行)
+		want    []string // 依次取出的 IP
+	}{
+		{"empty", "", 0, nil},
+		{"leftover", `{"ip":"1.1.1.0"}` + "\n" + `{"ip":"2.2.2.0","request_id":"r"}` + "\n", 2, []string{"1.1.1.0", "2.2.2.0"}},
+		{"missing trailing newline", `{"ip":"1.1.1.0"}` + "\n" + `{"ip":"2.2.2.0"}`, 2, []string{"1.1.1.0", "2.2.2.0"}},
+		{"corrupt middle", `{"ip":"1.1.1.0"}` + "\n" + "garbage\n" + `{"ip":"3.3.3.0"}` + "\n", 3, []string{"1.1.1.0", "3.3.3.0"}},
+		{"corrupt last", `{"ip":"1.1.1.0"}` + "\n" + `{"ip":""}` + "\n" + "{broken\n", 3, []string{"1.1.1.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "spill.jsonl")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			s, err := openSpillJournal(path, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.close()
+			if got := s.len(); got != tt.count {
+				t.Fatalf("len = %d, want %d", got, tt.count)
+			}
+
+			var got []string
+			for {
+				j, ok := s.pop()
+				if !ok {
+					break
+				}
+				got = append(got, j.IP)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("popped %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("popped %v, want %v", got, tt.want)
+				}
+			}
+
+			// 全部取出 (含损坏行) 后文件被截断
+			if fi, err := os.Stat(path); err != nil || fi.Size() != 0 {
+				t.Fatalf("journal not truncated: size=%d err=%v", fi.Size(), err)
+			}
+		})
+	}
+}
+
+func TestSpillJournalPushPop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill.jsonl")
+	s, err := openSpillJournal(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.close()
+
+	if !s.push(job{IP: "1.1.1.0", RequestID: "a"}) || !s.push(job{IP: "2.2.2.0"}) {
+		t.Fatal("push failed")
+	}
+	if s.push(job{IP: "3.3.3.0"}) {
+		t.Fatal("push beyond max should fail")
+	}
+	if j, ok := s.pop(); !ok || j.IP != "1.1.1.0" || j.RequestID != "a" {
+		t.Fatalf("pop = %+v, %v", j, ok)
+	}
+	// 取出后继续追加，读位置不受影响
+	if !s.push(job{IP: "3.3.3.0"}) {
+		t.Fatal("push after pop failed")
+	}
+	for _, want := range []string{"2.2.2.0", "3.3.3.0"} {
+		if j, ok := s.pop(); !ok || j.IP != want {
+			t.Fatalf("pop = %+v, %v, want %s", j, ok, want)
+		}
+	}
+	if _, ok := s.pop(); ok {
+		t.Fatal("pop from empty journal")
+	}
+}
+
+// 零停机重启：旧进程持有文件锁期间新进程不读写，旧进程关闭后接管其遗留条目
+func TestSpillJournalLockHandoff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill.jsonl")
+	old, err := openSpillJournal(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !old.push(job{IP: "1.1.1.0"}) {
+		t.Fatal("push failed")
+	}
+
+	succ, err := openSpillJournal(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer succ.close()
+	if succ.locked || succ.push(job{IP: "2.2.2.0"}) {
+		t.Fatal("successor must not write while the journal is locked")
+	}
+	if _, ok := succ.pop(); ok {
+		t.Fatal("successor must not read while the journal is locked")
+	}
+
+	// 旧进程停止时未处理的任务留在日志中
+	if !old.push(job{IP: "3.3.3.0"}) {
+		t.Fatal("push failed")
+	}
+	old.close()
+
+	if ok, err := succ.tryLock(); !ok || err != nil {
+		t.Fatalf("tryLock after predecessor closed = %v, %v", ok, err)
+	}
+	for _, want := range []string{"1.1.1.0", "3.3.3.0"} {
+		if j, ok := succ.pop(); !ok || j.IP != want {
+			t.Fatalf("pop = %+v, %v, want %s", j, ok, want)
+		}
+	}
+}
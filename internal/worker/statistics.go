@@ -0,0 +1,217 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"ip-resolver/internal/cache"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// statsSampleKeys 未指定 tag 时每个 Tag 展示的 key 样例数
+const statsSampleKeys = 50
+
+// StatisticsTag 单个 Tag 的缓存分布
+type StatisticsTag struct {
+	Tag   string   `json:"tag"`
+	Count int      `json:"count"`
+	Keys  []string `json:"keys"`
+}
+
+// StatisticsReport 统计页面数据
+// 未指定 tag 时按 Tag 分页 (每个 Tag 附带前 50 个 key 样例)，指定 tag 时对该 Tag 下的 key 分页
+type StatisticsReport struct {
+	TotalItems     int             `json:"total_items"`
+	MatchedItems   int             `json:"matched_items"` // 经 tag / prefix 筛选后的条目数
+	RetriedUpdates int64           `json:"retried_updates"`
+	DroppedUpdates int64           `json:"dropped_updates"`
+	LastDrift      int64           `json:"last_drift"`
+	TotalDrift     int64           `json:"total_drift"`
+	HotKeys        []cache.HotKey  `json:"hot_keys"`
+	Tag            string          `json:"tag,omitempty"`
+	Prefix         string          `json:"prefix,omitempty"`
+	Page           int             `json:"page"`
+	PageSize       int             `json:"page_size"`
+	Total          int             `json:"total"` // 分页对象总数: Tag 数或指定 Tag 下的 key 数
+	Tags           []StatisticsTag `json:"tags"`
+}
+
+// Statistics 按 tag (精确匹配) 与 prefix (key 前缀) 筛选缓存分布并分页
+func (m *Manager) Statistics(ctx context.Context, tag, prefix string, page, pageSize int) (StatisticsReport, error) {
+	items, err := m.cache.GetAllItemsContext(ctx)
+	if err != nil {
+		return StatisticsReport{}, err
+	}
+
+	rep := StatisticsReport{
+		TotalItems:     len(items),
+		RetriedUpdates: m.cache.RetriedCount(),
+		DroppedUpdates: m.cache.DroppedCount(),
+		LastDrift:      m.cache.LastDrift(),
+		TotalDrift:     m.cache.TotalDrift(),
+		HotKeys:        m.hotKeys.Top(HotKeyTopN),
+		Tag:            tag,
+		Prefix:         prefix,
+		Page:           page,
+		PageSize:       pageSize,
+		Tags:           []StatisticsTag{},
+	}
+
+	byTag := make(map[string][]string)
+	for k, t := range items {
+		if tag != "" && t != tag {
+			continue
+		}
+		if prefix != "" && !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		byTag[t] = append(byTag[t], k)
+		rep.MatchedItems++
+	}
+
+	offset := (page - 1) * pageSize
+	if tag != "" {
+		keys := byTag[tag]
+		sort.Strings(keys)
+		rep.Total = len(keys)
+		if len(keys) > 0 {
+			rep.Tags = append(rep.Tags, StatisticsTag{Tag: tag, Count: len(keys), Keys: paginate(keys, offset, pageSize)})
+		}
+		return rep, nil
+	}
+
+	tags := make([]string, 0, len(byTag))
+	for t := range byTag {
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+	rep.Total = len(tags)
+
+	for _, t := range paginate(tags, offset, pageSize) {
+		keys := byTag[t]
+		sort.Strings(keys)
+		rep.Tags = append(rep.Tags, StatisticsTag{Tag: t, Count: len(keys), Keys: keys[:min(len(keys), statsSampleKeys)]})
+	}
+	return rep, nil
+}
+
+func paginate(s []string, offset, limit int) []string {
+	if offset >= len(s) {
+		return []string{}
+	}
+	return s[offset:min(len(s), offset+limit)]
+}
+
+// HandleStatistics 缓存统计页面，支持 ?tag= / ?prefix= 筛选与 ?page= / ?page_size= 分页
+// ?format=json 或 Accept: application/json 时返回 JSON
+func (m *Manager) HandleStatistics(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, pageSize := parsePagination(r)
+
+	rep, err := m.Statistics(r.Context(), q.Get("tag"), q.Get("prefix"), page, pageSize)
+	if err != nil {
+		cacheLog.Error("获取统计数据失败", "err", err)
+		http.Error(w, "Failed to retrieve statistics from database", http.StatusInternalServerError)
+		return
+	}
+
+	if wantsJSON(r) {
+		writeJSONBody(w, http.StatusOK, rep)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	writeStatisticsHTML(w, r, rep)
+}
+
+func writeStatisticsHTML(w http.ResponseWriter, r *http.Request, rep StatisticsReport) {
+	esc := html.EscapeString
+
+	droppedClass := ""
+	if rep.DroppedUpdates > 0 {
+		droppedClass = "warn" // 有丢弃时显示红色
+	}
+	fmt.Fprintf(w, `<html>
+<head>
+	<title>IP Cache Statistics</title>
+	<style>
+		body { font-family: sans-serif; }
+		table { border-collapse: collapse; width: 100%%; }
+		th, td { border: 1px solid #ddd; padding: 8px; text-align: left; }
+		th { background-color: #f2f2f2; }
+		.metric { margin-bottom: 20px; font-weight: bold; }
+		.warn { color: red; }
+	</style>
+</head>
+<body>
+	<h1>IP Cache Statistics</h1>
+	<div class="metric">
+		<p>Total Cached Items: %d</p>
+		<p>Retried Updates (Disk Pressure): %d</p>
+		<p>Dropped Updates (Disk Pressure): <span class="%s">%d</span></p>
+		<p>Count Drift (Last / Total): %d / %d</p>
+	</div>
+	<form method="get">
+		Tag: <input name="tag" value="%s">
+		Key Prefix: <input name="prefix" value="%s">
+		Page Size: <input name="page_size" value="%d" size="5">
+		<input type="submit" value="Filter">
+		<a href="?%s">JSON</a>
+	</form>`,
+		rep.TotalItems, rep.RetriedUpdates, droppedClass, rep.DroppedUpdates, rep.LastDrift, rep.TotalDrift,
+		esc(rep.Tag), esc(rep.Prefix), rep.PageSize, esc(withQuery(r, "format", "json")))
+
+	fmt.Fprintf(w, `
+	<h2>Hot Keys (Top %d)</h2>
+	<table>
+		<tr><th>Key</th><th>Requests (Estimated)</th></tr>`, HotKeyTopN)
+	for _, hk := range rep.HotKeys {
+		fmt.Fprintf(w, "\n\t\t<tr><td>%s</td><td>%d</td></tr>", esc(hk.Key), hk.Count)
+	}
+
+	fmt.Fprintf(w, `
+	</table>
+	<h2>Tags (Matched Items: %d)</h2>
+	<table>
+		<tr><th>Tag</th><th>IP Ranges (Count)</th></tr>`, rep.MatchedItems)
+	for _, t := range rep.Tags {
+		keys := make([]string, len(t.Keys))
+		for i, k := range t.Keys {
+			keys[i] = esc(k)
+		}
+		more := ""
+		if rep.Tag == "" && t.Count > len(t.Keys) {
+			more = fmt.Sprintf(`, <a href="?%s">... and %d others</a>`,
+				esc(withQuery(r, "tag", t.Tag)), t.Count-len(t.Keys))
+		}
+		fmt.Fprintf(w, "\n\t\t<tr><td>%s</td><td>%s%s <br/>(Count: %d)</td></tr>",
+			esc(t.Tag), strings.Join(keys, ", "), more, t.Count)
+	}
+	fmt.Fprint(w, "\n\t</table>\n\t<p>")
+
+	pages := max(1, (rep.Total+rep.PageSize-1)/rep.PageSize)
+	if rep.Page > 1 {
+		fmt.Fprintf(w, `<a href="?%s">&laquo; Prev</a> `, esc(withQuery(r, "page", fmt.Sprint(rep.Page-1))))
+	}
+	fmt.Fprintf(w, "Page %d / %d (%d total)", rep.Page, pages, rep.Total)
+	if rep.Page < pages {
+		fmt.Fprintf(w, ` <a href="?%s">Next &raquo;</a>`, esc(withQuery(r, "page", fmt.Sprint(rep.Page+1))))
+	}
+	fmt.Fprint(w, "</p>\n</body>\n</html>")
+}
+
+// withQuery 返回替换单个参数后的查询串，切换 tag 时回到第一页
+func withQuery(r *http.Request, name, value string) string {
+	q := url.Values{}
+	for k, v := range r.URL.Query() {
+		q[k] = v
+	}
+	q.Set(name, value)
+	if name == "tag" {
+		q.Del("page")
+	}
+	return q.Encode()
+}
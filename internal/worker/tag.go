@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ======== 分页参数 =========
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// HandleTag 反查携带指定 Tag 的缓存子网: GET /tag/{tag}?page=1&page_size=100
+// 数据来自 SQLite 只读连接，反映已持久化的内容
+func (m *Manager) HandleTag(w http.ResponseWriter, r *http.Request) {
+	tag := strings.TrimPrefix(r.URL.Path, "/tag/")
+	if tag == "" {
+		http.Error(w, "missing tag", http.StatusBadRequest)
+		return
+	}
+
+	page, pageSize := parsePagination(r)
+
+	keys, total, err := m.cache.KeysByTag(r.Context(), tag, (page-1)*pageSize, pageSize)
+	if err != nil {
+		cacheLog.Error("按 Tag 查询失败", "err", err)
+		http.Error(w, "Failed to query database", http.StatusInternalServerError)
+		return
+	}
+
+	subnets := make([]rangeEntry, 0, len(keys))
+	for _, key := range keys {
+		entry := rangeEntry{Key: key, Tag: tag}
+		if n := keyToSubnet(key); n != nil {
+			entry.Subnet = n.String()
+		}
+		subnets = append(subnets, entry)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(struct {
+		Tag      string       `json:"tag"`
+		Total    int          `json:"total"`
+		Page     int          `json:"page"`
+		PageSize int          `json:"page_size"`
+		Subnets  []rangeEntry `json:"subnets"`
+	}{
+		Tag:      tag,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		Subnets:  subnets,
+	})
+}
+
+// parsePagination 解析 page / page_size 参数 (page 从 1 开始)
+func parsePagination(r *http.Request) (int, int) {
+	q := r.URL.Query()
+
+	page, err := strconv.Atoi(q.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(q.Get("page_size"))
+	if err != nil || pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	return page, pageSize
+}
@@ -0,0 +1,169 @@
+package worker
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ======== 硬编码参数 =========
+const (
+	tagSnapshotInterval  = 5 * time.Minute // 记录各 Tag 条目数的间隔 (用于计算变化量)
+	tagSnapshotRetention = 24 * time.Hour  // 可查询变化量的最长时间范围
+	tagSnapshotTimeout   = 30 * time.Second
+)
+
+// tagSnapshot 某一时刻各 Tag 的缓存条目数
+type tagSnapshot struct {
+	at     time.Time
+	counts map[string]int
+}
+
+// tagHistory 定期记录的 Tag 分布，只保留 tagSnapshotRetention 内的记录
+type tagHistory struct {
+	mu    sync.Mutex
+	snaps []tagSnapshot
+}
+
+func (h *tagHistory) add(s tagSnapshot) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.snaps = append(h.snaps, s)
+	cutoff := s.at.Add(-tagSnapshotRetention)
+	i := 0
+	for i < len(h.snaps)-1 && h.snaps[i].at.Before(cutoff) {
+		i++
+	}
+	h.snaps = h.snaps[i:]
+}
+
+// baseline 返回 since 之前 (含) 最近的一次记录；since 早于保留范围时返回最早的一次
+func (h *tagHistory) baseline(since time.Time) (tagSnapshot, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.snaps) == 0 {
+		return tagSnapshot{}, false
+	}
+	i := sort.Search(len(h.snaps), func(i int) bool { return h.snaps[i].at.After(since) })
+	if i == 0 {
+		return h.snaps[0], true
+	}
+	return h.snaps[i-1], true
+}
+
+// runTagSnapshots 启动时及之后每隔 tagSnapshotInterval 记录一次 Tag 分布
+func (m *Manager) runTagSnapshots() {
+	if !m.cache.HasStore() {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(tagSnapshotInterval)
+		defer ticker.Stop()
+
+		for {
+			m.snapshotTags()
+			select {
+			case <-ticker.C:
+			case <-m.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+func (m *Manager) snapshotTags() {
+	ctx, cancel := context.WithTimeout(context.Background(), tagSnapshotTimeout)
+	defer cancel()
+
+	counts, err := m.cache.TagCounts(ctx)
+	if err != nil {
+		cacheLog.Warn("记录 Tag 分布失败", "err", err)
+		return
+	}
+	m.tagHistory.add(tagSnapshot{at: time.Now(), counts: counts})
+}
+
+// TagCount 单个 Tag 的缓存子网数，Delta 为相对基准时刻的变化量
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+	Delta *int   `json:"delta,omitempty"`
+}
+
+// TagStats /stats/tags 的响应
+type TagStats struct {
+	Time         time.Time  `json:"time"`
+	Total        int        `json:"total"`
+	BaselineTime *time.Time `json:"baseline_time,omitempty"` // 计算变化量实际使用的记录时刻
+	Tags         []TagCount `json:"tags"`
+}
+
+// HandleTagStats GET /stats/tags: 各 Tag 的缓存子网数 (按数量降序)
+// ?since= (RFC3339 或 Unix 秒) 时附带相对该时刻的变化量，基准为此前最近一次记录 (每 5 分钟一次，保留 24 小时)
+func (m *Manager) HandleTagStats(w http.ResponseWriter, r *http.Request) {
+	var since time.Time
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		t, err := parseSince(raw)
+		if err != nil {
+			http.Error(w, "invalid since, expected RFC3339 or unix seconds", http.StatusBadRequest)
+			return
+		}
+		since = t
+	}
+
+	counts, err := m.cache.TagCounts(r.Context())
+	if err != nil {
+		cacheLog.Error("获取 Tag 分布失败", "err", err)
+		http.Error(w, "Failed to retrieve statistics from database", http.StatusInternalServerError)
+		return
+	}
+
+	stats := TagStats{Time: time.Now(), Tags: make([]TagCount, 0, len(counts))}
+	var base tagSnapshot
+	hasBase := false
+	if !since.IsZero() {
+		if base, hasBase = m.tagHistory.baseline(since); hasBase {
+			stats.BaselineTime = &base.at
+		}
+	}
+
+	for tag, n := range counts {
+		stats.Total += n
+		tc := TagCount{Tag: tag, Count: n}
+		if hasBase {
+			d := n - base.counts[tag]
+			tc.Delta = &d
+		}
+		stats.Tags = append(stats.Tags, tc)
+	}
+	// 基准时刻存在、现已全部过期或被删除的 Tag
+	if hasBase {
+		for tag, n := range base.counts {
+			if _, ok := counts[tag]; !ok {
+				d := -n
+				stats.Tags = append(stats.Tags, TagCount{Tag: tag, Delta: &d})
+			}
+		}
+	}
+
+	sort.Slice(stats.Tags, func(i, j int) bool {
+		if stats.Tags[i].Count != stats.Tags[j].Count {
+			return stats.Tags[i].Count > stats.Tags[j].Count
+		}
+		return stats.Tags[i].Tag < stats.Tags[j].Tag
+	})
+	writeJSONBody(w, http.StatusOK, stats)
+}
+
+func parseSince(raw string) (time.Time, error) {
+	if sec, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
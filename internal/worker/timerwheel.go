@@ -0,0 +1,130 @@
+package worker
+
+import (
+	"sync"
+	"time"
+)
+
+// timerWheel 单协程时间轮：延迟任务按到期刻度挂在槽位上，每个刻度处理一个槽位
+// 替代每个重试一个定时器协程，大量上游故障时也只占用一个协程
+type timerWheel struct {
+	mu      sync.Mutex
+	tick    time.Duration
+	slots   [][]wheelItem
+	pos     int
+	pending int
+	stopped bool
+	fire    func(job) bool // 返回 false 表示暂时无法处理 (如队列已满)，推迟一个刻度
+	quit    chan struct{}
+	wg      sync.WaitGroup
+}
+
+type wheelItem struct {
+	rounds int // 还需转过的整圈数
+	j      job
+}
+
+func newTimerWheel(tick time.Duration, size int, fire func(job) bool) *timerWheel {
+	return &timerWheel{
+		tick:  tick,
+		slots: make([][]wheelItem, size),
+		fire:  fire,
+		quit:  make(chan struct{}),
+	}
+}
+
+func (w *timerWheel) start() {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(w.tick)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				w.advance()
+			case <-w.quit:
+				return
+			}
+		}
+	}()
+}
+
+// add 在 delay 后触发 j，时间轮已停止时返回 false
+func (w *timerWheel) add(delay time.Duration, j job) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped {
+		return false
+	}
+	w.schedule(delay, j)
+	w.pending++
+	return true
+}
+
+// schedule 将 j 挂到对应槽位 (需持有 mu)
+func (w *timerWheel) schedule(delay time.Duration, j job) {
+	ticks := max(int((delay+w.tick-1)/w.tick), 1)
+	idx := (w.pos + ticks) % len(w.slots)
+	w.slots[idx] = append(w.slots[idx], wheelItem{rounds: (ticks - 1) / len(w.slots), j: j})
+}
+
+// advance 前进一个刻度并触发到期任务
+func (w *timerWheel) advance() {
+	w.mu.Lock()
+	w.pos = (w.pos + 1) % len(w.slots)
+	var due []job
+	keep := w.slots[w.pos][:0]
+	for _, it := range w.slots[w.pos] {
+		if it.rounds > 0 {
+			it.rounds--
+			keep = append(keep, it)
+		} else {
+			due = append(due, it.j)
+		}
+	}
+	w.slots[w.pos] = keep
+	w.mu.Unlock()
+
+	for _, j := range due {
+		fired := w.fire(j)
+
+		w.mu.Lock()
+		if fired {
+			w.pending--
+		} else {
+			w.schedule(w.tick, j)
+		}
+		w.mu.Unlock()
+	}
+}
+
+// len 返回等待中的任务数
+func (w *timerWheel) len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.pending
+}
+
+// stop 停止时间轮并返回所有未触发的任务
+func (w *timerWheel) stop() []job {
+	close(w.quit)
+	w.wg.Wait()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.stopped = true
+	var jobs []job
+	for i, slot := range w.slots {
+		for _, it := range slot {
+			jobs = append(jobs, it.j)
+		}
+		w.slots[i] = nil
+	}
+	w.pending = 0
+	return jobs
+}
@@ -0,0 +1,92 @@
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+// 手动推进刻度 (不调用 start)，检查任务在第几个刻度触发
+func TestTimerWheelFiresOnTick(t *testing.T) {
+	const tick = 10 * time.Millisecond
+	const slots = 4 // 一圈 40ms
+
+	tests := []struct {
+		name  string
+		delay time.Duration
+		ticks int
+	}{
+		{"zero delay fires next tick", 0, 1},
+		{"sub-tick rounds up", 3 * time.Millisecond, 1},
+		{"exact tick", tick, 1},
+		{"partial tick rounds up", tick + 1, 2},
+		{"last slot of first round", 4 * tick, 4},
+		{"wraps once", 5 * tick, 5},
+		{"exactly two rounds", 8 * tick, 8},
+		{"wraps twice", 9 * tick, 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fired := 0
+			w := newTimerWheel(tick, slots, func(job) bool { fired++; return true })
+			// 从非零位置开始，覆盖槽位下标回绕
+			for i := 0; i < 3; i++ {
+				w.advance()
+			}
+			w.add(tt.delay, job{IP: "1.1.1.0"})
+
+			for i := 1; i <= tt.ticks; i++ {
+				w.advance()
+				if i < tt.ticks && fired != 0 {
+					t.Fatalf("fired early at tick %d", i)
+				}
+			}
+			if fired != 1 || w.len() != 0 {
+				t.Fatalf("after %d ticks: fired = %d, pending = %d", tt.ticks, fired, w.len())
+			}
+		})
+	}
+}
+
+// fire 返回 false (队列已满) 时推迟一个刻度，成功前保持等待
+func TestTimerWheelPostpone(t *testing.T) {
+	accept := false
+	fired := 0
+	w := newTimerWheel(time.Millisecond, 2, func(job) bool {
+		if !accept {
+			return false
+		}
+		fired++
+		return true
+	})
+	w.add(time.Millisecond, job{IP: "1.1.1.0"})
+
+	for i := 0; i < 5; i++ {
+		w.advance()
+	}
+	if fired != 0 || w.len() != 1 {
+		t.Fatalf("fired = %d, pending = %d", fired, w.len())
+	}
+
+	accept = true
+	w.advance()
+	if fired != 1 || w.len() != 0 {
+		t.Fatalf("fired = %d, pending = %d", fired, w.len())
+	}
+}
+
+func TestTimerWheelStop(t *testing.T) {
+	w := newTimerWheel(time.Hour, 4, func(job) bool { return true })
+	w.start()
+	w.add(time.Hour, job{IP: "1.1.1.0"})
+	w.add(100*time.Hour, job{IP: "2.2.2.0"}) // 多圈之后
+
+	if jobs := w.stop(); len(jobs) != 2 {
+		t.Fatalf("stop returned %d jobs, want 2", len(jobs))
+	}
+	if w.add(time.Second, job{IP: "3.3.3.0"}) {
+		t.Fatal("add after stop should fail")
+	}
+	if w.len() != 0 {
+		t.Fatalf("pending = %d after stop", w.len())
+	}
+}
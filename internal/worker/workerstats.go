@@ -0,0 +1,105 @@
+package worker
+
+import (
+	"ip-resolver/internal/monitor"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// workerStat 单个 Worker 的累计统计
+type workerStat struct {
+	id         int
+	processed  atomic.Int64 // 处理的任务数 (含已缓存而跳过的任务)
+	fetched    atomic.Int64 // 调用上游次数
+	errors     atomic.Int64 // 上游失败与 panic 次数
+	totalNanos atomic.Int64 // 上游调用累计耗时
+	busySince  atomic.Int64 // 当前任务开始时间 (UnixNano)，0 为空闲
+	current    atomic.Value // 当前处理的 IP
+}
+
+func (s *workerStat) begin(ip string) {
+	s.current.Store(ip)
+	s.busySince.Store(time.Now().UnixNano())
+}
+
+func (s *workerStat) end() {
+	s.busySince.Store(0)
+	s.current.Store("")
+	s.processed.Add(1)
+}
+
+func (s *workerStat) observe(latency time.Duration, failed bool) {
+	s.fetched.Add(1)
+	s.totalNanos.Add(int64(latency))
+	if failed {
+		s.errors.Add(1)
+	}
+}
+
+// workerStatSet 运行中 Worker 的统计，Worker 退出 (缩容) 后移除
+type workerStatSet struct {
+	mu    sync.RWMutex
+	stats map[int]*workerStat
+}
+
+func (s *workerStatSet) register(id int) *workerStat {
+	st := &workerStat{id: id}
+	st.current.Store("")
+
+	s.mu.Lock()
+	if s.stats == nil {
+		s.stats = make(map[int]*workerStat)
+	}
+	s.stats[id] = st
+	s.mu.Unlock()
+	return st
+}
+
+func (s *workerStatSet) unregister(id int) {
+	s.mu.Lock()
+	delete(s.stats, id)
+	s.mu.Unlock()
+}
+
+// busy 返回正在处理任务的 Worker 数
+func (s *workerStatSet) busy() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n := 0
+	for _, st := range s.stats {
+		if st.busySince.Load() > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// WorkerStats 返回每个运行中 Worker 的处理数、错误数、平均耗时与当前任务，供 /status 展示
+func (m *Manager) WorkerStats() []monitor.WorkerStats {
+	m.workerStats.mu.RLock()
+	res := make([]monitor.WorkerStats, 0, len(m.workerStats.stats))
+	now := time.Now().UnixNano()
+	for _, st := range m.workerStats.stats {
+		ws := monitor.WorkerStats{
+			ID:        st.id,
+			Processed: st.processed.Load(),
+			Fetched:   st.fetched.Load(),
+			Errors:    st.errors.Load(),
+		}
+		if n := ws.Fetched; n > 0 {
+			ws.AvgLatencyMs = float64(st.totalNanos.Load()) / float64(n) / float64(time.Millisecond)
+		}
+		if since := st.busySince.Load(); since > 0 {
+			ws.BusyMs = (now - since) / int64(time.Millisecond)
+			ws.CurrentIP, _ = st.current.Load().(string)
+		}
+		res = append(res, ws)
+	}
+	m.workerStats.mu.RUnlock()
+
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res
+}
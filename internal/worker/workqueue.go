@@ -0,0 +1,120 @@
+package worker
+
+import (
+	"sync/atomic"
+)
+
+/*
+workQueue：
+- 分片的解析队列，入队按轮询分散到各分片，降低高并发入队时单个 channel 的锁竞争
+- 每个 Worker 固定从 id % 分片数 的分片取任务，为空时从其他分片窃取
+- 分片数大于 1 时，入队同时向 notify 发送信号，唤醒一个阻塞在其他分片上的空闲 Worker 来窃取
+- 分片数为 1 时等同于单个 channel
+*/
+type workQueue struct {
+	shards []chan job
+	notify chan struct{} // 容量 1，多次入队合并为一次唤醒；未分片时为 nil
+	next   atomic.Uint32
+	closed atomic.Bool
+}
+
+// newWorkQueue 总容量 size 平均分配到 shards 个分片 (向上取整)
+func newWorkQueue(size, shards int) *workQueue {
+	shards = max(shards, 1)
+	per := max((size+shards-1)/shards, 1)
+
+	q := &workQueue{shards: make([]chan job, shards)}
+	for i := range q.shards {
+		q.shards[i] = make(chan job, per)
+	}
+	if shards > 1 {
+		q.notify = make(chan struct{}, 1)
+	}
+	return q
+}
+
+// signal 唤醒一个等待窃取的空闲 Worker (已有未消费的信号时合并)
+func (q *workQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// tryPush 从轮询到的分片开始依次尝试入队，全部已满时返回 false
+func (q *workQueue) tryPush(j job) bool {
+	n := len(q.shards)
+	start := int(q.next.Add(1)) % n
+	for i := 0; i < n; i++ {
+		select {
+		case q.shards[(start+i)%n] <- j:
+			q.signal()
+			return true
+		default:
+		}
+	}
+	return false
+}
+
+// push 入队，全部分片已满时阻塞在轮询到的分片上，quit 关闭时返回 false
+func (q *workQueue) push(j job, quit <-chan struct{}) bool {
+	if q.tryPush(j) {
+		return true
+	}
+
+	select {
+	case q.shards[int(q.next.Add(1))%len(q.shards)] <- j:
+		q.signal()
+		return true
+	case <-quit:
+		return false
+	}
+}
+
+// tryPop 优先从 home 分片取任务，为空时窃取其他分片，全部为空时返回 false
+func (q *workQueue) tryPop(home int) (job, bool) {
+	n := len(q.shards)
+	for i := 0; i < n; i++ {
+		select {
+		case j, ok := <-q.shards[(home+i)%n]:
+			if ok {
+				return j, true
+			}
+		default:
+		}
+	}
+	return job{}, false
+}
+
+// home 返回 Worker 固定使用的分片
+func (q *workQueue) home(id int) chan job {
+	return q.shards[id%len(q.shards)]
+}
+
+func (q *workQueue) sharded() bool {
+	return len(q.shards) > 1
+}
+
+func (q *workQueue) len() int {
+	n := 0
+	for _, s := range q.shards {
+		n += len(s)
+	}
+	return n
+}
+
+func (q *workQueue) cap() int {
+	return cap(q.shards[0]) * len(q.shards)
+}
+
+// close 关闭所有分片，之后 tryPop 仍可取出剩余任务
+func (q *workQueue) close() {
+	q.closed.Store(true)
+	for _, s := range q.shards {
+		close(s)
+	}
+}
+
+func (q *workQueue) isClosed() bool {
+	return q.closed.Load()
+}
@@ -0,0 +1,131 @@
+package worker
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestWorkQueueCapacity(t *testing.T) {
+	tests := []struct {
+		size, shards int
+		wantCap      int
+		sharded      bool
+	}{
+		{4096, 1, 4096, false},
+		{4096, 0, 4096, false}, // 非法分片数按 1 处理
+		{10, 4, 12, true},      // 每个分片向上取整
+		{1, 8, 8, true},
+	}
+	for _, tt := range tests {
+		q := newWorkQueue(tt.size, tt.shards)
+		if q.cap() != tt.wantCap || q.sharded() != tt.sharded || (q.notify != nil) != tt.sharded {
+			t.Errorf("newWorkQueue(%d, %d): cap=%d sharded=%v notify=%v", tt.size, tt.shards, q.cap(), q.sharded(), q.notify != nil)
+		}
+	}
+}
+
+// 任务落在其他分片时，任意 home 都能窃取到，且不丢失任何任务
+func TestWorkQueueSteal(t *testing.T) {
+	tests := []struct {
+		shards, jobs, home int
+	}{
+		{1, 3, 0},
+		{4, 1, 0},
+		{4, 7, 2},
+		{4, 16, 3},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d shards %d jobs", tt.shards, tt.jobs), func(t *testing.T) {
+			q := newWorkQueue(64, tt.shards)
+			for i := 0; i < tt.jobs; i++ {
+				if !q.tryPush(job{IP: fmt.Sprintf("10.0.%d.0", i)}) {
+					t.Fatal("push failed")
+				}
+			}
+
+			seen := make(map[string]bool)
+			for {
+				j, ok := q.tryPop(tt.home)
+				if !ok {
+					break
+				}
+				seen[j.IP] = true
+			}
+			if len(seen) != tt.jobs || q.len() != 0 {
+				t.Fatalf("popped %d distinct jobs, want %d (left %d)", len(seen), tt.jobs, q.len())
+			}
+		})
+	}
+}
+
+func TestWorkQueueTryPushFull(t *testing.T) {
+	q := newWorkQueue(4, 2)
+	for i := 0; i < 4; i++ {
+		if !q.tryPush(job{IP: "1.1.1.0"}) {
+			t.Fatalf("push %d failed before full", i)
+		}
+	}
+	if q.tryPush(job{IP: "1.1.1.0"}) {
+		t.Fatal("push into full queue succeeded")
+	}
+
+	// push 在全部分片已满时阻塞，quit 关闭后返回 false
+	quit := make(chan struct{})
+	close(quit)
+	if q.push(job{IP: "1.1.1.0"}, quit) {
+		t.Fatal("blocking push should give up on quit")
+	}
+}
+
+// 入队信号唤醒等待中的 Worker，多次入队合并为一个信号
+func TestWorkQueueNotify(t *testing.T) {
+	q := newWorkQueue(16, 4)
+	for i := 0; i < 3; i++ {
+		q.tryPush(job{IP: "1.1.1.0"})
+	}
+
+	select {
+	case <-q.notify:
+	case <-time.After(time.Second):
+		t.Fatal("no wake-up signal after push")
+	}
+	select {
+	case <-q.notify:
+		t.Fatal("signals should coalesce")
+	default:
+	}
+
+	q.signal()
+	q.signal()
+	if len(q.notify) != 1 {
+		t.Fatalf("pending signals = %d, want 1", len(q.notify))
+	}
+
+	// 未分片时不发送信号
+	single := newWorkQueue(4, 1)
+	single.tryPush(job{IP: "1.1.1.0"})
+	single.signal()
+	if single.notify != nil {
+		t.Fatal("unsharded queue should not allocate notify")
+	}
+}
+
+func TestWorkQueueCloseDrains(t *testing.T) {
+	q := newWorkQueue(8, 2)
+	q.tryPush(job{IP: "1.1.1.0"})
+	q.tryPush(job{IP: "2.2.2.0"})
+	q.close()
+
+	if !q.isClosed() {
+		t.Fatal("isClosed = false after close")
+	}
+	for i := 0; i < 2; i++ {
+		if _, ok := q.tryPop(1); !ok {
+			t.Fatalf("remaining job %d not drained after close", i)
+		}
+	}
+	if _, ok := q.tryPop(0); ok {
+		t.Fatal("pop from drained queue")
+	}
+}
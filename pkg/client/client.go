@@ -0,0 +1,290 @@
+// Package client 是 ip-resolver HTTP 接口的 Go 客户端
+//
+// 支持 TCP 与 Unix Socket，未命中 (202) 时自动重试，队列繁忙 (503) 时遵循 Retry-After。
+//
+//	c, err := client.New(client.Options{BaseURL: "unix:///var/run/ip-resolver.sock"})
+//	res, err := c.Resolve(ctx, "1.1.1.1")
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrPending 重试次数用尽后仍未解析完成
+var ErrPending = errors.New("ip-resolver: 解析尚未完成")
+
+// ======== 默认参数 =========
+const (
+	DefaultTimeout       = 3 * time.Second
+	DefaultMaxRetries    = 5
+	DefaultRetryInterval = 200 * time.Millisecond
+	maxRetryInterval     = 2 * time.Second
+)
+
+// Options 客户端配置
+type Options struct {
+	// BaseURL 为 API 地址，如 http://127.0.0.1:8080 或 unix:///var/run/ip-resolver.sock
+	BaseURL string
+	// MonitorURL 为监控地址 (Status 使用)，如 http://127.0.0.1:9090
+	MonitorURL string
+	// LookupPrefix 为单 IP 查询的路由前缀 (对应服务端 lookup_path_prefix)，默认 /
+	LookupPrefix string
+	// Timeout 为单次 HTTP 请求超时
+	Timeout time.Duration
+	// MaxRetries 为未命中 (202) 或繁忙 (503) 时的最大重试次数，负数表示不重试
+	MaxRetries int
+	// RetryInterval 为首次重试间隔，之后指数增长 (最长 2s)，503 时优先使用 Retry-After
+	RetryInterval time.Duration
+	// HTTPClient 自定义 HTTP 客户端 (可选，BaseURL 为 unix:// 时忽略其 Transport)
+	HTTPClient *http.Client
+}
+
+// Client ip-resolver 客户端，可并发使用
+type Client struct {
+	baseURL    string
+	monitorURL string
+	prefix     string
+	http       *http.Client
+	monitor    *http.Client // 监控端口仅支持 TCP，不共用 Unix Socket Transport
+	maxRetries int
+	interval   time.Duration
+}
+
+// New 创建客户端
+func New(opts Options) (*Client, error) {
+	if opts.BaseURL == "" {
+		return nil, errors.New("ip-resolver: BaseURL 不能为空")
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = DefaultMaxRetries
+	}
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = DefaultRetryInterval
+	}
+
+	hc := &http.Client{}
+	if opts.HTTPClient != nil {
+		*hc = *opts.HTTPClient
+	}
+	hc.Timeout = opts.Timeout
+	mc := &http.Client{Transport: hc.Transport, Timeout: opts.Timeout}
+
+	prefix := "/"
+	if p := strings.Trim(opts.LookupPrefix, "/"); p != "" {
+		prefix = "/" + p + "/"
+	}
+
+	baseURL := strings.TrimRight(opts.BaseURL, "/")
+	if socketPath, ok := strings.CutPrefix(baseURL, "unix://"); ok {
+		hc.Transport = unixTransport(socketPath)
+		baseURL = "http://unix"
+	}
+
+	return &Client{
+		baseURL:    baseURL,
+		monitorURL: strings.TrimRight(opts.MonitorURL, "/"),
+		prefix:     prefix,
+		http:       hc,
+		monitor:    mc,
+		maxRetries: opts.MaxRetries,
+		interval:   opts.RetryInterval,
+	}, nil
+}
+
+func unixTransport(socketPath string) *http.Transport {
+	var d net.Dialer
+	return &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return d.DialContext(ctx, "unix", socketPath)
+		},
+		MaxIdleConns:    100,
+		IdleConnTimeout: 90 * time.Second,
+	}
+}
+
+// StatusError 服务端返回的非预期状态码
+type StatusError struct {
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("ip-resolver: 状态码 %d: %s", e.Code, e.Body)
+}
+
+// Resolve 查询单个 IP，未命中时按重试策略等待解析完成
+// 重试用尽仍未完成时返回最后一次结果与 ErrPending
+func (c *Client) Resolve(ctx context.Context, ip string) (*Result, error) {
+	u := c.baseURL + c.prefix + url.PathEscape(ip) + "?format=json"
+
+	var last *Result
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		var wait time.Duration
+		switch resp.StatusCode {
+		case http.StatusOK, http.StatusAccepted, http.StatusServiceUnavailable:
+			var res Result
+			err := json.NewDecoder(resp.Body).Decode(&res)
+			resp.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("ip-resolver: 解析响应失败: %w", err)
+			}
+			if resp.StatusCode == http.StatusOK {
+				return &res, nil
+			}
+			last = &res
+			wait = retryAfter(resp)
+		default:
+			return nil, readStatusError(resp)
+		}
+
+		if attempt >= c.maxRetries {
+			return last, ErrPending
+		}
+		if err := c.sleep(ctx, attempt, wait); err != nil {
+			return last, err
+		}
+	}
+}
+
+// BatchResolve 批量查询，未命中的 IP 会按重试策略重新查询
+// 非法 IP 的错误信息在对应 BatchItem.Error 中返回
+func (c *Client) BatchResolve(ctx context.Context, ips []string) (map[string]BatchItem, error) {
+	results := make(map[string]BatchItem, len(ips))
+	pending := ips
+
+	for attempt := 0; len(pending) > 0; attempt++ {
+		body, err := json.Marshal(pending)
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/batch", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, readStatusError(resp)
+		}
+
+		var batch map[string]BatchItem
+		err = json.NewDecoder(resp.Body).Decode(&batch)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("ip-resolver: 解析响应失败: %w", err)
+		}
+
+		pending = pending[:0:0]
+		for ip, item := range batch {
+			results[ip] = item
+			if item.Cache == CacheMiss || item.Cache == CacheRejected {
+				pending = append(pending, ip)
+			}
+		}
+
+		if len(pending) == 0 {
+			break
+		}
+		if attempt >= c.maxRetries {
+			return results, ErrPending
+		}
+		if err := c.sleep(ctx, attempt, 0); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// Status 查询监控端口的健康状态 (不健康时同样返回 Status，Healthy 为 false)
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	if c.monitorURL == "" {
+		return nil, errors.New("ip-resolver: 未配置 MonitorURL")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.monitorURL+"/status", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.monitor.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusInternalServerError {
+		return nil, readStatusError(resp)
+	}
+
+	var st Status
+	if err := json.NewDecoder(resp.Body).Decode(&st); err != nil {
+		return nil, fmt.Errorf("ip-resolver: 解析响应失败: %w", err)
+	}
+	return &st, nil
+}
+
+// sleep 等待下一次重试，wait 为服务端建议的间隔 (Retry-After)
+func (c *Client) sleep(ctx context.Context, attempt int, wait time.Duration) error {
+	if wait <= 0 {
+		wait = c.interval << attempt
+		if wait > maxRetryInterval || wait <= 0 {
+			wait = maxRetryInterval
+		}
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func retryAfter(resp *http.Response) time.Duration {
+	secs, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || secs <= 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+func readStatusError(resp *http.Response) error {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return &StatusError{Code: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+}
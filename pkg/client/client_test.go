@@ -0,0 +1,236 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestClient 启动 httptest Server 并创建指向它的客户端，重试间隔缩短到 1ms
+func newTestClient(t *testing.T, h http.HandlerFunc, opts Options) *Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	opts.BaseURL = srv.URL
+	if opts.RetryInterval == 0 {
+		opts.RetryInterval = time.Millisecond
+	}
+	c, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// writeResult 以 status 返回单 IP 查询结果
+func writeResult(w http.ResponseWriter, status int, res Result) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(res)
+}
+
+// 未命中 (202) 时重试，直到解析完成
+func TestResolveRetryOnAccepted(t *testing.T) {
+	var calls int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path != "/1.1.1.1" || r.URL.Query().Get("format") != "json" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if calls < 3 {
+			writeResult(w, http.StatusAccepted, Result{IP: "1.1.1.1", Cache: CacheMiss})
+			return
+		}
+		writeResult(w, http.StatusOK, Result{IP: "1.1.1.1", Tag: "GD_CT", Cache: CacheHit})
+	}, Options{})
+
+	res, err := c.Resolve(context.Background(), "1.1.1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 || res.Tag != "GD_CT" || res.Cache != CacheHit {
+		t.Fatalf("calls = %d, result = %+v", calls, res)
+	}
+}
+
+// 队列繁忙 (503) 时按 Retry-After 等待，而非较短的默认重试间隔
+func TestResolveRetryAfter(t *testing.T) {
+	var (
+		calls int
+		gap   time.Duration
+		last  time.Time
+	)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			last = time.Now()
+			w.Header().Set("Retry-After", "1")
+			writeResult(w, http.StatusServiceUnavailable, Result{IP: "1.1.1.1", Cache: CacheRejected})
+			return
+		}
+		gap = time.Since(last)
+		writeResult(w, http.StatusOK, Result{IP: "1.1.1.1", Tag: "GD_CT", Cache: CacheHit})
+	}, Options{})
+
+	if _, err := c.Resolve(context.Background(), "1.1.1.1"); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 || gap < time.Second {
+		t.Fatalf("calls = %d, retried after %v, want >= 1s", calls, gap)
+	}
+}
+
+// 重试用尽仍未完成时返回最后一次结果与 ErrPending
+func TestResolvePending(t *testing.T) {
+	var calls int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		writeResult(w, http.StatusAccepted, Result{IP: "1.1.1.1", Key: "1.1.1.0/24", Cache: CacheMiss})
+	}, Options{MaxRetries: 2})
+
+	res, err := c.Resolve(context.Background(), "1.1.1.1")
+	if !errors.Is(err, ErrPending) {
+		t.Fatalf("err = %v, want ErrPending", err)
+	}
+	if calls != 3 || res == nil || res.Key != "1.1.1.0/24" {
+		t.Fatalf("calls = %d, result = %+v", calls, res)
+	}
+}
+
+// 非预期的状态码以 StatusError 返回，不重试
+func TestResolveStatusError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad ip", http.StatusBadRequest)
+	}, Options{})
+
+	_, err := c.Resolve(context.Background(), "x")
+	var se *StatusError
+	if !errors.As(err, &se) || se.Code != http.StatusBadRequest || se.Body != "bad ip" {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+// 批量查询只重新提交 MISS 与 REJECTED 的 IP，命中与出错的结果保留
+func TestBatchResolve(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests [][]string
+	)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/batch" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		var ips []string
+		if err := json.NewDecoder(r.Body).Decode(&ips); err != nil {
+			t.Error(err)
+		}
+		slices.Sort(ips)
+		mu.Lock()
+		requests = append(requests, ips)
+		first := len(requests) == 1
+		mu.Unlock()
+
+		out := make(map[string]BatchItem, len(ips))
+		for _, ip := range ips {
+			out[ip] = BatchItem{Tag: "GD_CT", Cache: CacheHit}
+		}
+		if first {
+			out["2.2.2.2"] = BatchItem{Cache: CacheMiss}
+			out["3.3.3.3"] = BatchItem{Cache: CacheRejected}
+			out["bad"] = BatchItem{Error: "invalid ip"}
+		}
+		json.NewEncoder(w).Encode(out)
+	}, Options{})
+
+	res, err := c.BatchResolve(context.Background(), []string{"1.1.1.1", "2.2.2.2", "3.3.3.3", "bad"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"1.1.1.1", "2.2.2.2", "3.3.3.3", "bad"}, {"2.2.2.2", "3.3.3.3"}}
+	if !slices.EqualFunc(requests, want, slices.Equal) {
+		t.Fatalf("requests = %v, want %v", requests, want)
+	}
+	for _, ip := range []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"} {
+		if res[ip].Cache != CacheHit {
+			t.Fatalf("%s = %+v", ip, res[ip])
+		}
+	}
+	if res["bad"].Error != "invalid ip" {
+		t.Fatalf("bad = %+v", res["bad"])
+	}
+}
+
+// 批量查询重试用尽时返回已有结果与 ErrPending
+func TestBatchResolvePending(t *testing.T) {
+	var calls int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		json.NewEncoder(w).Encode(map[string]BatchItem{"1.1.1.1": {Cache: CacheMiss}})
+	}, Options{MaxRetries: 1})
+
+	res, err := c.BatchResolve(context.Background(), []string{"1.1.1.1"})
+	if !errors.Is(err, ErrPending) {
+		t.Fatalf("err = %v, want ErrPending", err)
+	}
+	if calls != 2 || res["1.1.1.1"].Cache != CacheMiss {
+		t.Fatalf("calls = %d, result = %+v", calls, res)
+	}
+}
+
+// LookupPrefix 对应服务端 lookup_path_prefix，前后的 / 可省略
+func TestLookupPrefix(t *testing.T) {
+	for _, prefix := range []string{"", "/", "ip", "/ip/"} {
+		want := "/1.1.1.1"
+		if prefix != "" && prefix != "/" {
+			want = "/ip/1.1.1.1"
+		}
+		t.Run(prefix, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != want {
+					http.NotFound(w, r)
+					return
+				}
+				writeResult(w, http.StatusOK, Result{IP: "1.1.1.1", Cache: CacheHit})
+			}, Options{LookupPrefix: prefix})
+
+			if _, err := c.Resolve(context.Background(), "1.1.1.1"); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// BaseURL 为 unix:// 时通过 Unix Socket 连接
+func TestUnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "ip-resolver.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix socket unavailable: %v", err)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeResult(w, http.StatusOK, Result{IP: "1.1.1.1", Tag: "GD_CT", Cache: CacheHit})
+	})}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	c, err := New(Options{BaseURL: "unix://" + sock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Resolve(context.Background(), "1.1.1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Tag != "GD_CT" {
+		t.Fatalf("result = %+v", res)
+	}
+}
@@ -0,0 +1,37 @@
+package client
+
+import "ip-resolver/internal/monitor"
+
+// 缓存状态 (与服务端一致)
+const (
+	CacheHit      = "HIT"
+	CacheRefresh  = "REFRESH"
+	CacheMiss     = "MISS"
+	CacheRejected = "REJECTED"
+)
+
+// Result 单个 IP 的查询结果
+type Result struct {
+	IP       string `json:"ip"`
+	Key      string `json:"key"`
+	Tag      string `json:"tag,omitempty"`
+	Province string `json:"province,omitempty"`
+	ISP      string `json:"isp,omitempty"`
+	Cache    string `json:"cache"`
+	TTL      int64  `json:"ttl"` // 剩余有效期 (秒)
+}
+
+// BatchItem 批量查询中单个 IP 的结果
+type BatchItem struct {
+	Key   string `json:"key,omitempty"`
+	Tag   string `json:"tag,omitempty"`
+	Cache string `json:"cache,omitempty"`
+	TTL   int64  `json:"ttl,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Status 监控端口 /status 的返回，与服务端使用同一类型，字段不会漂移
+type Status = monitor.Status
+
+// StatusData /status 的 data 字段 (服务端 monitor.Snapshot)
+type StatusData = monitor.Snapshot
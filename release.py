@@ -1,5 +1,6 @@
 # !/usr/bin/env python3
 import argparse
+import datetime
 import logging
 import os
 import subprocess
@@ -7,7 +8,7 @@ import zipfile
 
 # --- 配置部分 ---
 PROJECT_NAME = 'ip-resolver'          # 项目名称
-ENTRY_POINT = '../cmd/server/main.go' # 编译入口 (相对于 release 目录)
+ENTRY_POINT = '../cmd/server'         # 编译入口包 (相对于 release 目录)
 CONFIG_FILE = '../config.yaml'        # 配置文件路径 (相对于 release 目录)
 RELEASE_DIR = './release'             # 输出目录
 
@@ -24,8 +25,21 @@ args = parser.parse_args()
 
 logger = logging.getLogger(__name__)
 
+def version_ldflags():
+    # 注入版本号 (发布流程中的 TAG_NAME)、git commit 与编译时间，运行时可通过 /version 查看
+    version = os.environ.get('TAG_NAME', 'dev')
+    try:
+        commit = subprocess.check_output(['git', 'rev-parse', 'HEAD'], text=True).strip()
+    except Exception:
+        commit = ''
+    build_time = datetime.datetime.now(datetime.timezone.utc).strftime('%Y-%m-%dT%H:%M:%SZ')
+
+    pkg = 'ip-resolver/internal/version'
+    return f'-X {pkg}.Version={version} -X {pkg}.Commit={commit} -X {pkg}.BuildTime={build_time}'
+
 def go_build():
     logger.info(f'🚀 开始编译 {PROJECT_NAME} ...')
+    ldflags = version_ldflags()
 
     # 检查配置文件是否存在
     if not os.path.exists(CONFIG_FILE):
@@ -52,7 +66,7 @@ def go_build():
             # 构造编译命令
             # -s -w: 去掉调试信息，减小体积
             # -trimpath: 移除文件系统路径信息
-            cmd = f'go build -ldflags "-s -w" -trimpath -o {bin_filename} {ENTRY_POINT}'
+            cmd = f'go build -ldflags "-s -w {ldflags}" -trimpath -o {bin_filename} {ENTRY_POINT}'
             
             subprocess.check_call(cmd, shell=True, env=os_env)
 